@@ -0,0 +1,257 @@
+package beku
+
+import (
+	"errors"
+	"fmt"
+
+	"k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/meta"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/client-go/kubernetes"
+	"k8s.io/client-go/kubernetes/scheme"
+)
+
+// Bundle include a group of Kubernetes resource objects and error,
+// the objects keep the order in which they were added.
+type Bundle struct {
+	objs []runtime.Object
+	err  error
+}
+
+// NewBundle create Bundle and chain function call begin with this function.
+func NewBundle() *Bundle { return &Bundle{} }
+
+// Finish Chain function call end with this function
+// return Kubernetes resource objects and error.
+func (obj *Bundle) Finish() ([]runtime.Object, error) {
+	obj.verify()
+	return obj.objs, obj.err
+}
+
+// Add add beku builders or Kubernetes resource objects into Bundle,
+// the builder will be finished when it is added, so you should add it after all settings are completed.
+// builder support: Deployment,StatefulSet,DaemonSet,Pod,Service,ConfigMap,Secret,PersistentVolume,
+// PersistentVolumeClaim,StorageClass,ServiceAccount,ClusterRole,ClusterRoleBinding,Role,RoleBinding,Namespace,Ingress,Job,CronJob,HPA,PDB,NetworkPolicy,ResourceQuota,LimitRange,Endpoints,PriorityClass,UnionPV,UnionRBAC
+func (obj *Bundle) Add(items ...interface{}) *Bundle {
+	for _, item := range items {
+		objs, err := finishObject(item)
+		if err != nil {
+			obj.error(err)
+			return obj
+		}
+		obj.objs = append(obj.objs, objs...)
+	}
+	return obj
+}
+
+// Get get Kubernetes resource object from Bundle by kind, namespace and name,
+// return nil when it does not exist.
+func (obj *Bundle) Get(kind, namespace, name string) runtime.Object {
+	for _, o := range obj.objs {
+		accessor, err := meta.Accessor(o)
+		if err != nil {
+			continue
+		}
+		if o.GetObjectKind().GroupVersionKind().Kind == kind &&
+			accessor.GetNamespace() == namespace && accessor.GetName() == name {
+			return o
+		}
+	}
+	return nil
+}
+
+// CheckPriorityClasses check every PriorityClass which is referenced by workload in Bundle
+// exists in Bundle or on Kubernetes, Kubernetes is only requested when it is not in Bundle.
+func (obj *Bundle) CheckPriorityClasses() error {
+	objs, err := obj.Finish()
+	if err != nil {
+		return err
+	}
+	var client *kubernetes.Clientset
+	for _, o := range objs {
+		name := podPriorityClassName(o)
+		if !verifyString(name) || systemPriorityClasses[name] || obj.Get("PriorityClass", "", name) != nil {
+			continue
+		}
+		if client == nil {
+			if client, err = GetKubeClient(); err != nil {
+				return fmt.Errorf("PriorityClass %s is not found in Bundle,%v", name, err)
+			}
+		}
+		if err = priorityClassExists(client, name); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (obj *Bundle) error(err error) {
+	if obj.err != nil {
+		return
+	}
+	obj.err = err
+}
+
+// verify check Bundle necessary value, input the default field and input related data.
+func (obj *Bundle) verify() {
+	if obj.err != nil {
+		return
+	}
+	if len(obj.objs) <= 0 {
+		obj.err = errors.New("Bundle is not allowed to be empty,you can call Add() input")
+		return
+	}
+	obj.error(obj.renameHashedConfigs())
+}
+
+// renameHashedConfigs rename the references of workloads, Ingresses and ServiceAccounts to the ConfigMaps and Secrets
+// which have name suffix hash in Bundle, they reference them by the name without hash
+func (obj *Bundle) renameHashedConfigs() error {
+	// renames is map[namespace]map[kind]map[baseName]hashedName
+	renames := make(map[string]map[string]map[string]string)
+	for _, o := range obj.objs {
+		var kind string
+		switch o.(type) {
+		case *v1.ConfigMap:
+			kind = "ConfigMap"
+		case *v1.Secret:
+			kind = "Secret"
+		default:
+			continue
+		}
+		accessor, err := meta.Accessor(o)
+		if err != nil {
+			return err
+		}
+		base := accessor.GetLabels()[NameHashBaseKey]
+		if !verifyString(base) || base == accessor.GetName() {
+			continue
+		}
+		namespace := defaultNamespace(accessor.GetNamespace())
+		if renames[namespace] == nil {
+			renames[namespace] = make(map[string]map[string]string)
+		}
+		if renames[namespace][kind] == nil {
+			renames[namespace][kind] = make(map[string]string)
+		}
+		if name, ok := renames[namespace][kind][base]; ok && name != accessor.GetName() {
+			return fmt.Errorf("Bundle has two generations %s and %s of %s %s/%s", name, accessor.GetName(), kind, namespace, base)
+		}
+		renames[namespace][kind][base] = accessor.GetName()
+	}
+	if len(renames) <= 0 {
+		return nil
+	}
+	for _, o := range obj.objs {
+		accessor, err := meta.Accessor(o)
+		if err != nil {
+			return err
+		}
+		renameConfigReferences(o, renames[defaultNamespace(accessor.GetNamespace())])
+	}
+	return nil
+}
+
+// defaultNamespace return "default" when namespace is empty
+func defaultNamespace(namespace string) string {
+	if !verifyString(namespace) {
+		return "default"
+	}
+	return namespace
+}
+
+// finishObject finish beku builder and return Kubernetes resource objects
+func finishObject(item interface{}) ([]runtime.Object, error) {
+	var (
+		o   runtime.Object
+		err error
+	)
+	switch v := item.(type) {
+	case *Deployment:
+		return v.objects()
+	case *StatefulSet:
+		return v.objects()
+	case *DaemonSet:
+		o, err = v.Finish()
+	case *Pod:
+		o, err = v.Finish()
+	case *Service:
+		return v.objects()
+	case *ConfigMap:
+		o, err = v.Finish()
+	case *Secret:
+		o, err = v.Finish()
+	case *PersistentVolume:
+		o, err = v.Finish()
+	case *PersistentVolumeClaim:
+		o, err = v.Finish()
+	case *StorageClass:
+		o, err = v.Finish()
+	case *ServiceAccount:
+		o, err = v.Finish()
+	case *ClusterRole:
+		o, err = v.Finish()
+	case *ClusterRoleBinding:
+		o, err = v.Finish()
+	case *Role:
+		o, err = v.Finish()
+	case *RoleBinding:
+		o, err = v.Finish()
+	case *Namespace:
+		return v.objects()
+	case *Ingress:
+		o, err = v.Finish()
+	case *Job:
+		o, err = v.Finish()
+	case *CronJob:
+		o, err = v.Finish()
+	case *HPA:
+		o, err = v.Finish()
+	case *PDB:
+		o, err = v.Finish()
+	case *NetworkPolicy:
+		o, err = v.Finish()
+	case *ResourceQuota:
+		o, err = v.Finish()
+	case *LimitRange:
+		o, err = v.Finish()
+	case *Endpoints:
+		o, err = v.Finish()
+	case *PriorityClass:
+		o, err = v.Finish()
+	case *UnionPV:
+		pv, pvc, err := v.Finish()
+		if err != nil {
+			return nil, err
+		}
+		return []runtime.Object{pv, pvc}, nil
+	case *UnionRBAC:
+		sa, role, binding, err := v.Finish()
+		if err != nil {
+			return nil, err
+		}
+		return []runtime.Object{sa, role, binding}, nil
+	case runtime.Object:
+		o = v
+		err = setTypeMeta(o)
+	default:
+		return nil, fmt.Errorf("Bundle Add err,type %T is not supported", item)
+	}
+	if err != nil {
+		return nil, err
+	}
+	return []runtime.Object{o}, nil
+}
+
+// setTypeMeta input Kind and APIVersion when Kubernetes resource object does not have them
+func setTypeMeta(o runtime.Object) error {
+	if !o.GetObjectKind().GroupVersionKind().Empty() {
+		return nil
+	}
+	gvks, _, err := scheme.Scheme.ObjectKinds(o)
+	if err != nil {
+		return err
+	}
+	o.GetObjectKind().SetGroupVersionKind(gvks[0])
+	return nil
+}
@@ -3,9 +3,11 @@ package beku
 import (
 	"encoding/json"
 	"errors"
+	"fmt"
 
 	"github.com/ghodss/yaml"
 	"k8s.io/api/rbac/v1beta1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 )
 
 // ClusterRole include kubernetes resource object ClusterRole and error
@@ -37,15 +39,50 @@ func (obj *ClusterRole) YAMLNew(yamlbyts []byte) *ClusterRole {
 	return obj
 }
 
+// JSONNewTemplate use json template and values create ClusterRole,
+// the template is rendered by RenderTemplate() and must be valid json
+func (obj *ClusterRole) JSONNewTemplate(tpl []byte, values interface{}) *ClusterRole {
+	jsonbyts, err := renderJSONTemplate(tpl, values)
+	if err != nil {
+		obj.error(err)
+		return obj
+	}
+	return obj.JSONNew(jsonbyts)
+}
+
+// YAMLNewTemplate use yaml template and values create ClusterRole,
+// the template is rendered by RenderTemplate() and must be valid yaml
+func (obj *ClusterRole) YAMLNewTemplate(tpl []byte, values interface{}) *ClusterRole {
+	yamlbyts, err := renderYAMLTemplate(tpl, values)
+	if err != nil {
+		obj.error(err)
+		return obj
+	}
+	return obj.YAMLNew(yamlbyts)
+}
+
+// Replace replace role by Kubernetes resource object
+func (obj *ClusterRole) Replace(role *v1beta1.ClusterRole) *ClusterRole {
+	if role != nil {
+		obj.role = role
+	}
+	return obj
+}
+
 func (obj *ClusterRole) verify() {
 	if obj.role.GetName() == "" {
 		obj.error(errors.New("Set Name err,name is not allowed to be empty"))
 		return
 	}
-	if len(obj.role.Rules) <= 0 {
+	// rules of aggregated ClusterRole is filled by Kubernetes controller
+	if len(obj.role.Rules) <= 0 && (obj.role.AggregationRule == nil || len(obj.role.AggregationRule.ClusterRoleSelectors) <= 0) {
 		obj.error(errors.New("Set ClusterRole err,rules is not allowed to be empty"))
 		return
 	}
+	if err := verifyPolicyRules("ClusterRole", obj.role.Rules, false); err != nil {
+		obj.error(err)
+		return
+	}
 	obj.role.APIVersion = "rbac.authorization.k8s.io/v1beta1"
 	obj.role.Kind = "ClusterRole"
 }
@@ -62,20 +99,134 @@ func (obj *ClusterRole) SetName(name string) *ClusterRole {
 	return obj
 }
 
-// SetRole set cluster role
+// SetRole append cluster role rule, it can be called many times to set many rules
 // verbs is func method. such as "get", "watch", "list","create", "delete" ..., you can set "*" if you want to use all the func method
 // apiGroups is resource apiGroup. such as "v1", "apps/v1", "rbac.authorization.k8s.io"... , you can set "*" if you want to use all the resource apiGroup
 // resources is resources object. such as "daemonsets", "deployments","replicasets", you can set "*" if you want to use all the resource object.
 func (obj *ClusterRole) SetRole(verbs, apiGroups, resources []string) *ClusterRole {
-	rule := v1beta1.PolicyRule{
-		Verbs:     verbs,
-		APIGroups: apiGroups,
-		Resources: resources,
+	return obj.SetRule(PolicyRule{Verbs: verbs, APIGroups: apiGroups, Resources: resources})
+}
+
+// SetLabels set ClusterRole labels
+func (obj *ClusterRole) SetLabels(labels map[string]string) *ClusterRole {
+	if len(obj.role.Labels) <= 0 {
+		obj.role.Labels = labels
+		return obj
 	}
-	if obj.role.Rules != nil {
-		obj.role.Rules = append(obj.role.Rules, rule)
+	for key, value := range labels {
+		obj.role.Labels[key] = value
+	}
+	return obj
+}
+
+// SetAnnotations set ClusterRole annotations
+func (obj *ClusterRole) SetAnnotations(annotations map[string]string) *ClusterRole {
+	if len(obj.role.Annotations) <= 0 {
+		obj.role.Annotations = annotations
 		return obj
 	}
-	obj.role.Rules = []v1beta1.PolicyRule{rule}
+	for key, value := range annotations {
+		obj.role.Annotations[key] = value
+	}
 	return obj
 }
+
+// SetRule append cluster role rule which supports resourceNames and nonResourceURLs
+func (obj *ClusterRole) SetRule(rule PolicyRule) *ClusterRole {
+	obj.role.Rules = append(obj.role.Rules, rule.ToK8s())
+	return obj
+}
+
+// SetNonResourceURLs append cluster role rule of non-resource URLs
+// verbs is http method in lower case. such as "get", "post"
+// nonResourceURLs is url path. such as "/healthz", "/metrics", "/api/*"
+func (obj *ClusterRole) SetNonResourceURLs(verbs, nonResourceURLs []string) *ClusterRole {
+	return obj.SetRule(PolicyRule{Verbs: verbs, NonResourceURLs: nonResourceURLs})
+}
+
+// setRules append rules
+func (obj *ClusterRole) setRules(rules []v1beta1.PolicyRule) *ClusterRole {
+	obj.role.Rules = append(obj.role.Rules, rules...)
+	return obj
+}
+
+// SetPreset append built-in rules:PresetReadOnly,PresetNamespaceEditor,PresetSecretsReader
+func (obj *ClusterRole) SetPreset(preset RolePreset) *ClusterRole {
+	rules := preset.Rules()
+	if len(rules) <= 0 {
+		obj.error(fmt.Errorf("SetPreset err,preset %s is not supported", preset))
+		return obj
+	}
+	return obj.setRules(rules)
+}
+
+// SetAggregationRule aggregate rules of ClusterRoles which match any of selectors into this ClusterRole,
+// the rules are filled by Kubernetes controller, so rules of this ClusterRole is allowed to be empty
+func (obj *ClusterRole) SetAggregationRule(selectors ...map[string]string) *ClusterRole {
+	if len(selectors) <= 0 {
+		obj.error(errors.New("SetAggregationRule err,selectors is not allowed to be empty"))
+		return obj
+	}
+	if obj.role.AggregationRule == nil {
+		obj.role.AggregationRule = &v1beta1.AggregationRule{}
+	}
+	for _, selector := range selectors {
+		obj.role.AggregationRule.ClusterRoleSelectors = append(obj.role.AggregationRule.ClusterRoleSelectors,
+			metav1.LabelSelector{MatchLabels: selector})
+	}
+	return obj
+}
+
+// AggregateTo set aggregation labels, so the rules of this ClusterRole will be aggregated into ClusterRoles of names,
+// eg: AggregateTo("view","edit") aggregate the rules into built-in ClusterRoles view and edit
+func (obj *ClusterRole) AggregateTo(names ...string) *ClusterRole {
+	labels := make(map[string]string, len(names))
+	for _, name := range names {
+		labels[AggregateToKeyPrefix+name] = "true"
+	}
+	return obj.SetLabels(labels)
+}
+
+// Release release ClusterRole on Kubernetes
+func (obj *ClusterRole) Release() (*v1beta1.ClusterRole, error) {
+	role, err := obj.Finish()
+	if err != nil {
+		return nil, err
+	}
+	client, err := GetKubeClient()
+	if err != nil {
+		return nil, err
+	}
+	return client.RbacV1beta1().ClusterRoles().Create(role)
+}
+
+// Apply it will be updated when this resource object exists in K8s,
+// it will be created when it does not exist.
+func (obj *ClusterRole) Apply() (*v1beta1.ClusterRole, error) {
+	role, err := obj.Finish()
+	if err != nil {
+		return nil, err
+	}
+	client, err := GetKubeClient()
+	if err != nil {
+		return nil, err
+	}
+	_, err = client.RbacV1beta1().ClusterRoles().Get(role.GetName(), metav1.GetOptions{})
+	if err != nil {
+		return client.RbacV1beta1().ClusterRoles().Create(role)
+	}
+	return client.RbacV1beta1().ClusterRoles().Update(role)
+}
+
+// Delete delete ClusterRole on Kubernetes
+func (obj *ClusterRole) Delete() error {
+	role, err := obj.Finish()
+	if err != nil {
+		return err
+	}
+	client, err := GetKubeClient()
+	if err != nil {
+		return err
+	}
+	return client.RbacV1beta1().ClusterRoles().Delete(role.GetName(), &metav1.DeleteOptions{})
+}
@@ -3,11 +3,11 @@ package beku
 import (
 	"encoding/json"
 	"errors"
-	"fmt"
 	"reflect"
 
 	"github.com/ghodss/yaml"
 	"k8s.io/api/rbac/v1beta1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 )
 
 // ClusterRoleBinding include kubernetes resource object ClusterRoleBinding and error
@@ -41,12 +41,60 @@ func (obj *ClusterRoleBinding) YAMLNew(yamlbyts []byte) *ClusterRoleBinding {
 	return obj
 }
 
+// JSONNewTemplate use json template and values create ClusterRoleBinding,
+// the template is rendered by RenderTemplate() and must be valid json
+func (obj *ClusterRoleBinding) JSONNewTemplate(tpl []byte, values interface{}) *ClusterRoleBinding {
+	jsonbyts, err := renderJSONTemplate(tpl, values)
+	if err != nil {
+		obj.error(err)
+		return obj
+	}
+	return obj.JSONNew(jsonbyts)
+}
+
+// YAMLNewTemplate use yaml template and values create ClusterRoleBinding,
+// the template is rendered by RenderTemplate() and must be valid yaml
+func (obj *ClusterRoleBinding) YAMLNewTemplate(tpl []byte, values interface{}) *ClusterRoleBinding {
+	yamlbyts, err := renderYAMLTemplate(tpl, values)
+	if err != nil {
+		obj.error(err)
+		return obj
+	}
+	return obj.YAMLNew(yamlbyts)
+}
+
+// Replace replace crb by Kubernetes resource object
+func (obj *ClusterRoleBinding) Replace(crb *v1beta1.ClusterRoleBinding) *ClusterRoleBinding {
+	if crb != nil {
+		obj.crb = crb
+	}
+	return obj
+}
+
 // SetName set ClusterRoleBinding name
 func (obj *ClusterRoleBinding) SetName(name string) *ClusterRoleBinding {
 	obj.crb.SetName(name)
 	return obj
 }
 
+// SetLabels set ClusterRoleBinding labels
+func (obj *ClusterRoleBinding) SetLabels(labels map[string]string) *ClusterRoleBinding {
+	obj.crb.SetLabels(labels)
+	return obj
+}
+
+// SetAnnotations set ClusterRoleBinding annotations
+func (obj *ClusterRoleBinding) SetAnnotations(annotations map[string]string) *ClusterRoleBinding {
+	if len(obj.crb.Annotations) <= 0 {
+		obj.crb.Annotations = annotations
+		return obj
+	}
+	for key, value := range annotations {
+		obj.crb.Annotations[key] = value
+	}
+	return obj
+}
+
 // SubKind subject kind
 type SubKind string
 
@@ -59,8 +107,8 @@ const (
 
 var (
 	kindMaps = map[SubKind]string{
-		User:  "rbac.authorization.k8s.io",
-		Group: "rbac.authorization.k8s.io",
+		User:  rbacAPIGroup,
+		Group: rbacAPIGroup,
 		SA:    "namespace",
 	}
 )
@@ -69,21 +117,9 @@ var (
 // kind only support "User", "Group", "ServiceAccount"
 // namespace  it is Required when kind is "ServiceAccount" default is "". it is Optional when kind is "User" or "Group"
 func (obj *ClusterRoleBinding) Subject(name string, kind SubKind, namespace string) *ClusterRoleBinding {
-	if kindMaps[kind] == "" {
-		obj.error(fmt.Errorf("Set subject err. kind:%v is not supported, only support User/Group/ServiceAccount ", kind))
-		return obj
-	}
-	subject := v1beta1.Subject{
-		Name: name,
-		Kind: string(kind),
-	}
-	if kindMaps[kind] == "namespace" {
-		subject.Namespace = namespace
-	} else {
-		subject.APIGroup = kindMaps[kind]
-	}
-	if len(obj.crb.Subjects) <= 0 {
-		obj.crb.Subjects = []v1beta1.Subject{subject}
+	subject, err := newSubject(name, kind, namespace)
+	if err != nil {
+		obj.error(err)
 		return obj
 	}
 	obj.crb.Subjects = append(obj.crb.Subjects, subject)
@@ -97,7 +133,7 @@ func (obj *ClusterRoleBinding) SetRoleRef(name string) *ClusterRoleBinding {
 		return obj
 	}
 	obj.crb.RoleRef = v1beta1.RoleRef{
-		APIGroup: "rbac.authorization.k8s.io",
+		APIGroup: rbacAPIGroup,
 		Kind:     "ClusterRole",
 		Name:     name,
 	}
@@ -113,8 +149,8 @@ func (obj *ClusterRoleBinding) verify() {
 		return
 	}
 
-	if len(obj.crb.Subjects) <= 0 {
-		obj.error(errors.New("Set ClusterRoleBinding err,subejects is not allowed to be empty"))
+	if err := verifySubjects("ClusterRoleBinding", obj.crb.Subjects); err != nil {
+		obj.error(err)
 		return
 	}
 
@@ -133,3 +169,54 @@ func (obj *ClusterRoleBinding) error(err error) {
 	}
 	obj.err = err
 }
+
+// Release release ClusterRoleBinding on Kubernetes
+func (obj *ClusterRoleBinding) Release() (*v1beta1.ClusterRoleBinding, error) {
+	crb, err := obj.Finish()
+	if err != nil {
+		return nil, err
+	}
+	client, err := GetKubeClient()
+	if err != nil {
+		return nil, err
+	}
+	return client.RbacV1beta1().ClusterRoleBindings().Create(crb)
+}
+
+// Apply it will be updated when this resource object exists in K8s,
+// it will be created when it does not exist.
+// RoleRef is immutable on Kubernetes, so ClusterRoleBinding will be recreated when RoleRef is changed.
+func (obj *ClusterRoleBinding) Apply() (*v1beta1.ClusterRoleBinding, error) {
+	crb, err := obj.Finish()
+	if err != nil {
+		return nil, err
+	}
+	client, err := GetKubeClient()
+	if err != nil {
+		return nil, err
+	}
+	present, err := client.RbacV1beta1().ClusterRoleBindings().Get(crb.GetName(), metav1.GetOptions{})
+	if err != nil {
+		return client.RbacV1beta1().ClusterRoleBindings().Create(crb)
+	}
+	if present.RoleRef != crb.RoleRef {
+		if err = client.RbacV1beta1().ClusterRoleBindings().Delete(crb.GetName(), &metav1.DeleteOptions{}); err != nil {
+			return nil, err
+		}
+		return client.RbacV1beta1().ClusterRoleBindings().Create(crb)
+	}
+	return client.RbacV1beta1().ClusterRoleBindings().Update(crb)
+}
+
+// Delete delete ClusterRoleBinding on Kubernetes
+func (obj *ClusterRoleBinding) Delete() error {
+	crb, err := obj.Finish()
+	if err != nil {
+		return err
+	}
+	client, err := GetKubeClient()
+	if err != nil {
+		return err
+	}
+	return client.RbacV1beta1().ClusterRoleBindings().Delete(crb.GetName(), &metav1.DeleteOptions{})
+}
@@ -0,0 +1,277 @@
+package beku
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"strings"
+
+	appsv1 "k8s.io/api/apps/v1"
+	batchv1 "k8s.io/api/batch/v1"
+	batchv1beta1 "k8s.io/api/batch/v1beta1"
+	"k8s.io/api/core/v1"
+	networkingv1beta1 "k8s.io/api/networking/v1beta1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/util/validation"
+	"k8s.io/client-go/kubernetes"
+)
+
+const (
+	// NameHashBaseKey the label of ConfigMap and Secret whose name has content hash suffix,
+	// the value is the name without hash suffix, all generations of the same name have the same label
+	NameHashBaseKey = "beku.io/name-hash-base"
+	// nameHashLength the length of content hash suffix
+	nameHashLength = 10
+)
+
+// hashedName return base name with the hash suffix of content, eg: nginx-conf-5f7d9c6b8a
+func hashedName(base string, content interface{}) (string, error) {
+	if errs := validation.IsValidLabelValue(base); len(errs) > 0 {
+		return "", fmt.Errorf("name %s is not allowed to have hash suffix:%s", base, strings.Join(errs, ","))
+	}
+	// json encodes map keys in order, so the same content always has the same hash
+	byts, err := json.Marshal(content)
+	if err != nil {
+		return "", err
+	}
+	sum := sha256.Sum256(byts)
+	return base + "-" + hex.EncodeToString(sum[:])[:nameHashLength], nil
+}
+
+// hashBaseName return the name without hash suffix, it is read from NameHashBaseKey label
+// when name is a hashed generation of the label, so the object read from hashed manifest is not hashed twice
+func hashBaseName(name string, labels map[string]string) string {
+	base := labels[NameHashBaseKey]
+	if verifyString(base) && len(name) == len(base)+1+nameHashLength && strings.HasPrefix(name, base+"-") {
+		return base
+	}
+	return name
+}
+
+// podSpecOf return the Pod spec of workload, return nil when it is not a workload
+func podSpecOf(o runtime.Object) *v1.PodSpec {
+	switch v := o.(type) {
+	case *appsv1.Deployment:
+		return &v.Spec.Template.Spec
+	case *appsv1.StatefulSet:
+		return &v.Spec.Template.Spec
+	case *appsv1.DaemonSet:
+		return &v.Spec.Template.Spec
+	case *appsv1.ReplicaSet:
+		return &v.Spec.Template.Spec
+	case *batchv1.Job:
+		return &v.Spec.Template.Spec
+	case *batchv1beta1.CronJob:
+		return &v.Spec.JobTemplate.Spec.Template.Spec
+	case *v1.Pod:
+		return &v.Spec
+	}
+	return nil
+}
+
+// renameConfigReferences rename the ConfigMaps and Secrets which are referenced by object,
+// renames is map[kind]map[oldName]newName
+func renameConfigReferences(o runtime.Object, renames map[string]map[string]string) {
+	rename := func(kind string, name *string) {
+		if newName, ok := renames[kind][*name]; ok {
+			*name = newName
+		}
+	}
+	walkConfigReferences(o, rename)
+}
+
+// walkConfigReferences call fn with the kind and the pointer of name of every ConfigMap and Secret reference of object,
+// the references are in Pod spec of workload, TLS of Ingress, and imagePullSecrets and secrets of ServiceAccount
+func walkConfigReferences(o runtime.Object, fn func(kind string, name *string)) {
+	switch v := o.(type) {
+	case *networkingv1beta1.Ingress:
+		for index := range v.Spec.TLS {
+			fn("Secret", &v.Spec.TLS[index].SecretName)
+		}
+		return
+	case *v1.ServiceAccount:
+		for index := range v.ImagePullSecrets {
+			fn("Secret", &v.ImagePullSecrets[index].Name)
+		}
+		for index := range v.Secrets {
+			if ref := &v.Secrets[index]; (ref.Kind == "" || ref.Kind == "Secret") && (ref.Namespace == "" || ref.Namespace == v.Namespace) {
+				fn("Secret", &ref.Name)
+			}
+		}
+		return
+	}
+	if spec := podSpecOf(o); spec != nil {
+		walkPodSpecConfigReferences(spec, fn)
+	}
+}
+
+// walkPodSpecConfigReferences call fn with the references of Pod spec through
+// volumes, projected volumes, env, envFrom and imagePullSecrets
+func walkPodSpecConfigReferences(spec *v1.PodSpec, fn func(kind string, name *string)) {
+	for index := range spec.ImagePullSecrets {
+		fn("Secret", &spec.ImagePullSecrets[index].Name)
+	}
+	for index := range spec.Volumes {
+		volume := &spec.Volumes[index]
+		if volume.ConfigMap != nil {
+			fn("ConfigMap", &volume.ConfigMap.Name)
+		}
+		if volume.Secret != nil {
+			fn("Secret", &volume.Secret.SecretName)
+		}
+		if volume.Projected == nil {
+			continue
+		}
+		for i := range volume.Projected.Sources {
+			if source := volume.Projected.Sources[i]; source.ConfigMap != nil {
+				fn("ConfigMap", &source.ConfigMap.Name)
+			} else if source.Secret != nil {
+				fn("Secret", &source.Secret.Name)
+			}
+		}
+	}
+	for _, containers := range [][]v1.Container{spec.InitContainers, spec.Containers} {
+		for i := range containers {
+			for j := range containers[i].Env {
+				if from := containers[i].Env[j].ValueFrom; from != nil && from.ConfigMapKeyRef != nil {
+					fn("ConfigMap", &from.ConfigMapKeyRef.Name)
+				} else if from != nil && from.SecretKeyRef != nil {
+					fn("Secret", &from.SecretKeyRef.Name)
+				}
+			}
+			for j := range containers[i].EnvFrom {
+				if from := containers[i].EnvFrom[j]; from.ConfigMapRef != nil {
+					fn("ConfigMap", &from.ConfigMapRef.Name)
+				} else if from.SecretRef != nil {
+					fn("Secret", &from.SecretRef.Name)
+				}
+			}
+		}
+	}
+}
+
+// referencedConfigs return the names of kind(ConfigMap or Secret) which are referenced by workloads, Ingresses
+// and ServiceAccounts in namespace, ReplicaSets are included, so the generation which is used by Deployment rollback is kept
+func referencedConfigs(client *kubernetes.Clientset, kind, namespace string) (map[string]bool, error) {
+	var objs []runtime.Object
+	deployments, err := client.AppsV1().Deployments(namespace).List(metav1.ListOptions{})
+	if err != nil {
+		return nil, err
+	}
+	for index := range deployments.Items {
+		objs = append(objs, &deployments.Items[index])
+	}
+	replicaSets, err := client.AppsV1().ReplicaSets(namespace).List(metav1.ListOptions{})
+	if err != nil {
+		return nil, err
+	}
+	for index := range replicaSets.Items {
+		objs = append(objs, &replicaSets.Items[index])
+	}
+	statefulSets, err := client.AppsV1().StatefulSets(namespace).List(metav1.ListOptions{})
+	if err != nil {
+		return nil, err
+	}
+	for index := range statefulSets.Items {
+		objs = append(objs, &statefulSets.Items[index])
+	}
+	daemonSets, err := client.AppsV1().DaemonSets(namespace).List(metav1.ListOptions{})
+	if err != nil {
+		return nil, err
+	}
+	for index := range daemonSets.Items {
+		objs = append(objs, &daemonSets.Items[index])
+	}
+	jobs, err := client.BatchV1().Jobs(namespace).List(metav1.ListOptions{})
+	if err != nil {
+		return nil, err
+	}
+	for index := range jobs.Items {
+		objs = append(objs, &jobs.Items[index])
+	}
+	cronJobs, err := client.BatchV1beta1().CronJobs(namespace).List(metav1.ListOptions{})
+	if err != nil {
+		return nil, err
+	}
+	for index := range cronJobs.Items {
+		objs = append(objs, &cronJobs.Items[index])
+	}
+	pods, err := client.CoreV1().Pods(namespace).List(metav1.ListOptions{})
+	if err != nil {
+		return nil, err
+	}
+	for index := range pods.Items {
+		objs = append(objs, &pods.Items[index])
+	}
+	ingresses, err := client.NetworkingV1beta1().Ingresses(namespace).List(metav1.ListOptions{})
+	if err != nil {
+		return nil, err
+	}
+	for index := range ingresses.Items {
+		objs = append(objs, &ingresses.Items[index])
+	}
+	serviceAccounts, err := client.CoreV1().ServiceAccounts(namespace).List(metav1.ListOptions{})
+	if err != nil {
+		return nil, err
+	}
+	for index := range serviceAccounts.Items {
+		objs = append(objs, &serviceAccounts.Items[index])
+	}
+	names := make(map[string]bool)
+	for _, o := range objs {
+		walkConfigReferences(o, func(refKind string, name *string) {
+			if refKind == kind {
+				names[*name] = true
+			}
+		})
+	}
+	return names, nil
+}
+
+// gcConfigGenerations delete the generations of kind(ConfigMap or Secret) which have the same NameHashBaseKey label,
+// the current generation and the generations which are referenced by workloads are kept
+func gcConfigGenerations(kind, namespace, base, current string) error {
+	client, err := GetKubeClient()
+	if err != nil {
+		return err
+	}
+	referenced, err := referencedConfigs(client, kind, namespace)
+	if err != nil {
+		return err
+	}
+	options := metav1.ListOptions{LabelSelector: NameHashBaseKey + "=" + base}
+	var names []string
+	if kind == "ConfigMap" {
+		cms, err := client.CoreV1().ConfigMaps(namespace).List(options)
+		if err != nil {
+			return err
+		}
+		for _, cm := range cms.Items {
+			names = append(names, cm.GetName())
+		}
+	} else {
+		secrets, err := client.CoreV1().Secrets(namespace).List(options)
+		if err != nil {
+			return err
+		}
+		for _, secret := range secrets.Items {
+			names = append(names, secret.GetName())
+		}
+	}
+	for _, name := range names {
+		if name == current || referenced[name] {
+			continue
+		}
+		if kind == "ConfigMap" {
+			err = client.CoreV1().ConfigMaps(namespace).Delete(name, &metav1.DeleteOptions{})
+		} else {
+			err = client.CoreV1().Secrets(namespace).Delete(name, &metav1.DeleteOptions{})
+		}
+		if err != nil {
+			return fmt.Errorf("delete %s %s/%s err:%v", kind, namespace, name, err)
+		}
+	}
+	return nil
+}
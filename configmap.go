@@ -1,18 +1,33 @@
 package beku
 
 import (
+	"bufio"
+	"bytes"
 	"encoding/json"
 	"errors"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"unicode/utf8"
 
 	"github.com/ghodss/yaml"
 	"k8s.io/api/core/v1"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/util/validation"
 )
 
+// configMapMaxSize the max total size of ConfigMap data and binaryData, it is limited by etcd
+const configMapMaxSize = 1024 * 1024
+
 // ConfigMap include Kubernetes resource object ConfigMap(cm) and error.
 type ConfigMap struct {
 	cm  *v1.ConfigMap
 	err error
+	// nameSuffixHash append content hash to name, baseName is the name without hash
+	nameSuffixHash bool
+	baseName       string
 }
 
 // NewCM create ConfigMap(cm) and chain function call begin with this function.
@@ -38,6 +53,28 @@ func (obj *ConfigMap) YAMLNew(yamlbyts []byte) *ConfigMap {
 	return obj
 }
 
+// JSONNewTemplate use json template and values create ConfigMap,
+// the template is rendered by RenderTemplate() and must be valid json
+func (obj *ConfigMap) JSONNewTemplate(tpl []byte, values interface{}) *ConfigMap {
+	jsonbyts, err := renderJSONTemplate(tpl, values)
+	if err != nil {
+		obj.error(err)
+		return obj
+	}
+	return obj.JSONNew(jsonbyts)
+}
+
+// YAMLNewTemplate use yaml template and values create ConfigMap,
+// the template is rendered by RenderTemplate() and must be valid yaml
+func (obj *ConfigMap) YAMLNewTemplate(tpl []byte, values interface{}) *ConfigMap {
+	yamlbyts, err := renderYAMLTemplate(tpl, values)
+	if err != nil {
+		obj.error(err)
+		return obj
+	}
+	return obj.YAMLNew(yamlbyts)
+}
+
 // Replace replace cm by Kubernetes resource object
 func (obj *ConfigMap) Replace(cm *v1.ConfigMap) *ConfigMap {
 	if cm != nil {
@@ -49,6 +86,7 @@ func (obj *ConfigMap) Replace(cm *v1.ConfigMap) *ConfigMap {
 // SetName set ConfigMap(cm) name
 func (obj *ConfigMap) SetName(name string) *ConfigMap {
 	obj.cm.SetName(name)
+	obj.baseName = ""
 	return obj
 }
 
@@ -60,7 +98,7 @@ func (obj *ConfigMap) SetNamespace(namespace string) *ConfigMap {
 
 // SetNamespaceAndName set ConfigMap(cm) namespace and name
 func (obj *ConfigMap) SetNamespaceAndName(namespace, name string) *ConfigMap {
-	obj.cm.SetName(name)
+	obj.SetName(name)
 	obj.cm.SetNamespace(namespace)
 	return obj
 }
@@ -77,6 +115,156 @@ func (obj *ConfigMap) SetData(data map[string]string) *ConfigMap {
 	return obj
 }
 
+// FromFile add file content into ConfigMap(cm), key default is the file name,
+// the file which is not UTF-8 is added into binaryData
+func (obj *ConfigMap) FromFile(key, path string) *ConfigMap {
+	if !verifyString(key) {
+		key = filepath.Base(path)
+	}
+	byts, err := ioutil.ReadFile(path)
+	if err != nil {
+		obj.error(fmt.Errorf("FromFile err,%v", err))
+		return obj
+	}
+	obj.error(obj.addData(key, byts))
+	return obj
+}
+
+// FromDir add every regular file in dir into ConfigMap(cm), key is the file name,
+// subdirectories and symlinks to directories are skipped
+func (obj *ConfigMap) FromDir(dir string) *ConfigMap {
+	infos, err := ioutil.ReadDir(dir)
+	if err != nil {
+		obj.error(fmt.Errorf("FromDir err,%v", err))
+		return obj
+	}
+	for _, info := range infos {
+		path := filepath.Join(dir, info.Name())
+		if info.Mode()&os.ModeSymlink != 0 {
+			if info, err = os.Stat(path); err != nil {
+				obj.error(fmt.Errorf("FromDir err,%v", err))
+				return obj
+			}
+		}
+		if !info.Mode().IsRegular() {
+			continue
+		}
+		obj.FromFile(info.Name(), path)
+	}
+	return obj
+}
+
+// FromEnvFile add key=value lines of env file into ConfigMap(cm) data,
+// blank lines and lines beginning with '#' are ignored,
+// a line without '=' use the value of the environment variable which has the same name
+func (obj *ConfigMap) FromEnvFile(path string) *ConfigMap {
+	byts, err := ioutil.ReadFile(path)
+	if err != nil {
+		obj.error(fmt.Errorf("FromEnvFile err,%v", err))
+		return obj
+	}
+	envs, err := parseEnvFile(byts)
+	if err != nil {
+		obj.error(fmt.Errorf("FromEnvFile err,%s %v", path, err))
+		return obj
+	}
+	for _, env := range envs {
+		if err = obj.addData(env[0], []byte(env[1])); err != nil {
+			obj.error(fmt.Errorf("FromEnvFile err,%s %v", path, err))
+			return obj
+		}
+	}
+	return obj
+}
+
+// addData add value into data when it is UTF-8, otherwise binaryData, key must be unique
+func (obj *ConfigMap) addData(key string, value []byte) error {
+	if errs := validation.IsConfigMapKey(key); len(errs) > 0 {
+		return fmt.Errorf("ConfigMap key %q is invalid:%s", key, strings.Join(errs, ","))
+	}
+	_, inData := obj.cm.Data[key]
+	_, inBinary := obj.cm.BinaryData[key]
+	if inData || inBinary {
+		return fmt.Errorf("ConfigMap key %q is repeated", key)
+	}
+	if utf8.Valid(value) {
+		if obj.cm.Data == nil {
+			obj.cm.Data = make(map[string]string)
+		}
+		obj.cm.Data[key] = string(value)
+		return nil
+	}
+	if obj.cm.BinaryData == nil {
+		obj.cm.BinaryData = make(map[string][]byte)
+	}
+	obj.cm.BinaryData[key] = value
+	return nil
+}
+
+// parseEnvFile parse env file into [key,value] pairs which keep the order of file
+func parseEnvFile(byts []byte) ([][2]string, error) {
+	var envs [][2]string
+	scanner := bufio.NewScanner(bytes.NewReader(bytes.TrimPrefix(byts, []byte("\xEF\xBB\xBF"))))
+	for number := 1; scanner.Scan(); number++ {
+		line := strings.TrimLeft(scanner.Text(), " \t")
+		if emptyString(line) || strings.HasPrefix(line, "#") {
+			continue
+		}
+		kv := strings.SplitN(line, "=", 2)
+		key := kv[0]
+		if errs := validation.IsEnvVarName(key); len(errs) > 0 {
+			return nil, fmt.Errorf("line %d key %q is invalid:%s", number, key, strings.Join(errs, ","))
+		}
+		value := os.Getenv(key)
+		if len(kv) == 2 {
+			value = kv[1]
+		}
+		envs = append(envs, [2]string{key, value})
+	}
+	return envs, scanner.Err()
+}
+
+// SetNameSuffixHash append the hash of data to ConfigMap name when hash is true, eg: nginx-conf-5f7d9c6b8a,
+// a new ConfigMap is created when data is changed, so the workloads which reference it are rolled out.
+// the references of workloads in the same Bundle are renamed automatically,
+// and you can call GarbageCollect() to delete the old generations after the workloads are applied
+func (obj *ConfigMap) SetNameSuffixHash(hash bool) *ConfigMap {
+	obj.nameSuffixHash = hash
+	return obj
+}
+
+// GarbageCollect delete the old generations of ConfigMap which has name suffix hash,
+// the generations which are still referenced by workloads(include ReplicaSets for rollback) are kept
+func (obj *ConfigMap) GarbageCollect() error {
+	cm, err := obj.Finish()
+	if err != nil {
+		return err
+	}
+	if !obj.nameSuffixHash {
+		return errors.New("GarbageCollect err,it is only allowed when SetNameSuffixHash(true) is called")
+	}
+	return gcConfigGenerations("ConfigMap", cm.GetNamespace(), obj.baseName, cm.GetName())
+}
+
+// hashName append the hash of content to name and label the base name
+func (obj *ConfigMap) hashName(content interface{}) error {
+	if !verifyString(obj.baseName) {
+		obj.baseName = hashBaseName(obj.cm.GetName(), obj.cm.GetLabels())
+	}
+	name, err := hashedName(obj.baseName, content)
+	if err != nil {
+		return err
+	}
+	obj.cm.SetName(name)
+	labels := obj.cm.GetLabels()
+	if labels == nil {
+		labels = make(map[string]string)
+	}
+	labels[NameHashBaseKey] = obj.baseName
+	obj.cm.SetLabels(labels)
+	return nil
+}
+
 // Release release ConfigMap on Kubernetes
 func (obj *ConfigMap) Release() (*v1.ConfigMap, error) {
 	cm, err := obj.Finish()
@@ -125,8 +313,31 @@ func (obj *ConfigMap) verify() {
 		obj.err = errors.New("ConfigMap name is not allowed to be empty")
 		return
 	}
-	if len(obj.cm.Data) <= 0 {
+	if len(obj.cm.Data) <= 0 && len(obj.cm.BinaryData) <= 0 {
 		obj.err = errors.New("ConfigMap.Data is not allowed to be empty")
+		return
+	}
+	size := 0
+	for key, value := range obj.cm.Data {
+		if _, ok := obj.cm.BinaryData[key]; ok {
+			obj.err = fmt.Errorf("ConfigMap key %q is not allowed to be in both data and binaryData", key)
+			return
+		}
+		size += len(key) + len(value)
+	}
+	for key, value := range obj.cm.BinaryData {
+		size += len(key) + len(value)
+	}
+	if size > configMapMaxSize {
+		obj.err = fmt.Errorf("ConfigMap %s size %d bytes exceeds the limit of %d bytes(1MiB),you can use PersistentVolume instead", obj.cm.Name, size, configMapMaxSize)
+		return
+	}
+	if obj.nameSuffixHash {
+		content := map[string]interface{}{"kind": "ConfigMap", "data": obj.cm.Data, "binaryData": obj.cm.BinaryData}
+		if err := obj.hashName(content); err != nil {
+			obj.err = fmt.Errorf("ConfigMap SetNameSuffixHash err,%v", err)
+			return
+		}
 	}
 	obj.cm.APIVersion = "v1"
 	obj.cm.Kind = "ConfigMap"
@@ -0,0 +1,521 @@
+package beku
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/ghodss/yaml"
+	"github.com/robfig/cron"
+	batchv1beta1 "k8s.io/api/batch/v1beta1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+// cronJobNameMaxLen the max length of CronJob name,
+// Kubernetes appends 11 characters to CronJob name when it creates Job
+const cronJobNameMaxLen = 52
+
+// CronJob include Kubernetes resource object CronJob and error
+type CronJob struct {
+	cj  *batchv1beta1.CronJob
+	err error
+}
+
+// NewCronJob create CronJob and chain function call begin with this function.
+func NewCronJob() *CronJob { return &CronJob{cj: &batchv1beta1.CronJob{}} }
+
+// Finish Chain function call end with this function
+// return Kubernetes resource object CronJob and error.
+// In the function, it will check necessary parameters,input the default field
+func (obj *CronJob) Finish() (*batchv1beta1.CronJob, error) {
+	obj.verify()
+	return obj.cj, obj.err
+}
+
+// JSONNew use json data create CronJob
+func (obj *CronJob) JSONNew(jsonbyts []byte) *CronJob {
+	obj.error(json.Unmarshal(jsonbyts, obj.cj))
+	return obj
+}
+
+// YAMLNew use yaml data create CronJob
+func (obj *CronJob) YAMLNew(yamlbyts []byte) *CronJob {
+	obj.error(yaml.Unmarshal(yamlbyts, obj.cj))
+	return obj
+}
+
+// JSONNewTemplate use json template and values create CronJob,
+// the template is rendered by RenderTemplate() and must be valid json
+func (obj *CronJob) JSONNewTemplate(tpl []byte, values interface{}) *CronJob {
+	jsonbyts, err := renderJSONTemplate(tpl, values)
+	if err != nil {
+		obj.error(err)
+		return obj
+	}
+	return obj.JSONNew(jsonbyts)
+}
+
+// YAMLNewTemplate use yaml template and values create CronJob,
+// the template is rendered by RenderTemplate() and must be valid yaml
+func (obj *CronJob) YAMLNewTemplate(tpl []byte, values interface{}) *CronJob {
+	yamlbyts, err := renderYAMLTemplate(tpl, values)
+	if err != nil {
+		obj.error(err)
+		return obj
+	}
+	return obj.YAMLNew(yamlbyts)
+}
+
+// Replace replace CronJob by Kubernetes resource object
+func (obj *CronJob) Replace(cj *batchv1beta1.CronJob) *CronJob {
+	if cj != nil {
+		obj.cj = cj
+	}
+	return obj
+}
+
+// SetName set CronJob name
+func (obj *CronJob) SetName(name string) *CronJob {
+	obj.cj.SetName(name)
+	return obj
+}
+
+// SetNamespace set CronJob namespace,set Job and Pod namespace.
+func (obj *CronJob) SetNamespace(namespace string) *CronJob {
+	obj.cj.SetNamespace(namespace)
+	obj.cj.Spec.JobTemplate.SetNamespace(namespace)
+	obj.cj.Spec.JobTemplate.Spec.Template.SetNamespace(namespace)
+	return obj
+}
+
+// SetNamespaceAndName set CronJob namespace,set Job and Pod namespace,set CronJob name.
+func (obj *CronJob) SetNamespaceAndName(namespace, name string) *CronJob {
+	obj.SetNamespace(namespace)
+	obj.SetName(name)
+	return obj
+}
+
+// SetLabels set CronJob labels
+func (obj *CronJob) SetLabels(labels map[string]string) *CronJob {
+	obj.cj.SetLabels(labels)
+	return obj
+}
+
+// SetJobLabels set labels of Job which is created by CronJob
+func (obj *CronJob) SetJobLabels(labels map[string]string) *CronJob {
+	obj.cj.Spec.JobTemplate.SetLabels(labels)
+	return obj
+}
+
+// SetPodLabels set labels of Pod which is created by Job of CronJob
+func (obj *CronJob) SetPodLabels(labels map[string]string) *CronJob {
+	obj.cj.Spec.JobTemplate.Spec.Template.SetLabels(labels)
+	return obj
+}
+
+// SetAnnotations set CronJob annotations
+func (obj *CronJob) SetAnnotations(annotations map[string]string) *CronJob {
+	if len(obj.cj.Annotations) <= 0 {
+		obj.cj.Annotations = annotations
+		return obj
+	}
+	for key, value := range annotations {
+		obj.cj.Annotations[key] = value
+	}
+	return obj
+}
+
+// SetSchedule set CronJob schedule in cron format, eg: "*/5 * * * *","0 3 * * 1-5","@hourly",
+// the time zone is the time zone of kube-controller-manager
+func (obj *CronJob) SetSchedule(schedule string) *CronJob {
+	if _, err := cron.ParseStandard(schedule); err != nil {
+		obj.error(fmt.Errorf("SetSchedule err,schedule %q is invalid:%s", schedule, err.Error()))
+		return obj
+	}
+	obj.cj.Spec.Schedule = schedule
+	return obj
+}
+
+// SetConcurrencyPolicy set how to treat concurrent executions of a Job:Allow,Forbid,Replace, default Allow
+func (obj *CronJob) SetConcurrencyPolicy(policy ConcurrencyPolicy) *CronJob {
+	obj.cj.Spec.ConcurrencyPolicy = policy.ToK8s()
+	if obj.cj.Spec.ConcurrencyPolicy == "" {
+		obj.error(fmt.Errorf("SetConcurrencyPolicy err,concurrency policy %s is not supported", policy))
+	}
+	return obj
+}
+
+// SetStartingDeadlineSeconds set deadline in seconds for starting the Job if it misses scheduled time for any reason,
+// missed Job executions will be counted as failed ones.
+func (obj *CronJob) SetStartingDeadlineSeconds(sec int64) *CronJob {
+	if sec <= 0 {
+		obj.error(errors.New("SetStartingDeadlineSeconds err,sec must be greater than 0"))
+		return obj
+	}
+	obj.cj.Spec.StartingDeadlineSeconds = &sec
+	return obj
+}
+
+// SetHistoryLimit set the number of successful and failed finished Jobs to retain, default 3 and 1
+func (obj *CronJob) SetHistoryLimit(successful, failed int32) *CronJob {
+	if successful < 0 || failed < 0 {
+		obj.error(errors.New("SetHistoryLimit err,limit is not allowed to be less than 0"))
+		return obj
+	}
+	obj.cj.Spec.SuccessfulJobsHistoryLimit = &successful
+	obj.cj.Spec.FailedJobsHistoryLimit = &failed
+	return obj
+}
+
+// SetSuspend suspend subsequent executions when suspend is true,it does not apply to already started executions.
+func (obj *CronJob) SetSuspend(suspend bool) *CronJob {
+	obj.cj.Spec.Suspend = &suspend
+	return obj
+}
+
+// SetCompletions set the number of Pods which should successfully complete of every Job, default 1
+func (obj *CronJob) SetCompletions(completions int32) *CronJob {
+	if completions <= 0 {
+		obj.error(errors.New("SetCompletions err,completions must be greater than 0"))
+		return obj
+	}
+	obj.cj.Spec.JobTemplate.Spec.Completions = &completions
+	return obj
+}
+
+// SetParallelism set the max number of Pods which run at the same time of every Job, default 1
+func (obj *CronJob) SetParallelism(parallelism int32) *CronJob {
+	if parallelism <= 0 {
+		obj.error(errors.New("SetParallelism err,parallelism must be greater than 0"))
+		return obj
+	}
+	obj.cj.Spec.JobTemplate.Spec.Parallelism = &parallelism
+	return obj
+}
+
+// SetBackoffLimit set the number of retries before marking Job failed, default 6
+func (obj *CronJob) SetBackoffLimit(limit int32) *CronJob {
+	if limit < 0 {
+		obj.error(errors.New("SetBackoffLimit err,limit is not allowed to be less than 0"))
+		return obj
+	}
+	obj.cj.Spec.JobTemplate.Spec.BackoffLimit = &limit
+	return obj
+}
+
+// SetActiveDeadlineSeconds set the max running seconds of every Job, Job will be failed with DeadlineExceeded when it is exceeded
+func (obj *CronJob) SetActiveDeadlineSeconds(sec int64) *CronJob {
+	if sec <= 0 {
+		obj.error(errors.New("SetActiveDeadlineSeconds err,sec must be greater than 0"))
+		return obj
+	}
+	obj.cj.Spec.JobTemplate.Spec.ActiveDeadlineSeconds = &sec
+	return obj
+}
+
+// SetRestartPolicy set Pod restart policy, only OnFailure and Never are allowed, default Never
+func (obj *CronJob) SetRestartPolicy(policy RestartPolicy) *CronJob {
+	obj.cj.Spec.JobTemplate.Spec.Template.Spec.RestartPolicy = policy.ToK8s()
+	if obj.cj.Spec.JobTemplate.Spec.Template.Spec.RestartPolicy == "" {
+		obj.error(fmt.Errorf("SetRestartPolicy err,restart policy %s is not supported", policy))
+	}
+	return obj
+}
+
+// SetContainer set CronJob container
+// name:name is container name ,default ""
+// image:image is image name ,must input image
+// containerPort: image expose containerPort, it is allowed to be empty
+func (obj *CronJob) SetContainer(name, image string, containerPort ...int32) *CronJob {
+	if len(containerPort) > 0 {
+		obj.error(setContainer(&obj.cj.Spec.JobTemplate.Spec.Template, name, image, containerPort[0]))
+		return obj
+	}
+	obj.error(addContainer(&obj.cj.Spec.JobTemplate.Spec.Template, name, image, nil))
+	return obj
+}
+
+// SetCommand set command and args of the last container set by SetContainer()
+func (obj *CronJob) SetCommand(command []string, args ...string) *CronJob {
+	obj.error(setCommand(&obj.cj.Spec.JobTemplate.Spec.Template, command, args...))
+	return obj
+}
+
+// SetEnvs set Pod Environmental variable
+func (obj *CronJob) SetEnvs(envMap map[string]string) *CronJob {
+	obj.error(setEnvs(&obj.cj.Spec.JobTemplate.Spec.Template, envMap))
+	return obj
+}
+
+// SetHTTPLiveness set container liveness of http style
+// port: required
+// path: http request URL,eg: /api/v1/posts/1
+// initDelaySec: how long time after the first start of the program the probe is executed for the first time.(sec)
+// timeoutSec: http request timeout seconds,defaults to 1 second. Minimum value is 1.
+// periodSec: how often does the probe? defaults to 1 second. Minimum value is 1,Except for the first time?
+// headers: headers[0] is HTTP Header, do not fill if you do not need to set
+// on the other hand, only **first container** will be set livenessProbe
+func (obj *CronJob) SetHTTPLiveness(port int, path string, initDelaySec, timeoutSec, periodSec int32, headers ...map[string]string) *CronJob {
+	obj.error(setLiveness(&obj.cj.Spec.JobTemplate.Spec.Template, httpProbe(port, path, initDelaySec, timeoutSec, periodSec, headers...)))
+	return obj
+}
+
+// SetCMDLiveness set container liveness of cmd style
+// cmd: execute liveness probe as commond line
+// on the other hand, only **first container** will be set livenessProbe
+func (obj *CronJob) SetCMDLiveness(cmd []string, initDelaySec, timeoutSec, periodSec int32) *CronJob {
+	obj.error(setLiveness(&obj.cj.Spec.JobTemplate.Spec.Template, cmdProbe(cmd, initDelaySec, timeoutSec, periodSec)))
+	return obj
+}
+
+// SetTCPLiveness set container liveness of tcp style
+// host: default is ""
+// port: required
+// on the other hand, only **first container** will be set livenessProbe
+func (obj *CronJob) SetTCPLiveness(host string, port int, initDelaySec, timeoutSec, periodSec int32) *CronJob {
+	obj.error(setLiveness(&obj.cj.Spec.JobTemplate.Spec.Template, tcpProbe(host, port, initDelaySec, timeoutSec, periodSec)))
+	return obj
+}
+
+// SetResourceLimit set container of CronJob resource limit,eg:CPU and MEMORY
+func (obj *CronJob) SetResourceLimit(limits map[ResourceName]string) *CronJob {
+	obj.error(setResourceLimit(&obj.cj.Spec.JobTemplate.Spec.Template, limits))
+	return obj
+}
+
+// SetResourceRequst set container of CronJob resource request,only CPU and MEMORY
+func (obj *CronJob) SetResourceRequst(requests map[ResourceName]string) *CronJob {
+	obj.error(setResourceRequests(&obj.cj.Spec.JobTemplate.Spec.Template, requests))
+	return obj
+}
+
+// SetPodQos set pod quality of service
+// qosClass: is quality of service,the value only 'Guaranteed','Burstable' and 'BestEffort'
+// autoSet: If your previous settings do not meet the requirements of PodQoS, we will automatically set
+func (obj *CronJob) SetPodQos(qosClass string, autoSet ...bool) *CronJob {
+	obj.SetAnnotations(setQosMap(obj.cj.Annotations, qosClass, autoSet...))
+	return obj
+}
+
+// SetPVClaim set CronJob PersistentVolumeClaimVolumeSource
+// params:
+// volumeName: this is Custom field,you can define VolumeSource name,will be used of the container MountPath,
+// claimName: this is PersistentVolumeClaim(PVC) name,the PVC and CronJob must on same namespace and exist.
+func (obj *CronJob) SetPVClaim(volumeName, claimName string) *CronJob {
+	obj.error(setPVClaim(&obj.cj.Spec.JobTemplate.Spec.Template, volumeName, claimName))
+	return obj
+}
+
+// SetPVCMounts mount PersistentVolumeClaim on the first container
+// volumeName:the param is SetPVClaim() function volumeName
+// mountPath: runtime container dir eg:/var/lib/mysql
+func (obj *CronJob) SetPVCMounts(volumeName, mountPath string) *CronJob {
+	obj.error(setPVCMounts(&obj.cj.Spec.JobTemplate.Spec.Template, volumeName, mountPath))
+	return obj
+}
+
+// SetConfigMapVolume declare ConfigMap volume on CronJob Pod, volumeName is used by SetVolumeMount(),
+// cm.Items is the keys which are projected, all keys are projected when it is empty
+func (obj *CronJob) SetConfigMapVolume(volumeName string, cm *ConfigMapVolumeSource) *CronJob {
+	obj.error(setConfigMapVolume(&obj.cj.Spec.JobTemplate.Spec.Template, volumeName, cm))
+	return obj
+}
+
+// SetSecretVolume declare Secret volume on CronJob Pod, volumeName is used by SetVolumeMount(),
+// secret.Items is the keys which are projected, all keys are projected when it is empty
+func (obj *CronJob) SetSecretVolume(volumeName string, secret *SecretVolumeSource) *CronJob {
+	obj.error(setSecretVolume(&obj.cj.Spec.JobTemplate.Spec.Template, volumeName, secret))
+	return obj
+}
+
+// SetEmptyDirVolume declare EmptyDir volume on CronJob Pod, it is deleted when Pod is deleted,
+// memory: use tmpfs, sizeLimit[0] is the max size of volume, eg: 1Gi
+func (obj *CronJob) SetEmptyDirVolume(volumeName string, memory bool, sizeLimit ...string) *CronJob {
+	obj.error(setEmptyDirVolume(&obj.cj.Spec.JobTemplate.Spec.Template, volumeName, memory, sizeLimit...))
+	return obj
+}
+
+// SetHostPathVolume declare HostPath volume on CronJob Pod, path is the absolute path on node
+func (obj *CronJob) SetHostPathVolume(volumeName, path string, hostPathType ...HostPathType) *CronJob {
+	obj.error(setHostPathVolume(&obj.cj.Spec.JobTemplate.Spec.Template, volumeName, path, hostPathType...))
+	return obj
+}
+
+// SetDownwardAPIVolume declare DownwardAPI volume on CronJob Pod, Pod fields and container resources are exposed as files
+func (obj *CronJob) SetDownwardAPIVolume(volumeName string, files []DownwardAPIVolumeFile, defaultMode ...int32) *CronJob {
+	obj.error(setDownwardAPIVolume(&obj.cj.Spec.JobTemplate.Spec.Template, volumeName, files, defaultMode...))
+	return obj
+}
+
+// SetProjectedVolume declare projected volume on CronJob Pod, it projects ConfigMap, Secret,
+// DownwardAPI and ServiceAccount token into the same directory
+func (obj *CronJob) SetProjectedVolume(volumeName string, sources []VolumeProjection, defaultMode ...int32) *CronJob {
+	obj.error(setProjectedVolume(&obj.cj.Spec.JobTemplate.Spec.Template, volumeName, sources, defaultMode...))
+	return obj
+}
+
+// SetVolumeMount mount volume on CronJob container, mount.Container is the container name, default is the first container,
+// the volume must be declared by SetPVClaim(), SetConfigMapVolume() and the other volume functions
+func (obj *CronJob) SetVolumeMount(mount VolumeMount) *CronJob {
+	obj.error(setVolumeMount(&obj.cj.Spec.JobTemplate.Spec.Template, mount))
+	return obj
+}
+
+// SetConfigMapFiles mount every key of ConfigMap as a read-only file in mountPath of CronJob container,
+// container[0] is the container name, default is the first container.
+// eg: NewCM().SetName("nginx").FromDir("conf") and SetConfigMapFiles(cm, "/etc/nginx/conf.d")
+func (obj *CronJob) SetConfigMapFiles(cm *ConfigMap, mountPath string, container ...string) *CronJob {
+	obj.error(setConfigMapFiles(&obj.cj.Spec.JobTemplate.Spec.Template, cm, mountPath, container...))
+	return obj
+}
+
+// SetImagePullSecrets set pod pull secret
+func (obj *CronJob) SetImagePullSecrets(secretName string) *CronJob {
+	setImagePullSecrets(&obj.cj.Spec.JobTemplate.Spec.Template, secretName)
+	return obj
+}
+
+// SetServiceAccount set CronJob Pod ServiceAccount
+// automount[0] set whether the ServiceAccount token should be automatically mounted,default use ServiceAccount setting.
+func (obj *CronJob) SetServiceAccount(serviceAccountName string, automount ...bool) *CronJob {
+	obj.error(setServiceAccount(&obj.cj.Spec.JobTemplate.Spec.Template, serviceAccountName, automount...))
+	return obj
+}
+
+// SetPodPriorityClass set CronJob Pod Priority
+// priorityClassName is Kubernetes resource object PriorityClass name
+func (obj *CronJob) SetPodPriorityClass(priorityClassName string) *CronJob {
+	obj.error(setPodPriorityClass(&obj.cj.Spec.JobTemplate.Spec.Template, priorityClassName))
+	return obj
+}
+
+// ImagePullPolicy CronJob pull image policy:Always,Never,IfNotPresent
+func (obj *CronJob) ImagePullPolicy(pullPolicy PullPolicy) *CronJob {
+	if len(obj.cj.Annotations) <= 0 {
+		obj.cj.Annotations = make(map[string]string, 0)
+	}
+	obj.cj.Annotations[ImagePullPolicyKey] = string(pullPolicy)
+	return obj
+}
+
+// NextRuns return the next n fire times of CronJob schedule after from[0],default after now,
+// it is computed offline in the local time zone, the result is shorter than n when the schedule never fires again.
+func (obj *CronJob) NextRuns(n int, from ...time.Time) ([]time.Time, error) {
+	if obj.err != nil {
+		return nil, obj.err
+	}
+	if n <= 0 {
+		return nil, fmt.Errorf("NextRuns err,n must be greater than 0,but it is %d", n)
+	}
+	if !verifyString(obj.cj.Spec.Schedule) {
+		return nil, errors.New("CronJob schedule is not allowed to be empty,you can call SetSchedule() input")
+	}
+	schedule, err := cron.ParseStandard(obj.cj.Spec.Schedule)
+	if err != nil {
+		return nil, fmt.Errorf("CronJob schedule %q is invalid:%s", obj.cj.Spec.Schedule, err.Error())
+	}
+	next := time.Now()
+	if len(from) > 0 {
+		next = from[0]
+	}
+	runs := make([]time.Time, 0, n)
+	for i := 0; i < n; i++ {
+		next = schedule.Next(next)
+		if next.IsZero() {
+			break
+		}
+		runs = append(runs, next)
+	}
+	return runs, nil
+}
+
+// Release release CronJob on Kubernetes
+func (obj *CronJob) Release() (*batchv1beta1.CronJob, error) {
+	cj, err := obj.Finish()
+	if err != nil {
+		return nil, err
+	}
+	client, err := GetKubeClient()
+	if err != nil {
+		return nil, err
+	}
+	if err = priorityClassExists(client, cj.Spec.JobTemplate.Spec.Template.Spec.PriorityClassName); err != nil {
+		return nil, err
+	}
+	return client.BatchV1beta1().CronJobs(cj.GetNamespace()).Create(cj)
+}
+
+// Apply it will be updated when this resource object exists in K8s,
+// it will be created when it does not exist.
+func (obj *CronJob) Apply() (*batchv1beta1.CronJob, error) {
+	cj, err := obj.Finish()
+	if err != nil {
+		return nil, err
+	}
+	client, err := GetKubeClient()
+	if err != nil {
+		return nil, err
+	}
+	if err = priorityClassExists(client, cj.Spec.JobTemplate.Spec.Template.Spec.PriorityClassName); err != nil {
+		return nil, err
+	}
+	_, err = client.BatchV1beta1().CronJobs(cj.GetNamespace()).Get(cj.GetName(), metav1.GetOptions{})
+	if err != nil {
+		return client.BatchV1beta1().CronJobs(cj.GetNamespace()).Create(cj)
+	}
+	return client.BatchV1beta1().CronJobs(cj.GetNamespace()).Update(cj)
+}
+
+// Delete delete CronJob and its Jobs on Kubernetes
+func (obj *CronJob) Delete() error {
+	cj, err := obj.Finish()
+	if err != nil {
+		return err
+	}
+	client, err := GetKubeClient()
+	if err != nil {
+		return err
+	}
+	propagation := metav1.DeletePropagationBackground
+	return client.BatchV1beta1().CronJobs(cj.GetNamespace()).Delete(cj.GetName(), &metav1.DeleteOptions{PropagationPolicy: &propagation})
+}
+
+func (obj *CronJob) error(err error) {
+	if obj.err != nil {
+		return
+	}
+	obj.err = err
+}
+
+// verify check CronJob necessary value, input the default field and input related data.
+func (obj *CronJob) verify() {
+	if obj.err != nil {
+		return
+	}
+	if !verifyString(obj.cj.GetName()) {
+		obj.err = errors.New("CronJob name is not allowed to be empty")
+		return
+	}
+	if len(obj.cj.GetName()) > cronJobNameMaxLen {
+		obj.err = fmt.Errorf("CronJob name %s is too long,it must be no more than %d characters", obj.cj.GetName(), cronJobNameMaxLen)
+		return
+	}
+	if !verifyString(obj.cj.Spec.Schedule) {
+		obj.err = errors.New("CronJob schedule is not allowed to be empty,you can call SetSchedule() input")
+		return
+	}
+	if _, err := cron.ParseStandard(obj.cj.Spec.Schedule); err != nil {
+		obj.err = fmt.Errorf("CronJob schedule %q is invalid:%s", obj.cj.Spec.Schedule, err.Error())
+		return
+	}
+	if err := verifyJobTemplate("CronJob", &obj.cj.Spec.JobTemplate.Spec.Template, obj.cj.Annotations); err != nil {
+		obj.err = err
+		return
+	}
+	if obj.cj.Spec.ConcurrencyPolicy == "" {
+		obj.cj.Spec.ConcurrencyPolicy = batchv1beta1.AllowConcurrent
+	}
+	obj.cj.Kind = "CronJob"
+	obj.cj.APIVersion = "batch/v1beta1"
+}
@@ -40,6 +40,28 @@ func (obj *DaemonSet) YAMLNew(yamlbyts []byte) *DaemonSet {
 	return obj
 }
 
+// JSONNewTemplate use json template and values create DaemonSet,
+// the template is rendered by RenderTemplate() and must be valid json
+func (obj *DaemonSet) JSONNewTemplate(tpl []byte, values interface{}) *DaemonSet {
+	jsonbyts, err := renderJSONTemplate(tpl, values)
+	if err != nil {
+		obj.error(err)
+		return obj
+	}
+	return obj.JSONNew(jsonbyts)
+}
+
+// YAMLNewTemplate use yaml template and values create DaemonSet,
+// the template is rendered by RenderTemplate() and must be valid yaml
+func (obj *DaemonSet) YAMLNewTemplate(tpl []byte, values interface{}) *DaemonSet {
+	yamlbyts, err := renderYAMLTemplate(tpl, values)
+	if err != nil {
+		obj.error(err)
+		return obj
+	}
+	return obj.YAMLNew(yamlbyts)
+}
+
 // Replace replace ds by Kubernetes resource object
 func (obj *DaemonSet) Replace(ds *v1.DaemonSet) *DaemonSet {
 	if ds != nil {
@@ -212,6 +234,61 @@ func (obj *DaemonSet) SetPVCMounts(volumeName, mountPath string) *DaemonSet {
 	return obj
 }
 
+// SetConfigMapVolume declare ConfigMap volume on DaemonSet Pod, volumeName is used by SetVolumeMount(),
+// cm.Items is the keys which are projected, all keys are projected when it is empty
+func (obj *DaemonSet) SetConfigMapVolume(volumeName string, cm *ConfigMapVolumeSource) *DaemonSet {
+	obj.error(setConfigMapVolume(&obj.ds.Spec.Template, volumeName, cm))
+	return obj
+}
+
+// SetSecretVolume declare Secret volume on DaemonSet Pod, volumeName is used by SetVolumeMount(),
+// secret.Items is the keys which are projected, all keys are projected when it is empty
+func (obj *DaemonSet) SetSecretVolume(volumeName string, secret *SecretVolumeSource) *DaemonSet {
+	obj.error(setSecretVolume(&obj.ds.Spec.Template, volumeName, secret))
+	return obj
+}
+
+// SetEmptyDirVolume declare EmptyDir volume on DaemonSet Pod, it is deleted when Pod is deleted,
+// memory: use tmpfs, sizeLimit[0] is the max size of volume, eg: 1Gi
+func (obj *DaemonSet) SetEmptyDirVolume(volumeName string, memory bool, sizeLimit ...string) *DaemonSet {
+	obj.error(setEmptyDirVolume(&obj.ds.Spec.Template, volumeName, memory, sizeLimit...))
+	return obj
+}
+
+// SetHostPathVolume declare HostPath volume on DaemonSet Pod, path is the absolute path on node
+func (obj *DaemonSet) SetHostPathVolume(volumeName, path string, hostPathType ...HostPathType) *DaemonSet {
+	obj.error(setHostPathVolume(&obj.ds.Spec.Template, volumeName, path, hostPathType...))
+	return obj
+}
+
+// SetDownwardAPIVolume declare DownwardAPI volume on DaemonSet Pod, Pod fields and container resources are exposed as files
+func (obj *DaemonSet) SetDownwardAPIVolume(volumeName string, files []DownwardAPIVolumeFile, defaultMode ...int32) *DaemonSet {
+	obj.error(setDownwardAPIVolume(&obj.ds.Spec.Template, volumeName, files, defaultMode...))
+	return obj
+}
+
+// SetProjectedVolume declare projected volume on DaemonSet Pod, it projects ConfigMap, Secret,
+// DownwardAPI and ServiceAccount token into the same directory
+func (obj *DaemonSet) SetProjectedVolume(volumeName string, sources []VolumeProjection, defaultMode ...int32) *DaemonSet {
+	obj.error(setProjectedVolume(&obj.ds.Spec.Template, volumeName, sources, defaultMode...))
+	return obj
+}
+
+// SetVolumeMount mount volume on DaemonSet container, mount.Container is the container name, default is the first container,
+// the volume must be declared by SetPVClaim(), SetConfigMapVolume() and the other volume functions
+func (obj *DaemonSet) SetVolumeMount(mount VolumeMount) *DaemonSet {
+	obj.error(setVolumeMount(&obj.ds.Spec.Template, mount))
+	return obj
+}
+
+// SetConfigMapFiles mount every key of ConfigMap as a read-only file in mountPath of DaemonSet container,
+// container[0] is the container name, default is the first container.
+// eg: NewCM().SetName("nginx").FromDir("conf") and SetConfigMapFiles(cm, "/etc/nginx/conf.d")
+func (obj *DaemonSet) SetConfigMapFiles(cm *ConfigMap, mountPath string, container ...string) *DaemonSet {
+	obj.error(setConfigMapFiles(&obj.ds.Spec.Template, cm, mountPath, container...))
+	return obj
+}
+
 // SetPreStopExec set StatefulSet PreStop command
 // PreStop is called immediately before a container is terminated.
 // The container is terminated after the handler completes.
@@ -264,6 +341,14 @@ func (obj *DaemonSet) SetPodPriorityClass(priorityClassName string) *DaemonSet {
 	return obj
 }
 
+// SetServiceAccount set DaemonSet Pod ServiceAccount
+// serviceAccountName is Kubernetes resource object ServiceAccount name, eg: UnionRBAC.GetName()
+// automount[0] set whether the ServiceAccount token should be automatically mounted,default use ServiceAccount setting.
+func (obj *DaemonSet) SetServiceAccount(serviceAccountName string, automount ...bool) *DaemonSet {
+	obj.error(setServiceAccount(&obj.ds.Spec.Template, serviceAccountName, automount...))
+	return obj
+}
+
 // SetEnvs set Pod Environmental variable
 func (obj *DaemonSet) SetEnvs(envMap map[string]string) *DaemonSet {
 	obj.error(setEnvs(&obj.ds.Spec.Template, envMap))
@@ -314,6 +399,9 @@ func (obj *DaemonSet) Release() (*v1.DaemonSet, error) {
 	if err != nil {
 		return nil, err
 	}
+	if err = priorityClassExists(client, ds.Spec.Template.Spec.PriorityClassName); err != nil {
+		return nil, err
+	}
 	return client.AppsV1().DaemonSets(ds.GetNamespace()).Create(ds)
 }
 
@@ -333,6 +421,9 @@ func (obj *DaemonSet) Apply() (*v1.DaemonSet, error) {
 	if err != nil {
 		return nil, err
 	}
+	if err = priorityClassExists(client, ds.Spec.Template.Spec.PriorityClassName); err != nil {
+		return nil, err
+	}
 	_, err = client.AppsV1().DaemonSets(ds.GetNamespace()).Get(ds.GetName(), metav1.GetOptions{})
 	if err != nil {
 		return client.AppsV1().DaemonSets(ds.GetNamespace()).Create(ds)
@@ -360,6 +451,10 @@ func (obj *DaemonSet) verify() {
 		obj.err = fmt.Errorf("DaemonSet.Spec.Template.Spec.Containers err:%s", err.Error())
 		return
 	}
+	if err := verifyVolumeMounts(obj.ds.Spec.Template.Spec); err != nil {
+		obj.err = fmt.Errorf("DaemonSet.Spec.Template.Spec.Volumes err:%s", err.Error())
+		return
+	}
 
 	if len(obj.GetPodLabel()) < 1 {
 		obj.err = errors.New("Pod Labels is not allowed to be empty,you can call SetPodLabels input")
@@ -380,10 +475,12 @@ func (obj *DaemonSet) verify() {
 		}
 	}
 	obj.ds.Kind = "DaemonSet"
-	obj.ds.APIVersion = "app/v1"
+	obj.ds.APIVersion = "apps/v1"
 	if obj.ds.Annotations[ImagePullPolicyKey] == "" {
 		for index := range obj.ds.Spec.Template.Spec.Containers {
-			obj.ds.Spec.Template.Spec.Containers[index].ImagePullPolicy = corev1.PullIfNotPresent
+			if obj.ds.Spec.Template.Spec.Containers[index].ImagePullPolicy == "" {
+				obj.ds.Spec.Template.Spec.Containers[index].ImagePullPolicy = corev1.PullIfNotPresent
+			}
 		}
 		return
 	}
@@ -7,13 +7,21 @@ import (
 
 	"github.com/ghodss/yaml"
 	"k8s.io/api/apps/v1"
+	"k8s.io/api/autoscaling/v2beta2"
 	corev1 "k8s.io/api/core/v1"
+	policyv1beta1 "k8s.io/api/policy/v1beta1"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/util/intstr"
 )
 
 // Deployment include Kubernetes resource object Deployment and error
 type Deployment struct {
-	dp  *v1.Deployment
+	dp *v1.Deployment
+	// hpa is set by SetAutoscale(), it is released together with Deployment
+	hpa *HPA
+	// pdb is set by SetDisruptionBudget(), it is released together with Deployment
+	pdb *PDB
 	err error
 }
 
@@ -41,6 +49,28 @@ func (obj *Deployment) YAMLNew(yamlbyts []byte) *Deployment {
 	return obj
 }
 
+// JSONNewTemplate use json template and values create Deployment,
+// the template is rendered by RenderTemplate() and must be valid json
+func (obj *Deployment) JSONNewTemplate(tpl []byte, values interface{}) *Deployment {
+	jsonbyts, err := renderJSONTemplate(tpl, values)
+	if err != nil {
+		obj.error(err)
+		return obj
+	}
+	return obj.JSONNew(jsonbyts)
+}
+
+// YAMLNewTemplate use yaml template and values create Deployment,
+// the template is rendered by RenderTemplate() and must be valid yaml
+func (obj *Deployment) YAMLNewTemplate(tpl []byte, values interface{}) *Deployment {
+	yamlbyts, err := renderYAMLTemplate(tpl, values)
+	if err != nil {
+		obj.error(err)
+		return obj
+	}
+	return obj.YAMLNew(yamlbyts)
+}
+
 // Replace replace Deployment by Kubernetes resource object
 func (obj *Deployment) Replace(dp *v1.Deployment) *Deployment {
 	if dp != nil {
@@ -316,6 +346,14 @@ func (obj *Deployment) SetPodPriorityClass(priorityClassName string) *Deployment
 	return obj
 }
 
+// SetServiceAccount set Deployment Pod ServiceAccount
+// serviceAccountName is Kubernetes resource object ServiceAccount name, eg: UnionRBAC.GetName()
+// automount[0] set whether the ServiceAccount token should be automatically mounted,default use ServiceAccount setting.
+func (obj *Deployment) SetServiceAccount(serviceAccountName string, automount ...bool) *Deployment {
+	obj.error(setServiceAccount(&obj.dp.Spec.Template, serviceAccountName, automount...))
+	return obj
+}
+
 // SetPVClaim set Deployment PersistentVolumeClaimVolumeSource
 // params:
 // volumeName: this is Custom field,you can define VolumeSource name,will be used of the container MountPath,
@@ -335,6 +373,61 @@ func (obj *Deployment) SetPVCMounts(volumeName, mountPath string) *Deployment {
 	return obj
 }
 
+// SetConfigMapVolume declare ConfigMap volume on Deployment Pod, volumeName is used by SetVolumeMount(),
+// cm.Items is the keys which are projected, all keys are projected when it is empty
+func (obj *Deployment) SetConfigMapVolume(volumeName string, cm *ConfigMapVolumeSource) *Deployment {
+	obj.error(setConfigMapVolume(&obj.dp.Spec.Template, volumeName, cm))
+	return obj
+}
+
+// SetSecretVolume declare Secret volume on Deployment Pod, volumeName is used by SetVolumeMount(),
+// secret.Items is the keys which are projected, all keys are projected when it is empty
+func (obj *Deployment) SetSecretVolume(volumeName string, secret *SecretVolumeSource) *Deployment {
+	obj.error(setSecretVolume(&obj.dp.Spec.Template, volumeName, secret))
+	return obj
+}
+
+// SetEmptyDirVolume declare EmptyDir volume on Deployment Pod, it is deleted when Pod is deleted,
+// memory: use tmpfs, sizeLimit[0] is the max size of volume, eg: 1Gi
+func (obj *Deployment) SetEmptyDirVolume(volumeName string, memory bool, sizeLimit ...string) *Deployment {
+	obj.error(setEmptyDirVolume(&obj.dp.Spec.Template, volumeName, memory, sizeLimit...))
+	return obj
+}
+
+// SetHostPathVolume declare HostPath volume on Deployment Pod, path is the absolute path on node
+func (obj *Deployment) SetHostPathVolume(volumeName, path string, hostPathType ...HostPathType) *Deployment {
+	obj.error(setHostPathVolume(&obj.dp.Spec.Template, volumeName, path, hostPathType...))
+	return obj
+}
+
+// SetDownwardAPIVolume declare DownwardAPI volume on Deployment Pod, Pod fields and container resources are exposed as files
+func (obj *Deployment) SetDownwardAPIVolume(volumeName string, files []DownwardAPIVolumeFile, defaultMode ...int32) *Deployment {
+	obj.error(setDownwardAPIVolume(&obj.dp.Spec.Template, volumeName, files, defaultMode...))
+	return obj
+}
+
+// SetProjectedVolume declare projected volume on Deployment Pod, it projects ConfigMap, Secret,
+// DownwardAPI and ServiceAccount token into the same directory
+func (obj *Deployment) SetProjectedVolume(volumeName string, sources []VolumeProjection, defaultMode ...int32) *Deployment {
+	obj.error(setProjectedVolume(&obj.dp.Spec.Template, volumeName, sources, defaultMode...))
+	return obj
+}
+
+// SetVolumeMount mount volume on Deployment container, mount.Container is the container name, default is the first container,
+// the volume must be declared by SetPVClaim(), SetConfigMapVolume() and the other volume functions
+func (obj *Deployment) SetVolumeMount(mount VolumeMount) *Deployment {
+	obj.error(setVolumeMount(&obj.dp.Spec.Template, mount))
+	return obj
+}
+
+// SetConfigMapFiles mount every key of ConfigMap as a read-only file in mountPath of Deployment container,
+// container[0] is the container name, default is the first container.
+// eg: NewCM().SetName("nginx").FromDir("conf") and SetConfigMapFiles(cm, "/etc/nginx/conf.d")
+func (obj *Deployment) SetConfigMapFiles(cm *ConfigMap, mountPath string, container ...string) *Deployment {
+	obj.error(setConfigMapFiles(&obj.dp.Spec.Template, cm, mountPath, container...))
+	return obj
+}
+
 func (obj *Deployment) error(err error) {
 	if obj.err != nil {
 		return
@@ -388,9 +481,89 @@ func (obj *Deployment) SetEnvs(envMap map[string]string) *Deployment {
 	return obj
 }
 
-// Release release Deployment on Kubernetes
-func (obj *Deployment) Release() (*v1.Deployment, error) {
+// SetAutoscale scale Deployment by HorizontalPodAutoscaler(HPA) which has the same name as Deployment,
+// min and max is the limit of replicas, cpuPercent is the target of average cpu utilization of Pods,
+// containers must set cpu requests. HPA is released together with Deployment by Release() and Apply(),
+// you can get it by FinishWithHPA().
+func (obj *Deployment) SetAutoscale(min, max, cpuPercent int32) *Deployment {
+	hpa := NewHPA().SetReplicas(min, max).SetCPUUtilization(cpuPercent)
+	if hpa.err != nil {
+		obj.error(fmt.Errorf("SetAutoscale err:%s", hpa.err.Error()))
+		return obj
+	}
+	obj.hpa = hpa
+	if obj.dp.Spec.Replicas == nil {
+		obj.SetReplicas(min)
+	}
+	return obj
+}
+
+// SetDisruptionBudget protect Pods of Deployment by PodDisruptionBudget(PDB) which has the same name as Deployment,
+// val is minAvailable by default, it is maxUnavailable when maxUnavailable[0] is true, eg: FromInt(1),Parse("50%").
+// PDB is released together with Deployment by Release() and Apply(), you can get it by FinishWithPDB().
+func (obj *Deployment) SetDisruptionBudget(val intstr.IntOrString, maxUnavailable ...bool) *Deployment {
+	pdb := NewPDB()
+	if len(maxUnavailable) > 0 && maxUnavailable[0] {
+		pdb.SetMaxUnavailable(val)
+	} else {
+		pdb.SetMinAvailable(val)
+	}
+	if pdb.err != nil {
+		obj.error(fmt.Errorf("SetDisruptionBudget err:%s", pdb.err.Error()))
+		return obj
+	}
+	obj.pdb = pdb
+	return obj
+}
+
+// FinishWithHPA Chain function call end with this function
+// return Kubernetes resource object Deployment and HorizontalPodAutoscaler which is set by SetAutoscale() and error.
+func (obj *Deployment) FinishWithHPA() (*v1.Deployment, *v2beta2.HorizontalPodAutoscaler, error) {
+	dp, err := obj.Finish()
+	if err != nil {
+		return nil, nil, err
+	}
+	if obj.hpa == nil {
+		return nil, nil, errors.New("Deployment autoscale is not set,you can call SetAutoscale() input")
+	}
+	hpa, err := obj.hpa.SetDeployment(dp).Finish()
+	if err != nil {
+		return nil, nil, err
+	}
+	return dp, hpa, nil
+}
+
+// FinishWithPDB Chain function call end with this function
+// return Kubernetes resource object Deployment and PodDisruptionBudget which is set by SetDisruptionBudget() and error,
+// you can get PDB warnings by Warnings()
+func (obj *Deployment) FinishWithPDB() (*v1.Deployment, *policyv1beta1.PodDisruptionBudget, error) {
 	dp, err := obj.Finish()
+	if err != nil {
+		return nil, nil, err
+	}
+	if obj.pdb == nil {
+		return nil, nil, errors.New("Deployment disruption budget is not set,you can call SetDisruptionBudget() input")
+	}
+	pdb, err := obj.pdb.SetDeployment(dp).Finish()
+	if err != nil {
+		return nil, nil, err
+	}
+	return dp, pdb, nil
+}
+
+// Warnings return the warnings of PDB which is set by SetDisruptionBudget(),
+// it is available after Finish,eg: PDB blocks all evictions
+func (obj *Deployment) Warnings() []string {
+	if obj.pdb == nil {
+		return nil
+	}
+	return obj.pdb.Warnings()
+}
+
+// Release release Deployment on Kubernetes,
+// HPA and PDB will be released too when they are set by SetAutoscale() and SetDisruptionBudget()
+func (obj *Deployment) Release() (*v1.Deployment, error) {
+	dp, err := obj.finishAll()
 	if err != nil {
 		return nil, err
 	}
@@ -398,13 +571,22 @@ func (obj *Deployment) Release() (*v1.Deployment, error) {
 	if err != nil {
 		return nil, err
 	}
-	return client.AppsV1().Deployments(dp.GetNamespace()).Create(dp)
+	if err = priorityClassExists(client, dp.Spec.Template.Spec.PriorityClassName); err != nil {
+		return nil, err
+	}
+	dp, err = client.AppsV1().Deployments(dp.GetNamespace()).Create(dp)
+	if err != nil {
+		return nil, err
+	}
+	return dp, obj.releaseAttached(false)
 }
 
 // Apply  it will be updated when this resource object exists in K8s,
 // it will be created when it does not exist.
+// replicas of Deployment on Kubernetes is kept when it is scaled by HPA.
+// HPA and PDB will be applied too when they are set by SetAutoscale() and SetDisruptionBudget()
 func (obj *Deployment) Apply() (*v1.Deployment, error) {
-	dp, err := obj.Finish()
+	dp, err := obj.finishAll()
 	if err != nil {
 		return nil, err
 	}
@@ -412,11 +594,82 @@ func (obj *Deployment) Apply() (*v1.Deployment, error) {
 	if err != nil {
 		return nil, err
 	}
-	_, err = client.AppsV1().Deployments(dp.GetNamespace()).Get(dp.GetName(), metav1.GetOptions{})
+	if err = priorityClassExists(client, dp.Spec.Template.Spec.PriorityClassName); err != nil {
+		return nil, err
+	}
+	present, err := client.AppsV1().Deployments(dp.GetNamespace()).Get(dp.GetName(), metav1.GetOptions{})
+	if err != nil {
+		dp, err = client.AppsV1().Deployments(dp.GetNamespace()).Create(dp)
+	} else {
+		if obj.hpa != nil || hpaManaged(client, dp.GetNamespace(), "Deployment", dp.GetName()) {
+			dp.Spec.Replicas = present.Spec.Replicas
+		}
+		dp, err = client.AppsV1().Deployments(dp.GetNamespace()).Update(dp)
+	}
+	if err != nil {
+		return nil, err
+	}
+	return dp, obj.releaseAttached(true)
+}
+
+// objects finish Deployment,HPA and PDB which are set by SetAutoscale() and SetDisruptionBudget()
+func (obj *Deployment) objects() ([]runtime.Object, error) {
+	dp, err := obj.Finish()
+	if err != nil {
+		return nil, err
+	}
+	objs := []runtime.Object{dp}
+	if obj.hpa != nil {
+		hpa, err := obj.hpa.SetDeployment(dp).Finish()
+		if err != nil {
+			return nil, err
+		}
+		objs = append(objs, hpa)
+	}
+	if obj.pdb != nil {
+		pdb, err := obj.pdb.SetDeployment(dp).Finish()
+		if err != nil {
+			return nil, err
+		}
+		objs = append(objs, pdb)
+	}
+	return objs, nil
+}
+
+// finishAll finish Deployment with HPA and PDB, so their errors are returned before releasing
+func (obj *Deployment) finishAll() (*v1.Deployment, error) {
+	objs, err := obj.objects()
 	if err != nil {
-		return client.AppsV1().Deployments(dp.GetNamespace()).Create(dp)
+		return nil, err
+	}
+	return objs[0].(*v1.Deployment), nil
+}
+
+// releaseAttached release or apply HPA and PDB of Deployment
+func (obj *Deployment) releaseAttached(apply bool) error {
+	if obj.hpa != nil {
+		var err error
+		if apply {
+			_, err = obj.hpa.Apply()
+		} else {
+			_, err = obj.hpa.Release()
+		}
+		if err != nil {
+			return err
+		}
+	}
+	if obj.pdb != nil {
+		var err error
+		if apply {
+			_, err = obj.pdb.Apply()
+		} else {
+			_, err = obj.pdb.Release()
+		}
+		if err != nil {
+			return err
+		}
 	}
-	return client.AppsV1().Deployments(dp.GetNamespace()).Update(dp)
+	return nil
 }
 
 // DelNodeAffinity delete node affinitys
@@ -509,6 +762,10 @@ func (obj *Deployment) verify() {
 		obj.err = fmt.Errorf("Deployment.Spec.Template.Spec.Containers err:%s", err.Error())
 		return
 	}
+	if err := verifyVolumeMounts(obj.dp.Spec.Template.Spec); err != nil {
+		obj.err = fmt.Errorf("Deployment.Spec.Template.Spec.Volumes err:%s", err.Error())
+		return
+	}
 	if obj.dp.Spec.Selector == nil {
 		obj.SetSelector(obj.GetPodLabel())
 	}
@@ -527,11 +784,20 @@ func (obj *Deployment) verify() {
 			return
 		}
 	}
+	// the PDB is finished with Deployment, so its errors and warnings are available after Finish
+	if obj.pdb != nil {
+		if _, err := obj.pdb.SetDeployment(obj.dp).Finish(); err != nil {
+			obj.err = fmt.Errorf("Deployment disruption budget err:%s", err.Error())
+			return
+		}
+	}
 	obj.dp.Kind = "Deployment"
 	obj.dp.APIVersion = "apps/v1"
 	if obj.dp.Annotations[ImagePullPolicyKey] == "" {
 		for index := range obj.dp.Spec.Template.Spec.Containers {
-			obj.dp.Spec.Template.Spec.Containers[index].ImagePullPolicy = corev1.PullIfNotPresent
+			if obj.dp.Spec.Template.Spec.Containers[index].ImagePullPolicy == "" {
+				obj.dp.Spec.Template.Spec.Containers[index].ImagePullPolicy = corev1.PullIfNotPresent
+			}
 		}
 		return
 	}
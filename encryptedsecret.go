@@ -0,0 +1,249 @@
+package beku
+
+import (
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/sha256"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/json"
+	"encoding/pem"
+	"errors"
+	"fmt"
+	"io"
+	"regexp"
+
+	"github.com/ghodss/yaml"
+	"k8s.io/api/core/v1"
+)
+
+const (
+	// EncryptedDataKeysKey the annotation of encrypted Secret manifest, it keeps the data key
+	// which is encrypted by RSA-OAEP(SHA-256) for every recipient
+	EncryptedDataKeysKey = "beku.io/encrypted-data-keys"
+	// minRSAKeyBits the min size of recipient RSA key
+	minRSAKeyBits = 2048
+)
+
+// encryptedValue the format of encrypted Secret value, data and iv are base64 encoded
+var encryptedValue = regexp.MustCompile(`^ENC\[AES256_GCM,data:([A-Za-z0-9+/=]+),iv:([A-Za-z0-9+/=]+)\]$`)
+
+// encryptedDataKey the data key which is encrypted for one recipient
+type encryptedDataKey struct {
+	// Fingerprint sha256 of recipient public key in PKIX DER, hex encoded
+	Fingerprint string `json:"fingerprint"`
+	// Key the data key which is encrypted by RSA-OAEP(SHA-256), base64 encoded
+	Key string `json:"key"`
+}
+
+// ToEncryptedYAML output Secret as yaml which can be stored in git,
+// every data value is encrypted by a random AES-256-GCM data key and the data key is encrypted
+// by RSA-OAEP(SHA-256) for every recipient, metadata and data keys are kept readable for review.
+// you can call YAMLNewEncrypted() with the private key of any recipient to decrypt it
+func (obj *Secret) ToEncryptedYAML(recipientKeys ...*rsa.PublicKey) ([]byte, error) {
+	sec, err := obj.Finish()
+	if err != nil {
+		return nil, err
+	}
+	if len(recipientKeys) <= 0 {
+		return nil, errors.New("ToEncryptedYAML err,recipientKeys is not allowed to be empty")
+	}
+	dataKey := make([]byte, 32)
+	if _, err = io.ReadFull(rand.Reader, dataKey); err != nil {
+		return nil, err
+	}
+	var dataKeys []encryptedDataKey
+	for _, pub := range recipientKeys {
+		if pub == nil || pub.N.BitLen() < minRSAKeyBits {
+			return nil, fmt.Errorf("ToEncryptedYAML err,recipient RSA key must be at least %d bits", minRSAKeyBits)
+		}
+		fingerprint, err := rsaFingerprint(pub)
+		if err != nil {
+			return nil, err
+		}
+		wrapped, err := rsa.EncryptOAEP(sha256.New(), rand.Reader, pub, dataKey, nil)
+		if err != nil {
+			return nil, fmt.Errorf("ToEncryptedYAML err,%v", err)
+		}
+		dataKeys = append(dataKeys, encryptedDataKey{Fingerprint: fingerprint, Key: base64.StdEncoding.EncodeToString(wrapped)})
+	}
+	gcm, err := newGCM(dataKey)
+	if err != nil {
+		return nil, err
+	}
+	// stringData overwrites data on Kubernetes, so it is merged into data before encryption
+	values := make(map[string][]byte, len(sec.Data)+len(sec.StringData))
+	for key, value := range sec.Data {
+		values[key] = value
+	}
+	for key, value := range sec.StringData {
+		values[key] = []byte(value)
+	}
+	encrypted := sec.DeepCopy()
+	encrypted.Data = nil
+	encrypted.StringData = make(map[string]string, len(values))
+	for key, value := range values {
+		nonce := make([]byte, gcm.NonceSize())
+		if _, err = io.ReadFull(rand.Reader, nonce); err != nil {
+			return nil, err
+		}
+		// the key is used as additional data, so the values can't be swapped between keys
+		ciphertext := gcm.Seal(nil, nonce, value, []byte(key))
+		encrypted.StringData[key] = fmt.Sprintf("ENC[AES256_GCM,data:%s,iv:%s]",
+			base64.StdEncoding.EncodeToString(ciphertext), base64.StdEncoding.EncodeToString(nonce))
+	}
+	keysJSON, err := json.Marshal(dataKeys)
+	if err != nil {
+		return nil, err
+	}
+	if encrypted.Annotations == nil {
+		encrypted.Annotations = make(map[string]string)
+	}
+	encrypted.Annotations[EncryptedDataKeysKey] = string(keysJSON)
+	return yaml.Marshal(encrypted)
+}
+
+// YAMLNewEncrypted use yaml data which is output by ToEncryptedYAML() create Secret,
+// privateKey is the private key of any recipient, the values are decrypted into data
+func (obj *Secret) YAMLNewEncrypted(yamlbyts []byte, privateKey *rsa.PrivateKey) *Secret {
+	if privateKey == nil {
+		obj.error(errors.New("YAMLNewEncrypted err,privateKey is not allowed to be empty"))
+		return obj
+	}
+	sec := &v1.Secret{}
+	if err := yaml.Unmarshal(yamlbyts, sec); err != nil {
+		obj.error(fmt.Errorf("YAMLNewEncrypted err,%v", err))
+		return obj
+	}
+	dataKey, err := unwrapDataKey(sec.Annotations[EncryptedDataKeysKey], privateKey)
+	if err != nil {
+		obj.error(fmt.Errorf("YAMLNewEncrypted err,%v", err))
+		return obj
+	}
+	gcm, err := newGCM(dataKey)
+	if err != nil {
+		obj.error(fmt.Errorf("YAMLNewEncrypted err,%v", err))
+		return obj
+	}
+	if len(sec.Data) > 0 {
+		obj.error(errors.New("YAMLNewEncrypted err,Secret data is not encrypted, it is only allowed to be in stringData"))
+		return obj
+	}
+	sec.Data = make(map[string][]byte, len(sec.StringData))
+	for key, value := range sec.StringData {
+		match := encryptedValue.FindStringSubmatch(value)
+		if match == nil {
+			obj.error(fmt.Errorf("YAMLNewEncrypted err,Secret key %s is not encrypted", key))
+			return obj
+		}
+		ciphertext, err := base64.StdEncoding.DecodeString(match[1])
+		if err != nil {
+			obj.error(fmt.Errorf("YAMLNewEncrypted err,Secret key %s %v", key, err))
+			return obj
+		}
+		nonce, err := base64.StdEncoding.DecodeString(match[2])
+		if err != nil || len(nonce) != gcm.NonceSize() {
+			obj.error(fmt.Errorf("YAMLNewEncrypted err,Secret key %s iv is invalid", key))
+			return obj
+		}
+		plaintext, err := gcm.Open(nil, nonce, ciphertext, []byte(key))
+		if err != nil {
+			obj.error(fmt.Errorf("YAMLNewEncrypted err,Secret key %s can't be decrypted:%v", key, err))
+			return obj
+		}
+		sec.Data[key] = plaintext
+	}
+	sec.StringData = nil
+	delete(sec.Annotations, EncryptedDataKeysKey)
+	obj.sc = sec
+	return obj
+}
+
+// unwrapDataKey find the data key which is encrypted for privateKey and decrypt it
+func unwrapDataKey(annotation string, privateKey *rsa.PrivateKey) ([]byte, error) {
+	if !verifyString(annotation) {
+		return nil, fmt.Errorf("annotation %s is not found, it is not an encrypted Secret", EncryptedDataKeysKey)
+	}
+	var dataKeys []encryptedDataKey
+	if err := json.Unmarshal([]byte(annotation), &dataKeys); err != nil {
+		return nil, fmt.Errorf("annotation %s is invalid:%v", EncryptedDataKeysKey, err)
+	}
+	fingerprint, err := rsaFingerprint(&privateKey.PublicKey)
+	if err != nil {
+		return nil, err
+	}
+	for _, dataKey := range dataKeys {
+		if dataKey.Fingerprint != fingerprint {
+			continue
+		}
+		wrapped, err := base64.StdEncoding.DecodeString(dataKey.Key)
+		if err != nil {
+			return nil, err
+		}
+		return rsa.DecryptOAEP(sha256.New(), rand.Reader, privateKey, wrapped, nil)
+	}
+	return nil, fmt.Errorf("privateKey %s is not a recipient of the Secret", fingerprint)
+}
+
+// rsaFingerprint return sha256 of public key in PKIX DER, hex encoded
+func rsaFingerprint(pub *rsa.PublicKey) (string, error) {
+	der, err := x509.MarshalPKIXPublicKey(pub)
+	if err != nil {
+		return "", err
+	}
+	sum := sha256.Sum256(der)
+	return hex.EncodeToString(sum[:]), nil
+}
+
+func newGCM(key []byte) (cipher.AEAD, error) {
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		return nil, err
+	}
+	return cipher.NewGCM(block)
+}
+
+// ParseRSAPublicKey parse PEM encoded RSA public key, it supports "PUBLIC KEY"(PKIX) and "RSA PUBLIC KEY"(PKCS#1),
+// eg: openssl rsa -in key.pem -pubout
+func ParseRSAPublicKey(pemBytes []byte) (*rsa.PublicKey, error) {
+	block, _ := pem.Decode(pemBytes)
+	if block == nil {
+		return nil, errors.New("RSA public key is not PEM encoded")
+	}
+	if block.Type == "RSA PUBLIC KEY" {
+		return x509.ParsePKCS1PublicKey(block.Bytes)
+	}
+	pub, err := x509.ParsePKIXPublicKey(block.Bytes)
+	if err != nil {
+		return nil, err
+	}
+	rsaPub, ok := pub.(*rsa.PublicKey)
+	if !ok {
+		return nil, errors.New("public key is not RSA public key")
+	}
+	return rsaPub, nil
+}
+
+// ParseRSAPrivateKey parse PEM encoded RSA private key, it supports "RSA PRIVATE KEY"(PKCS#1) and "PRIVATE KEY"(PKCS#8),
+// eg: openssl genrsa -out key.pem 4096
+func ParseRSAPrivateKey(pemBytes []byte) (*rsa.PrivateKey, error) {
+	block, _ := pem.Decode(pemBytes)
+	if block == nil {
+		return nil, errors.New("RSA private key is not PEM encoded")
+	}
+	if block.Type == "RSA PRIVATE KEY" {
+		return x509.ParsePKCS1PrivateKey(block.Bytes)
+	}
+	key, err := x509.ParsePKCS8PrivateKey(block.Bytes)
+	if err != nil {
+		return nil, err
+	}
+	rsaKey, ok := key.(*rsa.PrivateKey)
+	if !ok {
+		return nil, errors.New("private key is not RSA private key")
+	}
+	return rsaKey, nil
+}
@@ -0,0 +1,271 @@
+package beku
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net"
+
+	"github.com/ghodss/yaml"
+	"k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+// Endpoints include Kubernetes resource object Endpoints and error,
+// Endpoints with the same namespace and name as a Service without selector
+// makes the Service route to addresses outside the cluster
+type Endpoints struct {
+	ep  *v1.Endpoints
+	err error
+}
+
+// NewEndpoints create Endpoints and chain function call begin with this function.
+func NewEndpoints() *Endpoints {
+	return &Endpoints{ep: &v1.Endpoints{Subsets: []v1.EndpointSubset{{}}}}
+}
+
+// Finish Chain function call end with this function
+// return Kubernetes resource object Endpoints and error.
+// In the function, it will check necessary parameters,input the default field
+func (obj *Endpoints) Finish() (*v1.Endpoints, error) {
+	obj.verify()
+	return obj.ep, obj.err
+}
+
+// JSONNew use json data create Endpoints
+func (obj *Endpoints) JSONNew(jsonbyts []byte) *Endpoints {
+	obj.error(json.Unmarshal(jsonbyts, obj.ep))
+	return obj
+}
+
+// YAMLNew use yaml data create Endpoints
+func (obj *Endpoints) YAMLNew(yamlbyts []byte) *Endpoints {
+	obj.error(yaml.Unmarshal(yamlbyts, obj.ep))
+	return obj
+}
+
+// JSONNewTemplate use json template and values create Endpoints,
+// the template is rendered by RenderTemplate() and must be valid json
+func (obj *Endpoints) JSONNewTemplate(tpl []byte, values interface{}) *Endpoints {
+	jsonbyts, err := renderJSONTemplate(tpl, values)
+	if err != nil {
+		obj.error(err)
+		return obj
+	}
+	return obj.JSONNew(jsonbyts)
+}
+
+// YAMLNewTemplate use yaml template and values create Endpoints,
+// the template is rendered by RenderTemplate() and must be valid yaml
+func (obj *Endpoints) YAMLNewTemplate(tpl []byte, values interface{}) *Endpoints {
+	yamlbyts, err := renderYAMLTemplate(tpl, values)
+	if err != nil {
+		obj.error(err)
+		return obj
+	}
+	return obj.YAMLNew(yamlbyts)
+}
+
+// Replace replace Endpoints by Kubernetes resource object
+func (obj *Endpoints) Replace(ep *v1.Endpoints) *Endpoints {
+	if ep != nil {
+		obj.ep = ep
+	}
+	return obj
+}
+
+// SetName set Endpoints name, it must be the same as Service name
+func (obj *Endpoints) SetName(name string) *Endpoints {
+	obj.ep.SetName(name)
+	return obj
+}
+
+// SetNamespace set Endpoints namespace, it must be the same as Service namespace
+func (obj *Endpoints) SetNamespace(namespace string) *Endpoints {
+	obj.ep.SetNamespace(namespace)
+	return obj
+}
+
+// SetNamespaceAndName set Endpoints namespace and name
+func (obj *Endpoints) SetNamespaceAndName(namespace, name string) *Endpoints {
+	obj.SetNamespace(namespace)
+	obj.SetName(name)
+	return obj
+}
+
+// SetLabels set Endpoints labels
+func (obj *Endpoints) SetLabels(labels map[string]string) *Endpoints {
+	obj.ep.SetLabels(labels)
+	return obj
+}
+
+// SetAnnotations set Endpoints annotations
+func (obj *Endpoints) SetAnnotations(annotations map[string]string) *Endpoints {
+	if len(obj.ep.Annotations) <= 0 {
+		obj.ep.Annotations = annotations
+		return obj
+	}
+	for key, value := range annotations {
+		obj.ep.Annotations[key] = value
+	}
+	return obj
+}
+
+// SetAddresses set IP addresses of Endpoints, it can be called many times.
+// the addresses must be IP, hostname is not allowed, you can use Service.SetExternalName() instead
+func (obj *Endpoints) SetAddresses(ips ...string) *Endpoints {
+	subset := obj.subset()
+	for _, ip := range ips {
+		if net.ParseIP(ip) == nil {
+			obj.error(fmt.Errorf("SetAddresses err,%s is not a valid IP", ip))
+			return obj
+		}
+		subset.Addresses = append(subset.Addresses, v1.EndpointAddress{IP: ip})
+	}
+	return obj
+}
+
+// SetPorts set ports of Endpoints, it can be called many times.
+// port name must be the same as the name of Service port
+func (obj *Endpoints) SetPorts(ports ...EndpointPort) *Endpoints {
+	subset := obj.subset()
+	for _, port := range ports {
+		if port.Port <= 0 || port.Port > 65535 {
+			obj.error(fmt.Errorf("SetPorts err,port %d is not between 1 and 65535", port.Port))
+			return obj
+		}
+		subset.Ports = append(subset.Ports, v1.EndpointPort{
+			Name:     port.Name,
+			Port:     port.Port,
+			Protocol: port.Protocol.ToK8s(),
+		})
+	}
+	return obj
+}
+
+// subset return the subset which SetAddresses() and SetPorts() write into
+func (obj *Endpoints) subset() *v1.EndpointSubset {
+	if len(obj.ep.Subsets) <= 0 {
+		obj.ep.Subsets = []v1.EndpointSubset{{}}
+	}
+	return &obj.ep.Subsets[0]
+}
+
+// Release release Endpoints on Kubernetes
+func (obj *Endpoints) Release() (*v1.Endpoints, error) {
+	ep, err := obj.Finish()
+	if err != nil {
+		return nil, err
+	}
+	client, err := GetKubeClient()
+	if err != nil {
+		return nil, err
+	}
+	return client.CoreV1().Endpoints(ep.GetNamespace()).Create(ep)
+}
+
+// Apply it will be updated when this resource object exists in K8s,
+// it will be created when it does not exist.
+func (obj *Endpoints) Apply() (*v1.Endpoints, error) {
+	ep, err := obj.Finish()
+	if err != nil {
+		return nil, err
+	}
+	client, err := GetKubeClient()
+	if err != nil {
+		return nil, err
+	}
+	_, err = client.CoreV1().Endpoints(ep.GetNamespace()).Get(ep.GetName(), metav1.GetOptions{})
+	if err != nil {
+		return client.CoreV1().Endpoints(ep.GetNamespace()).Create(ep)
+	}
+	return client.CoreV1().Endpoints(ep.GetNamespace()).Update(ep)
+}
+
+// Delete delete Endpoints on Kubernetes
+func (obj *Endpoints) Delete() error {
+	ep, err := obj.Finish()
+	if err != nil {
+		return err
+	}
+	client, err := GetKubeClient()
+	if err != nil {
+		return err
+	}
+	return client.CoreV1().Endpoints(ep.GetNamespace()).Delete(ep.GetName(), &metav1.DeleteOptions{})
+}
+
+func (obj *Endpoints) error(err error) {
+	if obj.err != nil {
+		return
+	}
+	obj.err = err
+}
+
+// matchService check Endpoints ports are the same as Service ports by name,
+// Service must not have selector, otherwise Endpoints will be overwritten by Kubernetes
+func (obj *Endpoints) matchService(svc *v1.Service) error {
+	if verifyMap(svc.Spec.Selector) {
+		return fmt.Errorf("Endpoints of Service %s is not allowed when Service has selector", svc.GetName())
+	}
+	if svc.Spec.Type == v1.ServiceTypeExternalName {
+		return fmt.Errorf("Endpoints of Service %s is not allowed when Service type is ExternalName", svc.GetName())
+	}
+	svcPorts := make(map[string]bool, len(svc.Spec.Ports))
+	for _, port := range svc.Spec.Ports {
+		svcPorts[port.Name] = true
+	}
+	epPorts := make(map[string]bool, len(svc.Spec.Ports))
+	for _, subset := range obj.ep.Subsets {
+		for _, port := range subset.Ports {
+			if !svcPorts[port.Name] {
+				return fmt.Errorf("Endpoints port name '%s' is not found in Service %s ports", port.Name, svc.GetName())
+			}
+			epPorts[port.Name] = true
+		}
+	}
+	for name := range svcPorts {
+		if !epPorts[name] {
+			return fmt.Errorf("Service %s port name '%s' is not found in Endpoints ports", svc.GetName(), name)
+		}
+	}
+	return nil
+}
+
+// verify check Endpoints necessary value, input the default field and input related data.
+func (obj *Endpoints) verify() {
+	if obj.err != nil {
+		return
+	}
+	if !verifyString(obj.ep.GetName()) {
+		obj.err = errors.New("Endpoints name is not allowed to be empty")
+		return
+	}
+	if !verifyString(obj.ep.GetNamespace()) {
+		obj.ep.SetNamespace("default")
+	}
+	for index, subset := range obj.ep.Subsets {
+		if len(subset.Addresses) <= 0 && len(subset.NotReadyAddresses) <= 0 {
+			obj.err = fmt.Errorf("Endpoints subsets[%d] addresses is not allowed to be empty,you can call SetAddresses() input", index)
+			return
+		}
+		if len(subset.Ports) <= 0 {
+			obj.err = fmt.Errorf("Endpoints subsets[%d] ports is not allowed to be empty,you can call SetPorts() input", index)
+			return
+		}
+		names := make(map[string]bool, len(subset.Ports))
+		for _, port := range subset.Ports {
+			if len(subset.Ports) > 1 && !verifyString(port.Name) {
+				obj.err = fmt.Errorf("Endpoints subsets[%d] port name is not allowed to be empty when it has more than one port", index)
+				return
+			}
+			if names[port.Name] {
+				obj.err = fmt.Errorf("Endpoints subsets[%d] port name '%s' is repeated", index, port.Name)
+				return
+			}
+			names[port.Name] = true
+		}
+	}
+	obj.ep.Kind = "Endpoints"
+	obj.ep.APIVersion = "v1"
+}
@@ -111,13 +111,18 @@ func Base64Decode(src string) ([]byte, error) {
 
 // ResourceMapsToK8s to K8s resourceList
 func ResourceMapsToK8s(maps map[ResourceName]string) (v1.ResourceList, error) {
+	return resourceMapsToK8s(maps, stringToResourceName)
+}
+
+// resourceMapsToK8s to K8s resourceList, the resource name is checked by resolve
+func resourceMapsToK8s(maps map[ResourceName]string, resolve func(string) ResourceName) (v1.ResourceList, error) {
 	data := make(v1.ResourceList, 0)
 	for k, v := range maps {
 		q, err := apiresource.ParseQuantity(v)
 		if err != nil {
 			return nil, err
 		}
-		reName := k.ToK8s()
+		reName := v1.ResourceName(resolve(string(k)))
 		if reName == "" {
 			return nil, errors.New("resource name not allow")
 		}
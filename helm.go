@@ -0,0 +1,306 @@
+package beku
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strings"
+
+	"k8s.io/apimachinery/pkg/runtime"
+)
+
+// HelmValue is the field which will be parameterised into Helm chart values.yaml
+type HelmValue string
+
+// HelmValue params
+const (
+	// HelmImage container image
+	HelmImage HelmValue = "image"
+	// HelmReplicas Deployment and StatefulSet replicas
+	HelmReplicas HelmValue = "replicas"
+	// HelmResources container resources, it is set by SetResourceLimit() and SetResourceRequst()
+	HelmResources HelmValue = "resources"
+	// HelmEnvs container environmental variable, it is set by SetEnvs()
+	HelmEnvs HelmValue = "env"
+)
+
+const helmPlaceholder = "BEKUHELMVALUE"
+
+var (
+	helmChartName    = regexp.MustCompile(`^[a-z0-9]([-a-z0-9]*[a-z0-9])?$`)
+	helmChartVersion = regexp.MustCompile(`^v?(0|[1-9]\d*)\.(0|[1-9]\d*)\.(0|[1-9]\d*)(-[0-9A-Za-z-.]+)?(\+[0-9A-Za-z-.]+)?$`)
+	// clusterScopedKinds Kubernetes resource objects which have no namespace
+	clusterScopedKinds = map[string]bool{
+		"Namespace":          true,
+		"Node":               true,
+		"PersistentVolume":   true,
+		"StorageClass":       true,
+		"ClusterRole":        true,
+		"ClusterRoleBinding": true,
+		"PriorityClass":      true,
+	}
+)
+
+// HelmChart export Bundle as Helm chart and error
+type HelmChart struct {
+	name        string
+	version     string
+	appVersion  string
+	description string
+	bundle      *Bundle
+	values      []HelmValue
+	err         error
+}
+
+// NewHelmChart create Helm chart and chain function call begin with this function.
+// name is chart name, only lower case letters, numbers and '-' are allowed.
+// version is chart version, it must be SemVer 2, eg: 0.1.0
+func NewHelmChart(name, version string) *HelmChart {
+	return &HelmChart{name: name, version: version}
+}
+
+// Finish Chain function call end with this function
+// return Helm chart files and error, key is file path relative to chart directory, eg: Chart.yaml,templates/deployment-mysql.yaml
+// In the function, it will check necessary parameters,input the default field
+func (obj *HelmChart) Finish() (map[string][]byte, error) {
+	obj.verify()
+	if obj.err != nil {
+		return nil, obj.err
+	}
+	objs, err := obj.bundle.Finish()
+	if err != nil {
+		return nil, err
+	}
+	files := make(map[string][]byte, 0)
+	chart := map[string]string{
+		"apiVersion":  "v1",
+		"name":        obj.name,
+		"version":     obj.version,
+		"appVersion":  obj.appVersion,
+		"description": obj.description,
+	}
+	if files["Chart.yaml"], err = ToYAML(chart); err != nil {
+		return nil, err
+	}
+	values := make(map[string]interface{}, 0)
+	for _, o := range objs {
+		name, byts, err := obj.template(o, values)
+		if err != nil {
+			return nil, err
+		}
+		if _, ok := files[name]; ok {
+			return nil, fmt.Errorf("HelmChart err,template %s is repeated", name)
+		}
+		files[name] = byts
+	}
+	if files["values.yaml"], err = ToYAML(values); err != nil {
+		return nil, err
+	}
+	return files, nil
+}
+
+// Write write Helm chart files into dir/name directory
+func (obj *HelmChart) Write(dir string) error {
+	files, err := obj.Finish()
+	if err != nil {
+		return err
+	}
+	root := filepath.Join(dir, obj.name)
+	for name, byts := range files {
+		path := filepath.Join(root, name)
+		if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+			return err
+		}
+		if err := ioutil.WriteFile(path, byts, 0644); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// SetBundle set Kubernetes resource objects which will be templates of Helm chart
+func (obj *HelmChart) SetBundle(bundle *Bundle) *HelmChart {
+	obj.bundle = bundle
+	return obj
+}
+
+// SetAppVersion set Helm chart appVersion, it is the version of the app that this contains
+func (obj *HelmChart) SetAppVersion(appVersion string) *HelmChart {
+	obj.appVersion = appVersion
+	return obj
+}
+
+// SetDescription set Helm chart description
+func (obj *HelmChart) SetDescription(desc string) *HelmChart {
+	obj.description = desc
+	return obj
+}
+
+// SetValues set fields which will be parameterised into values.yaml,
+// only Deployment,StatefulSet and DaemonSet fields can be parameterised,
+// namespace is always replaced with {{ .Release.Namespace }}
+func (obj *HelmChart) SetValues(values ...HelmValue) *HelmChart {
+	obj.values = append(obj.values, values...)
+	return obj
+}
+
+func (obj *HelmChart) hasValue(value HelmValue) bool {
+	for _, v := range obj.values {
+		if v == value {
+			return true
+		}
+	}
+	return false
+}
+
+// template translate Kubernetes resource object into Helm template and fill values
+func (obj *HelmChart) template(o runtime.Object, values map[string]interface{}) (string, []byte, error) {
+	jsonbyts, err := json.Marshal(o)
+	if err != nil {
+		return "", nil, err
+	}
+	data := make(map[string]interface{}, 0)
+	if err = json.Unmarshal(jsonbyts, &data); err != nil {
+		return "", nil, err
+	}
+	kind := o.GetObjectKind().GroupVersionKind().Kind
+	metadata, _ := data["metadata"].(map[string]interface{})
+	name, _ := metadata["name"].(string)
+	delete(data, "status")
+	delete(metadata, "creationTimestamp")
+
+	replaces := make(map[string]string, 0)
+	placeholder := func(action string) string {
+		key := fmt.Sprintf("%s%dEND", helmPlaceholder, len(replaces))
+		replaces[key] = action
+		return key
+	}
+	if !clusterScopedKinds[kind] {
+		metadata["namespace"] = placeholder("{{ .Release.Namespace }}")
+	}
+	switch kind {
+	case "Deployment", "StatefulSet", "DaemonSet":
+		obj.workloadValues(data, kind, name, values, placeholder)
+	}
+	byts, err := ToYAML(data)
+	if err != nil {
+		return "", nil, err
+	}
+	// the literal delimiters in object are escaped, so helm renders them as they are
+	tpl := strings.Replace(string(byts), "{{", `{{"{{"}}`, -1)
+	for key, action := range replaces {
+		tpl = strings.Replace(tpl, key, action, 1)
+	}
+	return fmt.Sprintf("templates/%s-%s.yaml", strings.ToLower(kind), name), []byte(tpl), nil
+}
+
+// workloadValues replace workload fields with placeholder and fill values
+func (obj *HelmChart) workloadValues(data map[string]interface{}, kind, name string, values map[string]interface{},
+	placeholder func(string) string) {
+	kindKey := strings.ToLower(kind[:1]) + kind[1:]
+	kindValues, _ := values[kindKey].(map[string]interface{})
+	if kindValues == nil {
+		kindValues = make(map[string]interface{}, 0)
+		values[kindKey] = kindValues
+	}
+	objValues := make(map[string]interface{}, 0)
+	path := func(keys ...string) string {
+		return fmt.Sprintf(`index .Values %q %q %s`, kindKey, name, `"`+strings.Join(keys, `" "`)+`"`)
+	}
+	spec, _ := data["spec"].(map[string]interface{})
+	if obj.hasValue(HelmReplicas) && kind != "DaemonSet" {
+		replicas, ok := spec["replicas"]
+		if !ok {
+			replicas = 1
+		}
+		objValues["replicas"] = replicas
+		spec["replicas"] = placeholder("{{ " + path("replicas") + " }}")
+	}
+	template, _ := spec["template"].(map[string]interface{})
+	podSpec, _ := template["spec"].(map[string]interface{})
+	containers, _ := podSpec["containers"].([]interface{})
+	containerValues := make(map[string]interface{}, 0)
+	for index := range containers {
+		container, _ := containers[index].(map[string]interface{})
+		cname, _ := container["name"].(string)
+		if !verifyString(cname) {
+			cname = fmt.Sprintf("container%d", index)
+		}
+		cvalues := make(map[string]interface{}, 0)
+		if obj.hasValue(HelmImage) {
+			cvalues["image"] = container["image"]
+			container["image"] = placeholder("{{ " + path("containers", cname, "image") + " | quote }}")
+		}
+		if obj.hasValue(HelmResources) {
+			resources, ok := container["resources"]
+			if !ok {
+				resources = map[string]interface{}{}
+			}
+			cvalues["resources"] = resources
+			container["resources"] = placeholder("{{ toJson (" + path("containers", cname, "resources") + ") }}")
+		}
+		if obj.hasValue(HelmEnvs) {
+			envs, ok := container["env"]
+			if !ok {
+				envs = []interface{}{}
+			}
+			cvalues["env"] = envs
+			container["env"] = placeholder("{{ toJson (" + path("containers", cname, "env") + ") }}")
+		}
+		if len(cvalues) > 0 {
+			containerValues[cname] = cvalues
+		}
+	}
+	if len(containerValues) > 0 {
+		objValues["containers"] = containerValues
+	}
+	if len(objValues) > 0 {
+		kindValues[name] = objValues
+	}
+}
+
+func (obj *HelmChart) error(err error) {
+	if obj.err != nil {
+		return
+	}
+	obj.err = err
+}
+
+// verify check HelmChart necessary value, input the default field and input related data.
+func (obj *HelmChart) verify() {
+	if obj.err != nil {
+		return
+	}
+	if !helmChartName.MatchString(obj.name) {
+		obj.err = fmt.Errorf("HelmChart name:%q is not allowed,only lower case letters, numbers and '-' are allowed", obj.name)
+		return
+	}
+	if !helmChartVersion.MatchString(obj.version) {
+		obj.err = fmt.Errorf("HelmChart version:%q is not allowed,it must be SemVer 2,eg: 0.1.0", obj.version)
+		return
+	}
+	if obj.bundle == nil {
+		obj.err = errors.New("HelmChart bundle is not allowed to be empty,you can call SetBundle() input")
+		return
+	}
+	for _, v := range obj.values {
+		switch v {
+		case HelmImage, HelmReplicas, HelmResources, HelmEnvs:
+		default:
+			obj.err = fmt.Errorf("HelmChart value:%s is not supported", v)
+			return
+		}
+	}
+	if !verifyString(obj.description) {
+		obj.description = fmt.Sprintf("A Helm chart for %s", obj.name)
+	}
+	if !verifyString(obj.appVersion) {
+		obj.appVersion = obj.version
+	}
+	sort.Slice(obj.values, func(i, j int) bool { return obj.values[i] < obj.values[j] })
+}
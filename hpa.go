@@ -0,0 +1,499 @@
+package beku
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+
+	"github.com/ghodss/yaml"
+	appsv1 "k8s.io/api/apps/v1"
+	autoscalingv1 "k8s.io/api/autoscaling/v1"
+	"k8s.io/api/autoscaling/v2beta2"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/resource"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes"
+)
+
+// hpaDefaultCPUUtilization the default target of cpu utilization when HPA has no metrics,
+// it is the same as the default of Kubernetes
+const hpaDefaultCPUUtilization int32 = 80
+
+// HPA include Kubernetes resource object HorizontalPodAutoscaler and error
+type HPA struct {
+	hpa *v2beta2.HorizontalPodAutoscaler
+	// podSpec Pod spec of scale target,it is used to check resource requests of containers
+	podSpec *corev1.PodSpec
+	err     error
+}
+
+// NewHPA create HorizontalPodAutoscaler(HPA) and chain function call begin with this function.
+func NewHPA() *HPA { return &HPA{hpa: &v2beta2.HorizontalPodAutoscaler{}} }
+
+// Finish Chain function call end with this function
+// return Kubernetes resource object HorizontalPodAutoscaler and error.
+// In the function, it will check necessary parameters,input the default field
+func (obj *HPA) Finish() (*v2beta2.HorizontalPodAutoscaler, error) {
+	obj.verify()
+	return obj.hpa, obj.err
+}
+
+// JSONNew use json data create HPA
+func (obj *HPA) JSONNew(jsonbyts []byte) *HPA {
+	obj.error(json.Unmarshal(jsonbyts, obj.hpa))
+	return obj
+}
+
+// YAMLNew use yaml data create HPA
+func (obj *HPA) YAMLNew(yamlbyts []byte) *HPA {
+	obj.error(yaml.Unmarshal(yamlbyts, obj.hpa))
+	return obj
+}
+
+// JSONNewTemplate use json template and values create HPA,
+// the template is rendered by RenderTemplate() and must be valid json
+func (obj *HPA) JSONNewTemplate(tpl []byte, values interface{}) *HPA {
+	jsonbyts, err := renderJSONTemplate(tpl, values)
+	if err != nil {
+		obj.error(err)
+		return obj
+	}
+	return obj.JSONNew(jsonbyts)
+}
+
+// YAMLNewTemplate use yaml template and values create HPA,
+// the template is rendered by RenderTemplate() and must be valid yaml
+func (obj *HPA) YAMLNewTemplate(tpl []byte, values interface{}) *HPA {
+	yamlbyts, err := renderYAMLTemplate(tpl, values)
+	if err != nil {
+		obj.error(err)
+		return obj
+	}
+	return obj.YAMLNew(yamlbyts)
+}
+
+// Replace replace HPA by Kubernetes resource object
+func (obj *HPA) Replace(hpa *v2beta2.HorizontalPodAutoscaler) *HPA {
+	if hpa != nil {
+		obj.hpa = hpa
+	}
+	return obj
+}
+
+// SetName set HPA name
+func (obj *HPA) SetName(name string) *HPA {
+	obj.hpa.SetName(name)
+	return obj
+}
+
+// SetNamespace set HPA namespace, it must be the same as the namespace of scale target
+func (obj *HPA) SetNamespace(namespace string) *HPA {
+	obj.hpa.SetNamespace(namespace)
+	return obj
+}
+
+// SetNamespaceAndName set HPA namespace and name
+func (obj *HPA) SetNamespaceAndName(namespace, name string) *HPA {
+	obj.SetNamespace(namespace)
+	obj.SetName(name)
+	return obj
+}
+
+// SetLabels set HPA labels
+func (obj *HPA) SetLabels(labels map[string]string) *HPA {
+	obj.hpa.SetLabels(labels)
+	return obj
+}
+
+// SetAnnotations set HPA annotations
+func (obj *HPA) SetAnnotations(annotations map[string]string) *HPA {
+	if len(obj.hpa.Annotations) <= 0 {
+		obj.hpa.Annotations = annotations
+		return obj
+	}
+	for key, value := range annotations {
+		obj.hpa.Annotations[key] = value
+	}
+	return obj
+}
+
+// SetScaleTarget set the workload which is scaled by HPA,kind only Deployment and StatefulSet,
+// container resource requests is not checked when scale target is set by this function
+func (obj *HPA) SetScaleTarget(kind, name string) *HPA {
+	if kind != "Deployment" && kind != "StatefulSet" {
+		obj.error(fmt.Errorf("SetScaleTarget err,kind %s is not supported,only Deployment and StatefulSet", kind))
+		return obj
+	}
+	if !verifyString(name) {
+		obj.error(errors.New("SetScaleTarget err,name is not allowed to be empty"))
+		return obj
+	}
+	obj.hpa.Spec.ScaleTargetRef = v2beta2.CrossVersionObjectReference{
+		APIVersion: "apps/v1",
+		Kind:       kind,
+		Name:       name,
+	}
+	return obj
+}
+
+// SetDeployment set Deployment as scale target,HPA name and namespace are the same as Deployment when they are empty,
+// container resource requests of Deployment will be checked by Finish()
+func (obj *HPA) SetDeployment(dp *appsv1.Deployment) *HPA {
+	if dp == nil {
+		obj.error(errors.New("SetDeployment err,Deployment is not allowed to be empty"))
+		return obj
+	}
+	obj.setTarget("Deployment", dp.ObjectMeta, &dp.Spec.Template.Spec)
+	return obj
+}
+
+// SetStatefulSet set StatefulSet as scale target,HPA name and namespace are the same as StatefulSet when they are empty,
+// container resource requests of StatefulSet will be checked by Finish()
+func (obj *HPA) SetStatefulSet(sts *appsv1.StatefulSet) *HPA {
+	if sts == nil {
+		obj.error(errors.New("SetStatefulSet err,StatefulSet is not allowed to be empty"))
+		return obj
+	}
+	obj.setTarget("StatefulSet", sts.ObjectMeta, &sts.Spec.Template.Spec)
+	return obj
+}
+
+func (obj *HPA) setTarget(kind string, meta metav1.ObjectMeta, podSpec *corev1.PodSpec) {
+	obj.SetScaleTarget(kind, meta.GetName())
+	if !verifyString(obj.hpa.GetName()) {
+		obj.hpa.SetName(meta.GetName())
+	}
+	if !verifyString(obj.hpa.GetNamespace()) {
+		obj.hpa.SetNamespace(meta.GetNamespace())
+	}
+	obj.podSpec = podSpec
+}
+
+// SetReplicas set the lower and upper limit of replicas which HPA can scale to
+func (obj *HPA) SetReplicas(min, max int32) *HPA {
+	if min < 1 {
+		obj.error(errors.New("SetReplicas err,min must be greater than 0"))
+		return obj
+	}
+	if max < min {
+		obj.error(fmt.Errorf("SetReplicas err,max %d is not allowed to be less than min %d", max, min))
+		return obj
+	}
+	obj.hpa.Spec.MinReplicas = &min
+	obj.hpa.Spec.MaxReplicas = max
+	return obj
+}
+
+// SetCPUUtilization set target of average cpu utilization of all Pods,
+// percent is percentage of cpu requests, so containers must set cpu requests
+func (obj *HPA) SetCPUUtilization(percent int32) *HPA {
+	obj.setResourceUtilization(ResourceCPU, percent)
+	return obj
+}
+
+// SetMemoryUtilization set target of average memory utilization of all Pods,
+// percent is percentage of memory requests, so containers must set memory requests
+func (obj *HPA) SetMemoryUtilization(percent int32) *HPA {
+	obj.setResourceUtilization(ResourceMemory, percent)
+	return obj
+}
+
+func (obj *HPA) setResourceUtilization(name ResourceName, percent int32) {
+	if percent <= 0 {
+		obj.error(fmt.Errorf("set %s utilization err,percent must be greater than 0", name))
+		return
+	}
+	obj.setMetric(v2beta2.MetricSpec{
+		Type: v2beta2.ResourceMetricSourceType,
+		Resource: &v2beta2.ResourceMetricSource{
+			Name: corev1.ResourceName(name),
+			Target: v2beta2.MetricTarget{
+				Type:               v2beta2.UtilizationMetricType,
+				AverageUtilization: &percent,
+			},
+		},
+	})
+}
+
+// SetPodsMetric set target of custom metric which describes each Pod,eg: packets-per-second,
+// averageValue is the target value of the average of the metric across all Pods,eg: 1k
+func (obj *HPA) SetPodsMetric(metricName, averageValue string) *HPA {
+	value, err := parseMetricValue("SetPodsMetric", metricName, averageValue)
+	if err != nil {
+		obj.error(err)
+		return obj
+	}
+	obj.setMetric(v2beta2.MetricSpec{
+		Type: v2beta2.PodsMetricSourceType,
+		Pods: &v2beta2.PodsMetricSource{
+			Metric: v2beta2.MetricIdentifier{Name: metricName},
+			Target: v2beta2.MetricTarget{
+				Type:         v2beta2.AverageValueMetricType,
+				AverageValue: &value,
+			},
+		},
+	})
+	return obj
+}
+
+// SetObjectMetric set target of custom metric which describes a Kubernetes object in the same namespace,
+// eg: requests-per-second of Ingress, apiVersion and kind is the object type,eg: networking.k8s.io/v1beta1,Ingress
+func (obj *HPA) SetObjectMetric(apiVersion, kind, name, metricName, value string) *HPA {
+	if !verifyString(kind) || !verifyString(name) {
+		obj.error(errors.New("SetObjectMetric err,kind and name of object are not allowed to be empty"))
+		return obj
+	}
+	target, err := parseMetricValue("SetObjectMetric", metricName, value)
+	if err != nil {
+		obj.error(err)
+		return obj
+	}
+	obj.setMetric(v2beta2.MetricSpec{
+		Type: v2beta2.ObjectMetricSourceType,
+		Object: &v2beta2.ObjectMetricSource{
+			DescribedObject: v2beta2.CrossVersionObjectReference{APIVersion: apiVersion, Kind: kind, Name: name},
+			Metric:          v2beta2.MetricIdentifier{Name: metricName},
+			Target: v2beta2.MetricTarget{
+				Type:  v2beta2.ValueMetricType,
+				Value: &target,
+			},
+		},
+	})
+	return obj
+}
+
+// SetExternalMetric set target of metric which is not associated with any Kubernetes object,eg: length of queue,
+// selector select the metric series,it is allowed to be empty,
+// averageValue is the target value of the metric divided by the number of Pods
+func (obj *HPA) SetExternalMetric(metricName string, selector map[string]string, averageValue string) *HPA {
+	value, err := parseMetricValue("SetExternalMetric", metricName, averageValue)
+	if err != nil {
+		obj.error(err)
+		return obj
+	}
+	identifier := v2beta2.MetricIdentifier{Name: metricName}
+	if len(selector) > 0 {
+		identifier.Selector = &metav1.LabelSelector{MatchLabels: selector}
+	}
+	obj.setMetric(v2beta2.MetricSpec{
+		Type: v2beta2.ExternalMetricSourceType,
+		External: &v2beta2.ExternalMetricSource{
+			Metric: identifier,
+			Target: v2beta2.MetricTarget{
+				Type:         v2beta2.AverageValueMetricType,
+				AverageValue: &value,
+			},
+		},
+	})
+	return obj
+}
+
+// setMetric add metric,it will replace the metric which has the same type and name
+func (obj *HPA) setMetric(metric v2beta2.MetricSpec) {
+	for index, present := range obj.hpa.Spec.Metrics {
+		if present.Type == metric.Type && metricName(present) == metricName(metric) {
+			obj.hpa.Spec.Metrics[index] = metric
+			return
+		}
+	}
+	obj.hpa.Spec.Metrics = append(obj.hpa.Spec.Metrics, metric)
+}
+
+// metricName return resource name or metric name of metric
+func metricName(metric v2beta2.MetricSpec) string {
+	switch {
+	case metric.Resource != nil:
+		return string(metric.Resource.Name)
+	case metric.Pods != nil:
+		return metric.Pods.Metric.Name
+	case metric.Object != nil:
+		return metric.Object.DescribedObject.Kind + "/" + metric.Object.DescribedObject.Name + "/" + metric.Object.Metric.Name
+	case metric.External != nil:
+		return metric.External.Metric.Name
+	}
+	return ""
+}
+
+func parseMetricValue(funcName, metricName, value string) (resource.Quantity, error) {
+	if !verifyString(metricName) {
+		return resource.Quantity{}, fmt.Errorf("%s err,metric name is not allowed to be empty", funcName)
+	}
+	quantity, err := resource.ParseQuantity(value)
+	if err != nil {
+		return resource.Quantity{}, fmt.Errorf("%s err,value %s of metric %s is invalid:%s", funcName, value, metricName, err.Error())
+	}
+	return quantity, nil
+}
+
+// Release release HPA on Kubernetes,
+// it will be released as autoscaling/v1 when Kubernetes does not support autoscaling/v2beta2,
+// and only cpu utilization metric is supported by autoscaling/v1.
+func (obj *HPA) Release() (*v2beta2.HorizontalPodAutoscaler, error) {
+	hpa, err := obj.Finish()
+	if err != nil {
+		return nil, err
+	}
+	client, err := GetKubeClient()
+	if err != nil {
+		return nil, err
+	}
+	if hpaV2beta2Supported(client) {
+		return client.AutoscalingV2beta2().HorizontalPodAutoscalers(hpa.GetNamespace()).Create(hpa)
+	}
+	v1hpa, err := hpaToV1(hpa)
+	if err != nil {
+		return nil, err
+	}
+	if _, err = client.AutoscalingV1().HorizontalPodAutoscalers(hpa.GetNamespace()).Create(v1hpa); err != nil {
+		return nil, err
+	}
+	return hpa, nil
+}
+
+// Apply it will be updated when this resource object exists in K8s,
+// it will be created when it does not exist.
+func (obj *HPA) Apply() (*v2beta2.HorizontalPodAutoscaler, error) {
+	hpa, err := obj.Finish()
+	if err != nil {
+		return nil, err
+	}
+	client, err := GetKubeClient()
+	if err != nil {
+		return nil, err
+	}
+	if hpaV2beta2Supported(client) {
+		_, err = client.AutoscalingV2beta2().HorizontalPodAutoscalers(hpa.GetNamespace()).Get(hpa.GetName(), metav1.GetOptions{})
+		if err != nil {
+			return client.AutoscalingV2beta2().HorizontalPodAutoscalers(hpa.GetNamespace()).Create(hpa)
+		}
+		return client.AutoscalingV2beta2().HorizontalPodAutoscalers(hpa.GetNamespace()).Update(hpa)
+	}
+	v1hpa, err := hpaToV1(hpa)
+	if err != nil {
+		return nil, err
+	}
+	_, err = client.AutoscalingV1().HorizontalPodAutoscalers(hpa.GetNamespace()).Get(hpa.GetName(), metav1.GetOptions{})
+	if err != nil {
+		_, err = client.AutoscalingV1().HorizontalPodAutoscalers(hpa.GetNamespace()).Create(v1hpa)
+	} else {
+		_, err = client.AutoscalingV1().HorizontalPodAutoscalers(hpa.GetNamespace()).Update(v1hpa)
+	}
+	if err != nil {
+		return nil, err
+	}
+	return hpa, nil
+}
+
+// Delete delete HPA on Kubernetes, the scale target will keep present replicas
+func (obj *HPA) Delete() error {
+	hpa, err := obj.Finish()
+	if err != nil {
+		return err
+	}
+	client, err := GetKubeClient()
+	if err != nil {
+		return err
+	}
+	return client.AutoscalingV1().HorizontalPodAutoscalers(hpa.GetNamespace()).Delete(hpa.GetName(), &metav1.DeleteOptions{})
+}
+
+// hpaV2beta2Supported check whether Kubernetes support autoscaling/v2beta2
+func hpaV2beta2Supported(client *kubernetes.Clientset) bool {
+	_, err := client.Discovery().ServerResourcesForGroupVersion(v2beta2.SchemeGroupVersion.String())
+	return err == nil
+}
+
+// hpaToV1 translate HPA into autoscaling/v1,only cpu utilization metric is supported
+func hpaToV1(hpa *v2beta2.HorizontalPodAutoscaler) (*autoscalingv1.HorizontalPodAutoscaler, error) {
+	v1hpa := &autoscalingv1.HorizontalPodAutoscaler{
+		TypeMeta:   metav1.TypeMeta{Kind: "HorizontalPodAutoscaler", APIVersion: "autoscaling/v1"},
+		ObjectMeta: hpa.ObjectMeta,
+		Spec: autoscalingv1.HorizontalPodAutoscalerSpec{
+			ScaleTargetRef: autoscalingv1.CrossVersionObjectReference{
+				APIVersion: hpa.Spec.ScaleTargetRef.APIVersion,
+				Kind:       hpa.Spec.ScaleTargetRef.Kind,
+				Name:       hpa.Spec.ScaleTargetRef.Name,
+			},
+			MinReplicas: hpa.Spec.MinReplicas,
+			MaxReplicas: hpa.Spec.MaxReplicas,
+		},
+	}
+	for _, metric := range hpa.Spec.Metrics {
+		if metric.Resource == nil || metric.Resource.Name != corev1.ResourceCPU || metric.Resource.Target.AverageUtilization == nil {
+			return nil, fmt.Errorf("HPA %s metric %s is not supported by autoscaling/v1,only cpu utilization is supported", hpa.GetName(), metricName(metric))
+		}
+		v1hpa.Spec.TargetCPUUtilizationPercentage = metric.Resource.Target.AverageUtilization
+	}
+	return v1hpa, nil
+}
+
+// hpaManaged check whether the workload is scaled by HPA on Kubernetes
+func hpaManaged(client *kubernetes.Clientset, namespace, kind, name string) bool {
+	hpas, err := client.AutoscalingV1().HorizontalPodAutoscalers(namespace).List(metav1.ListOptions{})
+	if err != nil {
+		return false
+	}
+	for _, hpa := range hpas.Items {
+		if hpa.Spec.ScaleTargetRef.Kind == kind && hpa.Spec.ScaleTargetRef.Name == name {
+			return true
+		}
+	}
+	return false
+}
+
+func (obj *HPA) error(err error) {
+	if obj.err != nil {
+		return
+	}
+	obj.err = err
+}
+
+// verify check HPA necessary value, input the default field and input related data.
+func (obj *HPA) verify() {
+	if obj.err != nil {
+		return
+	}
+	if !verifyString(obj.hpa.GetName()) {
+		obj.err = errors.New("HPA name is not allowed to be empty")
+		return
+	}
+	if !verifyString(obj.hpa.Spec.ScaleTargetRef.Kind) || !verifyString(obj.hpa.Spec.ScaleTargetRef.Name) {
+		obj.err = errors.New("HPA scale target is not allowed to be empty,you can call SetScaleTarget() or SetDeployment() input")
+		return
+	}
+	if obj.hpa.Spec.MaxReplicas <= 0 {
+		obj.err = errors.New("HPA max replicas is not allowed to be empty,you can call SetReplicas() input")
+		return
+	}
+	if obj.hpa.Spec.MinReplicas == nil {
+		min := int32(1)
+		obj.hpa.Spec.MinReplicas = &min
+	}
+	if *obj.hpa.Spec.MinReplicas > obj.hpa.Spec.MaxReplicas {
+		obj.err = fmt.Errorf("HPA max replicas %d is not allowed to be less than min replicas %d", obj.hpa.Spec.MaxReplicas, *obj.hpa.Spec.MinReplicas)
+		return
+	}
+	if len(obj.hpa.Spec.Metrics) <= 0 {
+		obj.SetCPUUtilization(hpaDefaultCPUUtilization)
+	}
+	if obj.podSpec != nil {
+		for _, metric := range obj.hpa.Spec.Metrics {
+			if metric.Resource == nil || metric.Resource.Target.Type != v2beta2.UtilizationMetricType {
+				continue
+			}
+			for _, container := range obj.podSpec.Containers {
+				// Kubernetes defaults requests to limits, so the container which only sets limits has requests
+				if _, ok := container.Resources.Requests[metric.Resource.Name]; ok {
+					continue
+				}
+				if _, ok := container.Resources.Limits[metric.Resource.Name]; !ok {
+					obj.err = fmt.Errorf("HPA %s utilization target need %s requests,but container %s of %s %s does not set,you can call SetResourceRequst() or SetResourceLimit() input",
+						metric.Resource.Name, metric.Resource.Name, container.Name, obj.hpa.Spec.ScaleTargetRef.Kind, obj.hpa.Spec.ScaleTargetRef.Name)
+					return
+				}
+			}
+		}
+	}
+	obj.hpa.Kind = "HorizontalPodAutoscaler"
+	obj.hpa.APIVersion = "autoscaling/v2beta2"
+}
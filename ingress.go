@@ -0,0 +1,406 @@
+package beku
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/ghodss/yaml"
+	appsv1 "k8s.io/api/apps/v1"
+	"k8s.io/api/core/v1"
+	"k8s.io/api/networking/v1beta1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/util/intstr"
+)
+
+// Ingress annotations of ingress class and common ingress controllers
+const (
+	// IngressClassKey ingress class annotation, it choose the ingress controller
+	IngressClassKey = "kubernetes.io/ingress.class"
+	// NginxRewriteTargetKey nginx ingress controller rewrite target annotation
+	NginxRewriteTargetKey = "nginx.ingress.kubernetes.io/rewrite-target"
+	// NginxSSLRedirectKey nginx ingress controller ssl redirect annotation
+	NginxSSLRedirectKey = "nginx.ingress.kubernetes.io/ssl-redirect"
+	// NginxProxyBodySizeKey nginx ingress controller max request body size annotation
+	NginxProxyBodySizeKey = "nginx.ingress.kubernetes.io/proxy-body-size"
+	// NginxBackendProtocolKey nginx ingress controller backend protocol annotation
+	NginxBackendProtocolKey = "nginx.ingress.kubernetes.io/backend-protocol"
+	// NginxWhitelistSourceRangeKey nginx ingress controller client ip whitelist annotation
+	NginxWhitelistSourceRangeKey = "nginx.ingress.kubernetes.io/whitelist-source-range"
+)
+
+// Ingress include Kubernetes resource object Ingress(ing) and error
+type Ingress struct {
+	ing *v1beta1.Ingress
+	err error
+}
+
+// NewIngress create Ingress(ing) and chain function call begin with this function.
+func NewIngress() *Ingress { return &Ingress{ing: &v1beta1.Ingress{}} }
+
+// Finish Chain function call end with this function
+// return Kubernetes resource object Ingress and error.
+// In the function, it will check necessary parameters,input the default field
+func (obj *Ingress) Finish() (*v1beta1.Ingress, error) {
+	obj.verify()
+	return obj.ing, obj.err
+}
+
+// JSONNew use json data create Ingress
+func (obj *Ingress) JSONNew(jsonbyts []byte) *Ingress {
+	obj.error(json.Unmarshal(jsonbyts, obj.ing))
+	return obj
+}
+
+// YAMLNew use yaml data create Ingress
+func (obj *Ingress) YAMLNew(yamlbyts []byte) *Ingress {
+	obj.error(yaml.Unmarshal(yamlbyts, obj.ing))
+	return obj
+}
+
+// JSONNewTemplate use json template and values create Ingress,
+// the template is rendered by RenderTemplate() and must be valid json
+func (obj *Ingress) JSONNewTemplate(tpl []byte, values interface{}) *Ingress {
+	jsonbyts, err := renderJSONTemplate(tpl, values)
+	if err != nil {
+		obj.error(err)
+		return obj
+	}
+	return obj.JSONNew(jsonbyts)
+}
+
+// YAMLNewTemplate use yaml template and values create Ingress,
+// the template is rendered by RenderTemplate() and must be valid yaml
+func (obj *Ingress) YAMLNewTemplate(tpl []byte, values interface{}) *Ingress {
+	yamlbyts, err := renderYAMLTemplate(tpl, values)
+	if err != nil {
+		obj.error(err)
+		return obj
+	}
+	return obj.YAMLNew(yamlbyts)
+}
+
+// Replace replace ing by Kubernetes resource object
+func (obj *Ingress) Replace(ing *v1beta1.Ingress) *Ingress {
+	if ing != nil {
+		obj.ing = ing
+	}
+	return obj
+}
+
+// SetName set Ingress name
+func (obj *Ingress) SetName(name string) *Ingress {
+	obj.ing.SetName(name)
+	return obj
+}
+
+// SetNamespace set Ingress namespace
+func (obj *Ingress) SetNamespace(namespace string) *Ingress {
+	obj.ing.SetNamespace(namespace)
+	return obj
+}
+
+// SetNamespaceAndName set Ingress namespace and name
+func (obj *Ingress) SetNamespaceAndName(namespace, name string) *Ingress {
+	obj.ing.SetName(name)
+	obj.ing.SetNamespace(namespace)
+	return obj
+}
+
+// SetLabels set Ingress labels
+func (obj *Ingress) SetLabels(labels map[string]string) *Ingress {
+	obj.ing.SetLabels(labels)
+	return obj
+}
+
+// SetAnnotations set Ingress annotations
+func (obj *Ingress) SetAnnotations(annotations map[string]string) *Ingress {
+	obj.ing.SetAnnotations(annotations)
+	return obj
+}
+
+// SetRule set Ingress rule, the request of host and path will be sent to service port,
+// host is allowed to be empty, it means all hosts,
+// path must begin with '/', servicePort can be port number or port name, eg: FromInt(80),FromString("http")
+// the path is appended when the host rule already exists
+func (obj *Ingress) SetRule(host, path, serviceName string, servicePort intstr.IntOrString) *Ingress {
+	httpPath := v1beta1.HTTPIngressPath{
+		Path: path,
+		Backend: v1beta1.IngressBackend{
+			ServiceName: serviceName,
+			ServicePort: servicePort,
+		},
+	}
+	for index := range obj.ing.Spec.Rules {
+		rule := &obj.ing.Spec.Rules[index]
+		if rule.Host != host {
+			continue
+		}
+		if rule.HTTP == nil {
+			rule.HTTP = &v1beta1.HTTPIngressRuleValue{}
+		}
+		rule.HTTP.Paths = append(rule.HTTP.Paths, httpPath)
+		return obj
+	}
+	obj.ing.Spec.Rules = append(obj.ing.Spec.Rules, v1beta1.IngressRule{
+		Host: host,
+		IngressRuleValue: v1beta1.IngressRuleValue{
+			HTTP: &v1beta1.HTTPIngressRuleValue{Paths: []v1beta1.HTTPIngressPath{httpPath}},
+		},
+	})
+	return obj
+}
+
+// SetDefaultBackend set Ingress default backend, the request which does not match any rule will be sent to it
+func (obj *Ingress) SetDefaultBackend(serviceName string, servicePort intstr.IntOrString) *Ingress {
+	obj.ing.Spec.Backend = &v1beta1.IngressBackend{
+		ServiceName: serviceName,
+		ServicePort: servicePort,
+	}
+	return obj
+}
+
+// SetTLS set Ingress TLS, secretName is the Secret of type kubernetes.io/tls which include tls.crt and tls.key,
+// hosts must be included in the rules
+func (obj *Ingress) SetTLS(secretName string, hosts ...string) *Ingress {
+	obj.ing.Spec.TLS = append(obj.ing.Spec.TLS, v1beta1.IngressTLS{
+		Hosts:      hosts,
+		SecretName: secretName,
+	})
+	return obj
+}
+
+// SetIngressClass set ingress class, it choose the ingress controller, eg: nginx,traefik
+func (obj *Ingress) SetIngressClass(class string) *Ingress {
+	return obj.setAnnotation(IngressClassKey, class)
+}
+
+// SetRewriteTarget set nginx ingress controller rewrite target, eg: /$2
+func (obj *Ingress) SetRewriteTarget(target string) *Ingress {
+	return obj.setAnnotation(NginxRewriteTargetKey, target)
+}
+
+// SetSSLRedirect set nginx ingress controller whether redirect http to https when TLS is set, default value is true
+func (obj *Ingress) SetSSLRedirect(redirect bool) *Ingress {
+	return obj.setAnnotation(NginxSSLRedirectKey, fmt.Sprint(redirect))
+}
+
+// SetProxyBodySize set nginx ingress controller max request body size, eg: 8m, 0 means no limit
+func (obj *Ingress) SetProxyBodySize(size string) *Ingress {
+	return obj.setAnnotation(NginxProxyBodySizeKey, size)
+}
+
+// SetBackendProtocol set nginx ingress controller backend protocol: HTTP,HTTPS,GRPC,GRPCS,AJP,FCGI
+func (obj *Ingress) SetBackendProtocol(protocol string) *Ingress {
+	switch protocol {
+	case "HTTP", "HTTPS", "GRPC", "GRPCS", "AJP", "FCGI":
+	default:
+		obj.error(fmt.Errorf("Ingress backend protocol %s is not supported", protocol))
+		return obj
+	}
+	return obj.setAnnotation(NginxBackendProtocolKey, protocol)
+}
+
+// SetWhitelistSourceRange set nginx ingress controller client ip whitelist, eg: 10.0.0.0/24
+func (obj *Ingress) SetWhitelistSourceRange(cidrs ...string) *Ingress {
+	return obj.setAnnotation(NginxWhitelistSourceRangeKey, strings.Join(cidrs, ","))
+}
+
+func (obj *Ingress) setAnnotation(key, value string) *Ingress {
+	if obj.ing.Annotations == nil {
+		obj.ing.Annotations = make(map[string]string, 0)
+	}
+	obj.ing.Annotations[key] = value
+	return obj
+}
+
+// Release release Ingress on Kubernetes
+func (obj *Ingress) Release() (*v1beta1.Ingress, error) {
+	ing, err := obj.Finish()
+	if err != nil {
+		return nil, err
+	}
+	client, err := GetKubeClient()
+	if err != nil {
+		return nil, err
+	}
+	return client.NetworkingV1beta1().Ingresses(ing.GetNamespace()).Create(ing)
+}
+
+// Apply it will be updated when this resource object exists in K8s,
+// it will be created when it does not exist.
+func (obj *Ingress) Apply() (*v1beta1.Ingress, error) {
+	ing, err := obj.Finish()
+	if err != nil {
+		return nil, err
+	}
+	client, err := GetKubeClient()
+	if err != nil {
+		return nil, err
+	}
+	_, err = client.NetworkingV1beta1().Ingresses(ing.GetNamespace()).Get(ing.GetName(), metav1.GetOptions{})
+	if err != nil {
+		return client.NetworkingV1beta1().Ingresses(ing.GetNamespace()).Create(ing)
+	}
+	return client.NetworkingV1beta1().Ingresses(ing.GetNamespace()).Update(ing)
+}
+
+// Delete delete Ingress on Kubernetes
+func (obj *Ingress) Delete() error {
+	ing, err := obj.Finish()
+	if err != nil {
+		return err
+	}
+	client, err := GetKubeClient()
+	if err != nil {
+		return err
+	}
+	return client.NetworkingV1beta1().Ingresses(ing.GetNamespace()).Delete(ing.GetName(), &metav1.DeleteOptions{})
+}
+
+func (obj *Ingress) error(err error) {
+	if obj.err != nil {
+		return
+	}
+	obj.err = err
+}
+
+// verify check Ingress necessary value, input the default field and input related data.
+func (obj *Ingress) verify() {
+	if obj.err != nil {
+		return
+	}
+	if !verifyString(obj.ing.GetName()) {
+		obj.err = errors.New("Ingress name is not allowed to be empty")
+		return
+	}
+	if len(obj.ing.Spec.Rules) <= 0 && obj.ing.Spec.Backend == nil {
+		obj.err = errors.New("Ingress rules and default backend are not allowed to be empty at the same time,you can call SetRule() or SetDefaultBackend() input")
+		return
+	}
+	if obj.ing.Spec.Backend != nil {
+		if err := verifyIngressBackend(obj.ing.Spec.Backend); err != nil {
+			obj.err = fmt.Errorf("Ingress default backend err:%v", err)
+			return
+		}
+	}
+	hosts := make(map[string]bool, 0)
+	for _, rule := range obj.ing.Spec.Rules {
+		hosts[rule.Host] = true
+		if rule.HTTP == nil || len(rule.HTTP.Paths) <= 0 {
+			obj.err = fmt.Errorf("Ingress rule host:%q paths are not allowed to be empty", rule.Host)
+			return
+		}
+		paths := make(map[string]bool, 0)
+		for _, path := range rule.HTTP.Paths {
+			if verifyString(path.Path) && !strings.HasPrefix(path.Path, "/") {
+				obj.err = fmt.Errorf("Ingress rule host:%q path:%q must begin with '/'", rule.Host, path.Path)
+				return
+			}
+			if paths[path.Path] {
+				obj.err = fmt.Errorf("Ingress rule host:%q path:%q is repeated", rule.Host, path.Path)
+				return
+			}
+			paths[path.Path] = true
+			if err := verifyIngressBackend(&path.Backend); err != nil {
+				obj.err = fmt.Errorf("Ingress rule host:%q path:%q err:%v", rule.Host, path.Path, err)
+				return
+			}
+		}
+	}
+	for _, tls := range obj.ing.Spec.TLS {
+		if !verifyString(tls.SecretName) {
+			obj.err = errors.New("Ingress TLS secretName is not allowed to be empty")
+			return
+		}
+		for _, host := range tls.Hosts {
+			if !hosts[host] {
+				obj.err = fmt.Errorf("Ingress TLS host:%q is not found in rules", host)
+				return
+			}
+		}
+	}
+	obj.ing.Kind = "Ingress"
+	obj.ing.APIVersion = "networking.k8s.io/v1beta1"
+}
+
+func verifyIngressBackend(backend *v1beta1.IngressBackend) error {
+	if !verifyString(backend.ServiceName) {
+		return errors.New("serviceName is not allowed to be empty")
+	}
+	if backend.ServicePort.Type == intstr.Int && backend.ServicePort.IntVal <= 0 {
+		return errors.New("servicePort must be greater than 0")
+	}
+	if backend.ServicePort.Type == intstr.String && !verifyString(backend.ServicePort.StrVal) {
+		return errors.New("servicePort name is not allowed to be empty")
+	}
+	return nil
+}
+
+// ServiceToIngress use the Service to generate the associated Ingress,
+// the request of host and path will be sent to the port of Service, it is the only port of Service by default,
+// servicePort[0] can choose the port by port number or port name, it must exist in Service ports.
+func ServiceToIngress(svc *v1.Service, host, path string, servicePort ...intstr.IntOrString) (*v1beta1.Ingress, error) {
+	return serviceToIngress(svc, host, path, servicePort...).Finish()
+}
+
+// serviceToIngress return Ingress builder of ServiceToIngress()
+func serviceToIngress(svc *v1.Service, host, path string, servicePort ...intstr.IntOrString) *Ingress {
+	ing := NewIngress()
+	if svc == nil {
+		ing.error(errors.New("ServiceToIngress err,Service is not allowed to be empty"))
+		return ing
+	}
+	var choose *intstr.IntOrString
+	if len(servicePort) > 0 {
+		choose = &servicePort[0]
+	}
+	port, err := findServicePort(svc, choose)
+	if err != nil {
+		ing.error(fmt.Errorf("ServiceToIngress err,%v", err))
+		return ing
+	}
+	return ing.SetNamespaceAndName(svc.GetNamespace(), svc.GetName()).SetRule(host, path, svc.GetName(), port)
+}
+
+// DeploymentToIngress use the Deployment to generate the associated Service and Ingress,
+// the Service is generated by DeploymentToSvc(), and Ingress send the request of host and path to the first Service port.
+// autoRelease[0] if true,beku will auto Release Service and Ingress On Kubernetes,default can't Release them On Kubernetes
+func DeploymentToIngress(dp *appsv1.Deployment, host, path string, autoRelease ...bool) (*v1.Service, *v1beta1.Ingress, error) {
+	svc, err := DeploymentToSvc(dp, ServiceTypeClusterIP, autoRelease...)
+	if err != nil {
+		return nil, nil, err
+	}
+	if len(svc.Spec.Ports) <= 0 {
+		return nil, nil, errors.New("DeploymentToIngress err,Deployment has no port")
+	}
+	builder := serviceToIngress(svc, host, path, FromInt(int(svc.Spec.Ports[0].Port)))
+	var ing *v1beta1.Ingress
+	if len(autoRelease) > 0 && autoRelease[0] == true {
+		ing, err = builder.Release()
+	} else {
+		ing, err = builder.Finish()
+	}
+	if err != nil {
+		return nil, nil, err
+	}
+	return svc, ing, nil
+}
+
+// findServicePort find port in Service ports by port number or port name
+func findServicePort(svc *v1.Service, servicePort *intstr.IntOrString) (intstr.IntOrString, error) {
+	if servicePort == nil {
+		if len(svc.Spec.Ports) != 1 {
+			return intstr.IntOrString{}, fmt.Errorf("Service %s has %d ports,servicePort is not allowed to be empty", svc.GetName(), len(svc.Spec.Ports))
+		}
+		return FromInt(int(svc.Spec.Ports[0].Port)), nil
+	}
+	for _, port := range svc.Spec.Ports {
+		if (servicePort.Type == intstr.Int && port.Port == servicePort.IntVal) ||
+			(servicePort.Type == intstr.String && verifyString(port.Name) && port.Name == servicePort.StrVal) {
+			return *servicePort, nil
+		}
+	}
+	return intstr.IntOrString{}, fmt.Errorf("Service %s port %s is not found", svc.GetName(), servicePort.String())
+}
@@ -0,0 +1,571 @@
+package beku
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/ghodss/yaml"
+	batchv1 "k8s.io/api/batch/v1"
+	"k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/util/wait"
+)
+
+// jobPollInterval interval of checking Job status in RunAndWait()
+var jobPollInterval = 2 * time.Second
+
+// Job include Kubernetes resource object Job and error
+type Job struct {
+	job *batchv1.Job
+	err error
+}
+
+// JobResult the completion status of Job and logs of the final Pod
+type JobResult struct {
+	// Succeeded is true when Job completed successfully
+	Succeeded bool
+	// Active,SucceededPods,FailedPods are the number of pods of Job
+	Active        int32
+	SucceededPods int32
+	FailedPods    int32
+	// Reason and Message of Job failed condition, eg: BackoffLimitExceeded,DeadlineExceeded
+	Reason  string
+	Message string
+	// PodName the final Pod of Job
+	PodName string
+	// Logs the logs of the final Pod, logs of every container begin with '==> container <==' when Pod has multiple containers
+	Logs string
+}
+
+// NewJob create Job and chain function call begin with this function.
+func NewJob() *Job { return &Job{job: &batchv1.Job{}} }
+
+// Finish Chain function call end with this function
+// return Kubernetes resource object Job and error.
+// In the function, it will check necessary parameters,input the default field
+func (obj *Job) Finish() (*batchv1.Job, error) {
+	obj.verify()
+	return obj.job, obj.err
+}
+
+// JSONNew use json data create Job
+func (obj *Job) JSONNew(jsonbyts []byte) *Job {
+	obj.error(json.Unmarshal(jsonbyts, obj.job))
+	return obj
+}
+
+// YAMLNew use yaml data create Job
+func (obj *Job) YAMLNew(yamlbyts []byte) *Job {
+	obj.error(yaml.Unmarshal(yamlbyts, obj.job))
+	return obj
+}
+
+// JSONNewTemplate use json template and values create Job,
+// the template is rendered by RenderTemplate() and must be valid json
+func (obj *Job) JSONNewTemplate(tpl []byte, values interface{}) *Job {
+	jsonbyts, err := renderJSONTemplate(tpl, values)
+	if err != nil {
+		obj.error(err)
+		return obj
+	}
+	return obj.JSONNew(jsonbyts)
+}
+
+// YAMLNewTemplate use yaml template and values create Job,
+// the template is rendered by RenderTemplate() and must be valid yaml
+func (obj *Job) YAMLNewTemplate(tpl []byte, values interface{}) *Job {
+	yamlbyts, err := renderYAMLTemplate(tpl, values)
+	if err != nil {
+		obj.error(err)
+		return obj
+	}
+	return obj.YAMLNew(yamlbyts)
+}
+
+// Replace replace job by Kubernetes resource object
+func (obj *Job) Replace(job *batchv1.Job) *Job {
+	if job != nil {
+		obj.job = job
+	}
+	return obj
+}
+
+// SetName set Job name
+func (obj *Job) SetName(name string) *Job {
+	obj.job.SetName(name)
+	return obj
+}
+
+// SetNamespace set Job namespace and set Pod namespace.
+func (obj *Job) SetNamespace(namespace string) *Job {
+	obj.job.SetNamespace(namespace)
+	obj.job.Spec.Template.SetNamespace(namespace)
+	return obj
+}
+
+// SetNamespaceAndName set Job namespace,set Pod namespace,set Job name.
+func (obj *Job) SetNamespaceAndName(namespace, name string) *Job {
+	obj.SetNamespace(namespace)
+	obj.SetName(name)
+	return obj
+}
+
+// SetLabels set Job labels
+func (obj *Job) SetLabels(labels map[string]string) *Job {
+	obj.job.SetLabels(labels)
+	return obj
+}
+
+// SetPodLabels set Pod labels of Job,
+// Job selector is generated by Kubernetes, so you don't need to set selector
+func (obj *Job) SetPodLabels(labels map[string]string) *Job {
+	obj.job.Spec.Template.SetLabels(labels)
+	return obj
+}
+
+// SetAnnotations set Job annotations
+func (obj *Job) SetAnnotations(annotations map[string]string) *Job {
+	if len(obj.job.Annotations) <= 0 {
+		obj.job.Annotations = annotations
+		return obj
+	}
+	for key, value := range annotations {
+		obj.job.Annotations[key] = value
+	}
+	return obj
+}
+
+// SetCompletions set the number of Pods which should successfully complete, default 1
+func (obj *Job) SetCompletions(completions int32) *Job {
+	if completions <= 0 {
+		obj.error(errors.New("SetCompletions err,completions must be greater than 0"))
+		return obj
+	}
+	obj.job.Spec.Completions = &completions
+	return obj
+}
+
+// SetParallelism set the max number of Pods which run at the same time, default 1
+func (obj *Job) SetParallelism(parallelism int32) *Job {
+	if parallelism <= 0 {
+		obj.error(errors.New("SetParallelism err,parallelism must be greater than 0"))
+		return obj
+	}
+	obj.job.Spec.Parallelism = &parallelism
+	return obj
+}
+
+// SetBackoffLimit set the number of retries before marking Job failed, default 6
+func (obj *Job) SetBackoffLimit(limit int32) *Job {
+	if limit < 0 {
+		obj.error(errors.New("SetBackoffLimit err,limit is not allowed to be less than 0"))
+		return obj
+	}
+	obj.job.Spec.BackoffLimit = &limit
+	return obj
+}
+
+// SetActiveDeadlineSeconds set the max running seconds of Job, Job will be failed with DeadlineExceeded when it is exceeded
+func (obj *Job) SetActiveDeadlineSeconds(sec int64) *Job {
+	if sec <= 0 {
+		obj.error(errors.New("SetActiveDeadlineSeconds err,sec must be greater than 0"))
+		return obj
+	}
+	obj.job.Spec.ActiveDeadlineSeconds = &sec
+	return obj
+}
+
+// SetTTLSecondsAfterFinished set seconds after Job finished, Job will be deleted automatically,
+// it need TTLAfterFinished feature gate is enabled on Kubernetes
+func (obj *Job) SetTTLSecondsAfterFinished(sec int32) *Job {
+	if sec < 0 {
+		obj.error(errors.New("SetTTLSecondsAfterFinished err,sec is not allowed to be less than 0"))
+		return obj
+	}
+	obj.job.Spec.TTLSecondsAfterFinished = &sec
+	return obj
+}
+
+// SetRestartPolicy set Pod restart policy, only OnFailure and Never are allowed, default Never
+func (obj *Job) SetRestartPolicy(policy RestartPolicy) *Job {
+	obj.job.Spec.Template.Spec.RestartPolicy = policy.ToK8s()
+	if obj.job.Spec.Template.Spec.RestartPolicy == "" {
+		obj.error(fmt.Errorf("SetRestartPolicy err,restart policy %s is not supported", policy))
+	}
+	return obj
+}
+
+// SetContainer set Job container
+// name:name is container name ,default ""
+// image:image is image name ,must input image
+// containerPort: image expose containerPort, it is allowed to be empty
+func (obj *Job) SetContainer(name, image string, containerPort ...int32) *Job {
+	if len(containerPort) > 0 {
+		obj.error(setContainer(&obj.job.Spec.Template, name, image, containerPort[0]))
+		return obj
+	}
+	obj.error(addContainer(&obj.job.Spec.Template, name, image, nil))
+	return obj
+}
+
+// SetCommand set command and args of the last container set by SetContainer()
+func (obj *Job) SetCommand(command []string, args ...string) *Job {
+	obj.error(setCommand(&obj.job.Spec.Template, command, args...))
+	return obj
+}
+
+// SetEnvs set Pod Environmental variable
+func (obj *Job) SetEnvs(envMap map[string]string) *Job {
+	obj.error(setEnvs(&obj.job.Spec.Template, envMap))
+	return obj
+}
+
+// SetResourceLimit set container of Job resource limit,eg:CPU and MEMORY
+func (obj *Job) SetResourceLimit(limits map[ResourceName]string) *Job {
+	obj.error(setResourceLimit(&obj.job.Spec.Template, limits))
+	return obj
+}
+
+// SetResourceRequst set container of Job resource request,only CPU and MEMORY
+func (obj *Job) SetResourceRequst(requests map[ResourceName]string) *Job {
+	obj.error(setResourceRequests(&obj.job.Spec.Template, requests))
+	return obj
+}
+
+// SetPodQos set pod quality of service
+// qosClass: is quality of service,the value only 'Guaranteed','Burstable' and 'BestEffort'
+// autoSet: If your previous settings do not meet the requirements of PodQoS, we will automatically set
+func (obj *Job) SetPodQos(qosClass string, autoSet ...bool) *Job {
+	obj.SetAnnotations(setQosMap(obj.job.Annotations, qosClass, autoSet...))
+	return obj
+}
+
+// SetPVClaim set Job PersistentVolumeClaimVolumeSource
+// params:
+// volumeName: this is Custom field,you can define VolumeSource name,will be used of the container MountPath,
+// claimName: this is PersistentVolumeClaim(PVC) name,the PVC and Job must on same namespace and exist.
+func (obj *Job) SetPVClaim(volumeName, claimName string) *Job {
+	obj.error(setPVClaim(&obj.job.Spec.Template, volumeName, claimName))
+	return obj
+}
+
+// SetPVCMounts mount PersistentVolumeClaim on the first container
+// volumeName:the param is SetPVClaim() function volumeName
+// mountPath: runtime container dir eg:/var/lib/mysql
+func (obj *Job) SetPVCMounts(volumeName, mountPath string) *Job {
+	obj.error(setPVCMounts(&obj.job.Spec.Template, volumeName, mountPath))
+	return obj
+}
+
+// SetConfigMapVolume declare ConfigMap volume on Job Pod, volumeName is used by SetVolumeMount(),
+// cm.Items is the keys which are projected, all keys are projected when it is empty
+func (obj *Job) SetConfigMapVolume(volumeName string, cm *ConfigMapVolumeSource) *Job {
+	obj.error(setConfigMapVolume(&obj.job.Spec.Template, volumeName, cm))
+	return obj
+}
+
+// SetSecretVolume declare Secret volume on Job Pod, volumeName is used by SetVolumeMount(),
+// secret.Items is the keys which are projected, all keys are projected when it is empty
+func (obj *Job) SetSecretVolume(volumeName string, secret *SecretVolumeSource) *Job {
+	obj.error(setSecretVolume(&obj.job.Spec.Template, volumeName, secret))
+	return obj
+}
+
+// SetEmptyDirVolume declare EmptyDir volume on Job Pod, it is deleted when Pod is deleted,
+// memory: use tmpfs, sizeLimit[0] is the max size of volume, eg: 1Gi
+func (obj *Job) SetEmptyDirVolume(volumeName string, memory bool, sizeLimit ...string) *Job {
+	obj.error(setEmptyDirVolume(&obj.job.Spec.Template, volumeName, memory, sizeLimit...))
+	return obj
+}
+
+// SetHostPathVolume declare HostPath volume on Job Pod, path is the absolute path on node
+func (obj *Job) SetHostPathVolume(volumeName, path string, hostPathType ...HostPathType) *Job {
+	obj.error(setHostPathVolume(&obj.job.Spec.Template, volumeName, path, hostPathType...))
+	return obj
+}
+
+// SetDownwardAPIVolume declare DownwardAPI volume on Job Pod, Pod fields and container resources are exposed as files
+func (obj *Job) SetDownwardAPIVolume(volumeName string, files []DownwardAPIVolumeFile, defaultMode ...int32) *Job {
+	obj.error(setDownwardAPIVolume(&obj.job.Spec.Template, volumeName, files, defaultMode...))
+	return obj
+}
+
+// SetProjectedVolume declare projected volume on Job Pod, it projects ConfigMap, Secret,
+// DownwardAPI and ServiceAccount token into the same directory
+func (obj *Job) SetProjectedVolume(volumeName string, sources []VolumeProjection, defaultMode ...int32) *Job {
+	obj.error(setProjectedVolume(&obj.job.Spec.Template, volumeName, sources, defaultMode...))
+	return obj
+}
+
+// SetVolumeMount mount volume on Job container, mount.Container is the container name, default is the first container,
+// the volume must be declared by SetPVClaim(), SetConfigMapVolume() and the other volume functions
+func (obj *Job) SetVolumeMount(mount VolumeMount) *Job {
+	obj.error(setVolumeMount(&obj.job.Spec.Template, mount))
+	return obj
+}
+
+// SetConfigMapFiles mount every key of ConfigMap as a read-only file in mountPath of Job container,
+// container[0] is the container name, default is the first container.
+// eg: NewCM().SetName("nginx").FromDir("conf") and SetConfigMapFiles(cm, "/etc/nginx/conf.d")
+func (obj *Job) SetConfigMapFiles(cm *ConfigMap, mountPath string, container ...string) *Job {
+	obj.error(setConfigMapFiles(&obj.job.Spec.Template, cm, mountPath, container...))
+	return obj
+}
+
+// SetImagePullSecrets set pod pull secret
+func (obj *Job) SetImagePullSecrets(secretName string) *Job {
+	setImagePullSecrets(&obj.job.Spec.Template, secretName)
+	return obj
+}
+
+// SetServiceAccount set Job Pod ServiceAccount
+// automount[0] set whether the ServiceAccount token should be automatically mounted,default use ServiceAccount setting.
+func (obj *Job) SetServiceAccount(serviceAccountName string, automount ...bool) *Job {
+	obj.error(setServiceAccount(&obj.job.Spec.Template, serviceAccountName, automount...))
+	return obj
+}
+
+// SetPodPriorityClass set Job Pod Priority
+// priorityClassName is Kubernetes resource object PriorityClass name
+func (obj *Job) SetPodPriorityClass(priorityClassName string) *Job {
+	obj.error(setPodPriorityClass(&obj.job.Spec.Template, priorityClassName))
+	return obj
+}
+
+// ImagePullPolicy Job pull image policy:Always,Never,IfNotPresent
+func (obj *Job) ImagePullPolicy(pullPolicy PullPolicy) *Job {
+	if len(obj.job.Annotations) <= 0 {
+		obj.job.Annotations = make(map[string]string, 0)
+	}
+	obj.job.Annotations[ImagePullPolicyKey] = string(pullPolicy)
+	return obj
+}
+
+// Release release Job on Kubernetes
+func (obj *Job) Release() (*batchv1.Job, error) {
+	job, err := obj.Finish()
+	if err != nil {
+		return nil, err
+	}
+	client, err := GetKubeClient()
+	if err != nil {
+		return nil, err
+	}
+	if err = priorityClassExists(client, job.Spec.Template.Spec.PriorityClassName); err != nil {
+		return nil, err
+	}
+	return client.BatchV1().Jobs(job.GetNamespace()).Create(job)
+}
+
+// Apply it will be updated when this resource object exists in K8s,
+// it will be created when it does not exist.
+// notice: Pod template of Job is immutable on Kubernetes
+func (obj *Job) Apply() (*batchv1.Job, error) {
+	job, err := obj.Finish()
+	if err != nil {
+		return nil, err
+	}
+	client, err := GetKubeClient()
+	if err != nil {
+		return nil, err
+	}
+	if err = priorityClassExists(client, job.Spec.Template.Spec.PriorityClassName); err != nil {
+		return nil, err
+	}
+	_, err = client.BatchV1().Jobs(job.GetNamespace()).Get(job.GetName(), metav1.GetOptions{})
+	if err != nil {
+		return client.BatchV1().Jobs(job.GetNamespace()).Create(job)
+	}
+	return client.BatchV1().Jobs(job.GetNamespace()).Update(job)
+}
+
+// Delete delete Job and its Pods on Kubernetes
+func (obj *Job) Delete() error {
+	job, err := obj.Finish()
+	if err != nil {
+		return err
+	}
+	client, err := GetKubeClient()
+	if err != nil {
+		return err
+	}
+	propagation := metav1.DeletePropagationBackground
+	return client.BatchV1().Jobs(job.GetNamespace()).Delete(job.GetName(), &metav1.DeleteOptions{PropagationPolicy: &propagation})
+}
+
+// RunAndWait release Job on Kubernetes and wait until it completed, failed or timeout,
+// return the completion status and the logs of the final Pod.
+// JobResult is returned with error when Job failed or timeout, so you can see the logs.
+func (obj *Job) RunAndWait(timeout time.Duration) (*JobResult, error) {
+	job, err := obj.Release()
+	if err != nil {
+		return nil, err
+	}
+	client, err := GetKubeClient()
+	if err != nil {
+		return nil, err
+	}
+	jobs := client.BatchV1().Jobs(job.GetNamespace())
+	var pollErr error
+	waitErr := wait.PollImmediate(jobPollInterval, timeout, func() (bool, error) {
+		current, err := jobs.Get(job.GetName(), metav1.GetOptions{})
+		if apierrors.IsNotFound(err) {
+			return false, fmt.Errorf("Job %s is deleted while waiting", job.GetName())
+		}
+		// other errors may be transient, the Job is read again in next poll
+		if pollErr = err; err != nil {
+			return false, nil
+		}
+		job = current
+		_, finished := jobFinished(job)
+		return finished, nil
+	})
+	result := &JobResult{
+		Active:        job.Status.Active,
+		SucceededPods: job.Status.Succeeded,
+		FailedPods:    job.Status.Failed,
+	}
+	if cond, finished := jobFinished(job); finished {
+		result.Succeeded = cond.Type == batchv1.JobComplete
+		result.Reason, result.Message = cond.Reason, cond.Message
+	}
+	if err = jobLogs(job, result); err != nil && waitErr == nil {
+		waitErr = err
+	}
+	if waitErr == wait.ErrWaitTimeout {
+		if pollErr != nil {
+			return result, fmt.Errorf("Job %s is not finished in %v,last error:%v", job.GetName(), timeout, pollErr)
+		}
+		return result, fmt.Errorf("Job %s is not finished in %v", job.GetName(), timeout)
+	}
+	if waitErr != nil {
+		return result, waitErr
+	}
+	if !result.Succeeded {
+		return result, fmt.Errorf("Job %s failed,reason:%s,message:%s", job.GetName(), result.Reason, result.Message)
+	}
+	return result, nil
+}
+
+// jobFinished return the Complete or Failed condition of Job
+func jobFinished(job *batchv1.Job) (batchv1.JobCondition, bool) {
+	for _, cond := range job.Status.Conditions {
+		if (cond.Type == batchv1.JobComplete || cond.Type == batchv1.JobFailed) && cond.Status == v1.ConditionTrue {
+			return cond, true
+		}
+	}
+	return batchv1.JobCondition{}, false
+}
+
+// jobLogs find the final Pod of Job and read its logs
+func jobLogs(job *batchv1.Job, result *JobResult) error {
+	client, err := GetKubeClient()
+	if err != nil {
+		return err
+	}
+	pods, err := client.CoreV1().Pods(job.GetNamespace()).List(metav1.ListOptions{
+		LabelSelector: "job-name=" + job.GetName(),
+	})
+	if err != nil {
+		return err
+	}
+	if len(pods.Items) <= 0 {
+		return nil
+	}
+	sort.Slice(pods.Items, func(i, j int) bool {
+		return pods.Items[i].CreationTimestamp.Before(&pods.Items[j].CreationTimestamp)
+	})
+	pod := pods.Items[len(pods.Items)-1]
+	result.PodName = pod.GetName()
+	var logs []string
+	for _, container := range pod.Spec.Containers {
+		byts, err := client.CoreV1().Pods(pod.GetNamespace()).GetLogs(pod.GetName(), &v1.PodLogOptions{Container: container.Name}).DoRaw()
+		if err != nil {
+			return fmt.Errorf("get Pod %s container %s logs err:%v", pod.GetName(), container.Name, err)
+		}
+		if len(pod.Spec.Containers) > 1 {
+			logs = append(logs, fmt.Sprintf("==> %s <==", container.Name))
+		}
+		logs = append(logs, string(byts))
+	}
+	result.Logs = strings.Join(logs, "\n")
+	return nil
+}
+
+func (obj *Job) error(err error) {
+	if obj.err != nil {
+		return
+	}
+	obj.err = err
+}
+
+// verify check Job necessary value, input the default field and input related data.
+func (obj *Job) verify() {
+	if obj.err != nil {
+		return
+	}
+	if !verifyString(obj.job.GetName()) {
+		obj.err = errors.New("Job name is not allowed to be empty")
+		return
+	}
+	if err := verifyJobTemplate("Job", &obj.job.Spec.Template, obj.job.Annotations); err != nil {
+		obj.err = err
+		return
+	}
+	obj.job.Kind = "Job"
+	obj.job.APIVersion = "batch/v1"
+}
+
+// verifyJobTemplate check Pod template of Job and CronJob,input default restart policy and image pull policy,
+// kind is used in error message, annotations is the annotations of Job or CronJob which keep qos and pull policy
+func verifyJobTemplate(kind string, podTemp *v1.PodTemplateSpec, annotations map[string]string) error {
+	if len(podTemp.Spec.Containers) <= 0 {
+		return fmt.Errorf("%s Pod template containers is not allowed to be empty,you can call SetContainer() input", kind)
+	}
+	if err := containerRepeated(podTemp.Spec.Containers); err != nil {
+		return fmt.Errorf("%s Pod template containers err:%s", kind, err.Error())
+	}
+	if err := verifyVolumeMounts(podTemp.Spec); err != nil {
+		return fmt.Errorf("%s Pod template volumes err:%s", kind, err.Error())
+	}
+	for index, container := range podTemp.Spec.Containers {
+		if !verifyString(container.Image) {
+			return fmt.Errorf("%s Pod template containers[%d].Image is not allowed to be empty", kind, index)
+		}
+	}
+	switch podTemp.Spec.RestartPolicy {
+	case "":
+		podTemp.Spec.RestartPolicy = v1.RestartPolicyNever
+	case v1.RestartPolicyNever, v1.RestartPolicyOnFailure:
+	default:
+		return fmt.Errorf("%s restart policy %s is not allowed,only OnFailure and Never are allowed", kind, podTemp.Spec.RestartPolicy)
+	}
+	//check qos set,if err!=nil, check need auto set qos
+	presentQos, err := qosCheck(annotations[qosKey], podTemp.Spec)
+	if err != nil {
+		if annotations[autoQosKey] != "true" {
+			return err
+		}
+		if err = autoSetQos(annotations[qosKey], presentQos, &podTemp.Spec); err != nil {
+			return err
+		}
+	}
+	if annotations[ImagePullPolicyKey] != "" {
+		policy := PullPolicy(annotations[ImagePullPolicyKey]).ToK8s()
+		for index := range podTemp.Spec.Containers {
+			podTemp.Spec.Containers[index].ImagePullPolicy = policy
+		}
+		delete(annotations, ImagePullPolicyKey)
+	}
+	for index := range podTemp.Spec.Containers {
+		if podTemp.Spec.Containers[index].ImagePullPolicy == "" {
+			podTemp.Spec.Containers[index].ImagePullPolicy = v1.PullIfNotPresent
+		}
+	}
+	return nil
+}
@@ -0,0 +1,973 @@
+package beku
+
+import (
+	"bufio"
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	jsonpatch "github.com/evanphx/json-patch"
+	"github.com/ghodss/yaml"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/apimachinery/pkg/util/strategicpatch"
+	"k8s.io/client-go/kubernetes/scheme"
+)
+
+// kustomizationFiles file names which kustomize recognize as kustomization
+var kustomizationFiles = []string{"kustomization.yaml", "kustomization.yml", "Kustomization"}
+
+// nameSuffixHashField the internal field of generated ConfigMap and Secret which need name suffix hash,
+// it is removed by hashGeneratedNames() before the objects are output
+const nameSuffixHashField = "$nameSuffixHash"
+
+// Kustomization is the kustomization.yaml fields which beku support
+type Kustomization struct {
+	APIVersion            string                    `json:"apiVersion,omitempty"`
+	Kind                  string                    `json:"kind,omitempty"`
+	Resources             []string                  `json:"resources,omitempty"`
+	Bases                 []string                  `json:"bases,omitempty"`
+	NamePrefix            string                    `json:"namePrefix,omitempty"`
+	NameSuffix            string                    `json:"nameSuffix,omitempty"`
+	Namespace             string                    `json:"namespace,omitempty"`
+	CommonLabels          map[string]string         `json:"commonLabels,omitempty"`
+	CommonAnnotations     map[string]string         `json:"commonAnnotations,omitempty"`
+	Images                []KustomizeImage          `json:"images,omitempty"`
+	ConfigMapGenerator    []KustomizeGenerator      `json:"configMapGenerator,omitempty"`
+	SecretGenerator       []KustomizeGenerator      `json:"secretGenerator,omitempty"`
+	GeneratorOptions      *KustomizeGeneratorOption `json:"generatorOptions,omitempty"`
+	PatchesStrategicMerge []string                  `json:"patchesStrategicMerge,omitempty"`
+	PatchesJSON6902       []KustomizeJSON6902       `json:"patchesJson6902,omitempty"`
+}
+
+// KustomizeImage kustomization images field, it modify container image whose name is Name
+type KustomizeImage struct {
+	Name    string `json:"name,omitempty"`
+	NewName string `json:"newName,omitempty"`
+	NewTag  string `json:"newTag,omitempty"`
+	Digest  string `json:"digest,omitempty"`
+}
+
+// KustomizeGenerator kustomization configMapGenerator and secretGenerator field
+// Behavior is one of create,merge,replace, default value is create
+type KustomizeGenerator struct {
+	Name      string   `json:"name,omitempty"`
+	Namespace string   `json:"namespace,omitempty"`
+	Behavior  string   `json:"behavior,omitempty"`
+	Files     []string `json:"files,omitempty"`
+	Literals  []string `json:"literals,omitempty"`
+	Envs      []string `json:"envs,omitempty"`
+	Env       string   `json:"env,omitempty"`
+	Type      string   `json:"type,omitempty"`
+}
+
+// KustomizeGeneratorOption kustomization generatorOptions field
+type KustomizeGeneratorOption struct {
+	Labels                map[string]string `json:"labels,omitempty"`
+	Annotations           map[string]string `json:"annotations,omitempty"`
+	DisableNameSuffixHash bool              `json:"disableNameSuffixHash,omitempty"`
+}
+
+// KustomizeJSON6902 kustomization patchesJson6902 field, patch is read from Path or Patch
+type KustomizeJSON6902 struct {
+	Target *KustomizeTarget `json:"target,omitempty"`
+	Path   string           `json:"path,omitempty"`
+	Patch  string           `json:"patch,omitempty"`
+}
+
+// KustomizeTarget the resource object which patchesJson6902 modify
+type KustomizeTarget struct {
+	Group     string `json:"group,omitempty"`
+	Version   string `json:"version,omitempty"`
+	Kind      string `json:"kind,omitempty"`
+	Name      string `json:"name,omitempty"`
+	Namespace string `json:"namespace,omitempty"`
+}
+
+// ReadKustomization read kustomization.yaml in dir and build its Kubernetes resource objects into Bundle,
+// resources and bases are read recursively, generators, patches and transformers are applied in the kustomize order.
+// you can get object from Bundle and continue to modify it by builder Replace(), eg: NewDeployment().Replace(dp)
+func ReadKustomization(dir string) (*Bundle, error) {
+	items, err := loadKustomization(dir)
+	if err != nil {
+		return nil, err
+	}
+	if err = hashGeneratedNames(items); err != nil {
+		return nil, err
+	}
+	bundle := NewBundle()
+	for _, item := range items {
+		o, err := mapToObject(item)
+		if err != nil {
+			return nil, err
+		}
+		bundle.Add(o)
+	}
+	return bundle, bundle.err
+}
+
+// loadKustomization load kustomization in dir and return resource objects in map
+func loadKustomization(dir string) ([]map[string]interface{}, error) {
+	var (
+		byts []byte
+		err  error
+	)
+	for _, name := range kustomizationFiles {
+		if byts, err = ioutil.ReadFile(filepath.Join(dir, name)); err == nil {
+			break
+		}
+	}
+	if err != nil {
+		return nil, fmt.Errorf("kustomization is not found in %s", dir)
+	}
+	k := new(Kustomization)
+	if err = yaml.Unmarshal(byts, k); err != nil {
+		return nil, fmt.Errorf("read %s/kustomization err:%v", dir, err)
+	}
+	var items []map[string]interface{}
+	for _, res := range append(k.Bases, k.Resources...) {
+		path := filepath.Join(dir, res)
+		info, err := os.Stat(path)
+		if err != nil {
+			return nil, err
+		}
+		var objs []map[string]interface{}
+		if info.IsDir() {
+			objs, err = loadKustomization(path)
+		} else {
+			objs, err = readManifests(path)
+		}
+		if err != nil {
+			return nil, err
+		}
+		items = append(items, objs...)
+	}
+	if items, err = k.generate(dir, items); err != nil {
+		return nil, err
+	}
+	if items, err = k.patch(dir, items); err != nil {
+		return nil, err
+	}
+	k.transform(items)
+	return items, nil
+}
+
+// readManifests read multi-document yaml or json file
+func readManifests(path string) ([]map[string]interface{}, error) {
+	byts, err := ioutil.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	var items []map[string]interface{}
+	for _, doc := range splitYAML(byts) {
+		item := make(map[string]interface{}, 0)
+		if err = yaml.Unmarshal(doc, &item); err != nil {
+			return nil, fmt.Errorf("read %s err:%v", path, err)
+		}
+		if len(item) <= 0 {
+			continue
+		}
+		if kind, _ := item["kind"].(string); kind == "List" {
+			list, _ := item["items"].([]interface{})
+			for _, l := range list {
+				if m, ok := l.(map[string]interface{}); ok {
+					items = append(items, m)
+				}
+			}
+			continue
+		}
+		items = append(items, item)
+	}
+	return items, nil
+}
+
+// splitYAML split multi-document yaml by '---'
+func splitYAML(byts []byte) [][]byte {
+	var (
+		docs [][]byte
+		buf  bytes.Buffer
+	)
+	scanner := bufio.NewScanner(bytes.NewReader(byts))
+	scanner.Buffer(make([]byte, 0, 64*1024), 16*1024*1024)
+	for scanner.Scan() {
+		line := scanner.Text()
+		if strings.HasPrefix(line, "---") && strings.TrimSpace(strings.TrimLeft(line, "-")) == "" {
+			docs = append(docs, append([]byte{}, buf.Bytes()...))
+			buf.Reset()
+			continue
+		}
+		buf.WriteString(line)
+		buf.WriteByte('\n')
+	}
+	return append(docs, buf.Bytes())
+}
+
+// generate apply configMapGenerator and secretGenerator
+func (k *Kustomization) generate(dir string, items []map[string]interface{}) ([]map[string]interface{}, error) {
+	for index, gens := range [][]KustomizeGenerator{k.ConfigMapGenerator, k.SecretGenerator} {
+		kind := "ConfigMap"
+		if index == 1 {
+			kind = "Secret"
+		}
+		for _, gen := range gens {
+			if !verifyString(gen.Name) {
+				return nil, fmt.Errorf("%s generator name is not allowed to be empty", kind)
+			}
+			data, err := gen.data(dir)
+			if err != nil {
+				return nil, err
+			}
+			if kind == "Secret" {
+				for key, val := range data {
+					data[key] = Base64Encode([]byte(val.(string)))
+				}
+			}
+			exist := findObject(items, kind, gen.Namespace, gen.Name)
+			switch gen.Behavior {
+			case "", "create":
+				if exist != nil {
+					return nil, fmt.Errorf("%s generator %s already exists, use behavior merge or replace", kind, gen.Name)
+				}
+			case "merge", "replace":
+				if exist == nil {
+					return nil, fmt.Errorf("%s generator %s with behavior %s is not found in resources", kind, gen.Name, gen.Behavior)
+				}
+				old, _ := exist["data"].(map[string]interface{})
+				if gen.Behavior == "merge" && old != nil {
+					for key, val := range data {
+						old[key] = val
+					}
+					data = old
+				}
+				exist["data"] = data
+				continue
+			default:
+				return nil, fmt.Errorf("%s generator %s behavior %s is not supported", kind, gen.Name, gen.Behavior)
+			}
+			metadata := map[string]interface{}{"name": gen.Name}
+			if verifyString(gen.Namespace) {
+				metadata["namespace"] = gen.Namespace
+			}
+			if k.GeneratorOptions != nil {
+				if len(k.GeneratorOptions.Labels) > 0 {
+					metadata["labels"] = stringMapToMap(k.GeneratorOptions.Labels)
+				}
+				if len(k.GeneratorOptions.Annotations) > 0 {
+					metadata["annotations"] = stringMapToMap(k.GeneratorOptions.Annotations)
+				}
+			}
+			item := map[string]interface{}{"apiVersion": "v1", "kind": kind, "metadata": metadata, "data": data}
+			if kind == "Secret" {
+				item["type"] = string(SecretTypeOpaque)
+				if verifyString(gen.Type) {
+					item["type"] = gen.Type
+				}
+			}
+			if k.GeneratorOptions == nil || !k.GeneratorOptions.DisableNameSuffixHash {
+				item[nameSuffixHashField] = true
+			}
+			items = append(items, item)
+		}
+	}
+	return items, nil
+}
+
+// hashGeneratedNames append content hash suffix to the names of generated ConfigMaps and Secrets
+// in the same way as kustomize, and update the references to them.
+// It is called after all kustomizations are applied, so the hash is computed on the final name and data
+func hashGeneratedNames(items []map[string]interface{}) error {
+	renames := make(map[string]map[string]string, 0)
+	for _, item := range items {
+		if _, ok := item[nameSuffixHashField]; !ok {
+			continue
+		}
+		delete(item, nameSuffixHashField)
+		kind, _, name := objectKey(item)
+		// the same fields as kustomize encodeConfigMap and encodeSecret, json encodes map keys in order
+		content := map[string]interface{}{"kind": kind, "name": name, "data": item["data"]}
+		if kind == "Secret" {
+			content["type"] = item["type"]
+		} else if binaryData, ok := item["binaryData"]; ok {
+			content["binaryData"] = binaryData
+		}
+		byts, err := json.Marshal(content)
+		if err != nil {
+			return err
+		}
+		sum := sha256.Sum256(byts)
+		if renames[kind] == nil {
+			renames[kind] = make(map[string]string, 0)
+		}
+		renames[kind][name] = name + "-" + kustomizeHashEncode(hex.EncodeToString(sum[:]))
+		objectMeta(item)["name"] = renames[kind][name]
+	}
+	if len(renames) > 0 {
+		renameReferences(items, renames)
+	}
+	return nil
+}
+
+// kustomizeHashEncode return the first 10 characters of hex hash,
+// some characters are replaced like kustomize to avoid generating bad words
+func kustomizeHashEncode(hash string) string {
+	enc := []byte(hash[:nameHashLength])
+	for index, c := range enc {
+		switch c {
+		case '0':
+			enc[index] = 'g'
+		case '1':
+			enc[index] = 'h'
+		case '3':
+			enc[index] = 'k'
+		case 'a':
+			enc[index] = 'm'
+		case 'e':
+			enc[index] = 't'
+		}
+	}
+	return string(enc)
+}
+
+// data read generator literals, files and env files
+func (gen *KustomizeGenerator) data(dir string) (map[string]interface{}, error) {
+	data := make(map[string]interface{}, 0)
+	for _, literal := range gen.Literals {
+		kv := strings.SplitN(literal, "=", 2)
+		if len(kv) != 2 || !verifyString(kv[0]) {
+			return nil, fmt.Errorf("generator %s literal %q is not key=value", gen.Name, literal)
+		}
+		data[kv[0]] = kv[1]
+	}
+	for _, file := range gen.Files {
+		key, path := filepath.Base(file), file
+		if kv := strings.SplitN(file, "=", 2); len(kv) == 2 {
+			key, path = kv[0], kv[1]
+		}
+		byts, err := ioutil.ReadFile(filepath.Join(dir, path))
+		if err != nil {
+			return nil, err
+		}
+		data[key] = string(byts)
+	}
+	envs := gen.Envs
+	if verifyString(gen.Env) {
+		envs = append(envs, gen.Env)
+	}
+	for _, env := range envs {
+		byts, err := ioutil.ReadFile(filepath.Join(dir, env))
+		if err != nil {
+			return nil, err
+		}
+		for _, line := range strings.Split(string(byts), "\n") {
+			line = strings.TrimSpace(line)
+			if emptyString(line) || strings.HasPrefix(line, "#") {
+				continue
+			}
+			kv := strings.SplitN(line, "=", 2)
+			if len(kv) != 2 {
+				return nil, fmt.Errorf("generator %s env file %s line %q is not key=value", gen.Name, env, line)
+			}
+			data[kv[0]] = kv[1]
+		}
+	}
+	return data, nil
+}
+
+// patch apply patchesStrategicMerge and patchesJson6902
+func (k *Kustomization) patch(dir string, items []map[string]interface{}) ([]map[string]interface{}, error) {
+	for _, path := range k.PatchesStrategicMerge {
+		patches, err := readManifests(filepath.Join(dir, path))
+		if err != nil {
+			return nil, err
+		}
+		for _, patch := range patches {
+			kind, namespace, name := objectKey(patch)
+			index := findObjectIndex(items, kind, namespace, name)
+			if index < 0 {
+				return nil, fmt.Errorf("patch %s target %s %s is not found", path, kind, name)
+			}
+			if directive, _ := patch["$patch"].(string); directive == "delete" {
+				items = append(items[:index], items[index+1:]...)
+				continue
+			}
+			dataStruct, err := newTypedObject(items[index])
+			if err != nil {
+				return nil, fmt.Errorf("patch %s err:%v", path, err)
+			}
+			patched, err := strategicpatch.StrategicMergeMapPatch(items[index], patch, dataStruct)
+			if err != nil {
+				return nil, fmt.Errorf("patch %s err:%v", path, err)
+			}
+			items[index] = patched
+		}
+	}
+	for _, p := range k.PatchesJSON6902 {
+		if p.Target == nil || !verifyString(p.Target.Kind) || !verifyString(p.Target.Name) {
+			return nil, errors.New("patchesJson6902 target kind and name are not allowed to be empty")
+		}
+		ops := []byte(p.Patch)
+		if verifyString(p.Path) {
+			byts, err := ioutil.ReadFile(filepath.Join(dir, p.Path))
+			if err != nil {
+				return nil, err
+			}
+			ops = byts
+		}
+		ops, err := yaml.YAMLToJSON(ops)
+		if err != nil {
+			return nil, fmt.Errorf("patchesJson6902 %s err:%v", p.Target.Name, err)
+		}
+		jp, err := jsonpatch.DecodePatch(ops)
+		if err != nil {
+			return nil, fmt.Errorf("patchesJson6902 %s err:%v", p.Target.Name, err)
+		}
+		index := findObjectIndex(items, p.Target.Kind, p.Target.Namespace, p.Target.Name)
+		if index < 0 {
+			return nil, fmt.Errorf("patchesJson6902 target %s %s is not found", p.Target.Kind, p.Target.Name)
+		}
+		byts, err := json.Marshal(items[index])
+		if err != nil {
+			return nil, err
+		}
+		if byts, err = jp.Apply(byts); err != nil {
+			return nil, fmt.Errorf("patchesJson6902 %s err:%v", p.Target.Name, err)
+		}
+		patched := make(map[string]interface{}, 0)
+		if err = json.Unmarshal(byts, &patched); err != nil {
+			return nil, err
+		}
+		items[index] = patched
+	}
+	return items, nil
+}
+
+// transform apply namePrefix,nameSuffix,namespace,commonLabels,commonAnnotations and images
+func (k *Kustomization) transform(items []map[string]interface{}) {
+	if verifyString(k.NamePrefix) || verifyString(k.NameSuffix) {
+		renames := make(map[string]map[string]string, 0)
+		for _, item := range items {
+			kind, _, name := objectKey(item)
+			if kind == "Namespace" || kind == "CustomResourceDefinition" {
+				continue
+			}
+			if renames[kind] == nil {
+				renames[kind] = make(map[string]string, 0)
+			}
+			renames[kind][name] = k.NamePrefix + name + k.NameSuffix
+			objectMeta(item)["name"] = renames[kind][name]
+		}
+		renameReferences(items, renames)
+	}
+	if verifyString(k.Namespace) {
+		for _, item := range items {
+			kind, _, _ := objectKey(item)
+			if !clusterScopedKinds[kind] {
+				objectMeta(item)["namespace"] = k.Namespace
+			}
+			if kind == "RoleBinding" || kind == "ClusterRoleBinding" {
+				subjects, _ := item["subjects"].([]interface{})
+				for _, s := range subjects {
+					subject, _ := s.(map[string]interface{})
+					if sname, _ := subject["name"].(string); subject["kind"] == "ServiceAccount" &&
+						findObject(items, "ServiceAccount", "", sname) != nil {
+						subject["namespace"] = k.Namespace
+					}
+				}
+			}
+		}
+	}
+	for _, item := range items {
+		kind, _, _ := objectKey(item)
+		if len(k.CommonLabels) > 0 {
+			mergeStringMap(objectMeta(item), "labels", k.CommonLabels)
+			for _, selector := range labelSelectors(item, kind) {
+				for key, val := range k.CommonLabels {
+					selector[key] = val
+				}
+			}
+			for _, tpl := range podTemplates(item, kind) {
+				mergeStringMap(nestedMap(tpl, true, "metadata"), "labels", k.CommonLabels)
+			}
+		}
+		if len(k.CommonAnnotations) > 0 {
+			mergeStringMap(objectMeta(item), "annotations", k.CommonAnnotations)
+			for _, tpl := range podTemplates(item, kind) {
+				mergeStringMap(nestedMap(tpl, true, "metadata"), "annotations", k.CommonAnnotations)
+			}
+		}
+		for _, img := range k.Images {
+			for _, spec := range podSpecs(item, kind) {
+				for _, field := range []string{"initContainers", "containers"} {
+					containers, _ := spec[field].([]interface{})
+					for _, c := range containers {
+						container, _ := c.(map[string]interface{})
+						image, _ := container["image"].(string)
+						if newImage, ok := img.replace(image); ok {
+							container["image"] = newImage
+						}
+					}
+				}
+			}
+		}
+	}
+}
+
+// replace return new image when image name is matched
+func (img KustomizeImage) replace(image string) (string, bool) {
+	name, tag := image, ""
+	if index := strings.Index(name, "@"); index >= 0 {
+		name, tag = name[:index], name[index:]
+	} else if index := strings.LastIndex(name, ":"); index > strings.LastIndex(name, "/") {
+		name, tag = name[:index], name[index:]
+	}
+	if name != img.Name {
+		return "", false
+	}
+	if verifyString(img.NewName) {
+		name = img.NewName
+	}
+	switch {
+	case verifyString(img.Digest):
+		tag = "@" + img.Digest
+	case verifyString(img.NewTag):
+		tag = ":" + img.NewTag
+	}
+	return name + tag, true
+}
+
+// renameReferences update the references to renamed objects, renames is map[kind]map[oldName]newName
+func renameReferences(items []map[string]interface{}, renames map[string]map[string]string) {
+	rename := func(m map[string]interface{}, field, kind string) {
+		if m == nil {
+			return
+		}
+		if old, ok := m[field].(string); ok {
+			if name, ok := renames[kind][old]; ok {
+				m[field] = name
+			}
+		}
+	}
+	for _, item := range items {
+		kind, _, _ := objectKey(item)
+		for _, spec := range podSpecs(item, kind) {
+			rename(spec, "serviceAccountName", "ServiceAccount")
+			rename(spec, "serviceAccount", "ServiceAccount")
+			for _, s := range sliceMaps(spec["imagePullSecrets"]) {
+				rename(s, "name", "Secret")
+			}
+			for _, volume := range sliceMaps(spec["volumes"]) {
+				rename(nestedMap(volume, false, "configMap"), "name", "ConfigMap")
+				rename(nestedMap(volume, false, "secret"), "secretName", "Secret")
+				rename(nestedMap(volume, false, "persistentVolumeClaim"), "claimName", "PersistentVolumeClaim")
+				for _, source := range sliceMaps(nestedMap(volume, false, "projected")["sources"]) {
+					rename(nestedMap(source, false, "configMap"), "name", "ConfigMap")
+					rename(nestedMap(source, false, "secret"), "name", "Secret")
+				}
+			}
+			for _, field := range []string{"initContainers", "containers"} {
+				for _, container := range sliceMaps(spec[field]) {
+					for _, env := range sliceMaps(container["env"]) {
+						rename(nestedMap(env, false, "valueFrom", "configMapKeyRef"), "name", "ConfigMap")
+						rename(nestedMap(env, false, "valueFrom", "secretKeyRef"), "name", "Secret")
+					}
+					for _, env := range sliceMaps(container["envFrom"]) {
+						rename(nestedMap(env, false, "configMapRef"), "name", "ConfigMap")
+						rename(nestedMap(env, false, "secretRef"), "name", "Secret")
+					}
+				}
+			}
+		}
+		switch kind {
+		case "StatefulSet":
+			rename(nestedMap(item, false, "spec"), "serviceName", "Service")
+		case "RoleBinding", "ClusterRoleBinding":
+			roleRef := nestedMap(item, false, "roleRef")
+			if refKind, _ := roleRef["kind"].(string); verifyString(refKind) {
+				rename(roleRef, "name", refKind)
+			}
+			for _, subject := range sliceMaps(item["subjects"]) {
+				if subject["kind"] == "ServiceAccount" {
+					rename(subject, "name", "ServiceAccount")
+				}
+			}
+		}
+	}
+}
+
+// podSpecs return pod spec of workload in map
+func podSpecs(item map[string]interface{}, kind string) []map[string]interface{} {
+	if kind == "Pod" {
+		return []map[string]interface{}{nestedMap(item, true, "spec")}
+	}
+	var specs []map[string]interface{}
+	for _, tpl := range podTemplates(item, kind) {
+		specs = append(specs, nestedMap(tpl, true, "spec"))
+	}
+	return specs
+}
+
+// podTemplates return pod template of workload in map
+func podTemplates(item map[string]interface{}, kind string) []map[string]interface{} {
+	var tpl map[string]interface{}
+	switch kind {
+	case "Deployment", "StatefulSet", "DaemonSet", "ReplicaSet", "ReplicationController", "Job":
+		tpl = nestedMap(item, false, "spec", "template")
+	case "CronJob":
+		tpl = nestedMap(item, false, "spec", "jobTemplate", "spec", "template")
+	}
+	if tpl == nil {
+		return nil
+	}
+	return []map[string]interface{}{tpl}
+}
+
+// labelSelectors return label selectors which select the pods of object,
+// like kustomize, the selectors of Service and NetworkPolicy are not created when they do not exist,
+// because Service without selector has manual Endpoints and empty podSelector selects all pods
+func labelSelectors(item map[string]interface{}, kind string) []map[string]interface{} {
+	var selector map[string]interface{}
+	switch kind {
+	case "Service":
+		selector = nestedMap(item, false, "spec", "selector")
+	case "ReplicationController":
+		selector = nestedMap(item, true, "spec", "selector")
+	case "Deployment", "StatefulSet", "DaemonSet", "ReplicaSet", "PodDisruptionBudget":
+		selector = nestedMap(item, true, "spec", "selector", "matchLabels")
+	case "NetworkPolicy":
+		selector = nestedMap(item, false, "spec", "podSelector", "matchLabels")
+	}
+	if selector == nil {
+		return nil
+	}
+	return []map[string]interface{}{selector}
+}
+
+// nestedMap return nested map by fields, create it when autoCreate is true and it does not exist
+func nestedMap(m map[string]interface{}, autoCreate bool, fields ...string) map[string]interface{} {
+	for _, field := range fields {
+		if m == nil {
+			return nil
+		}
+		next, ok := m[field].(map[string]interface{})
+		if !ok {
+			if !autoCreate {
+				return nil
+			}
+			next = make(map[string]interface{}, 0)
+			m[field] = next
+		}
+		m = next
+	}
+	return m
+}
+
+// sliceMaps return items of slice which are map
+func sliceMaps(v interface{}) []map[string]interface{} {
+	list, _ := v.([]interface{})
+	var maps []map[string]interface{}
+	for _, l := range list {
+		if m, ok := l.(map[string]interface{}); ok {
+			maps = append(maps, m)
+		}
+	}
+	return maps
+}
+
+func objectMeta(item map[string]interface{}) map[string]interface{} {
+	return nestedMap(item, true, "metadata")
+}
+
+// objectKey return kind, namespace and name of object
+func objectKey(item map[string]interface{}) (kind, namespace, name string) {
+	kind, _ = item["kind"].(string)
+	meta := nestedMap(item, false, "metadata")
+	namespace, _ = meta["namespace"].(string)
+	name, _ = meta["name"].(string)
+	return
+}
+
+// findObjectIndex find object by kind, namespace and name, namespace is ignored when it is empty
+func findObjectIndex(items []map[string]interface{}, kind, namespace, name string) int {
+	for index, item := range items {
+		k, ns, n := objectKey(item)
+		if k == kind && n == name && (emptyString(namespace) || ns == namespace) {
+			return index
+		}
+	}
+	return -1
+}
+
+func findObject(items []map[string]interface{}, kind, namespace, name string) map[string]interface{} {
+	if index := findObjectIndex(items, kind, namespace, name); index >= 0 {
+		return items[index]
+	}
+	return nil
+}
+
+func mergeStringMap(m map[string]interface{}, field string, values map[string]string) {
+	target := nestedMap(m, true, field)
+	for key, val := range values {
+		target[key] = val
+	}
+}
+
+func stringMapToMap(values map[string]string) map[string]interface{} {
+	m := make(map[string]interface{}, len(values))
+	for key, val := range values {
+		m[key] = val
+	}
+	return m
+}
+
+// newTypedObject return empty typed object by apiVersion and kind in map
+func newTypedObject(item map[string]interface{}) (runtime.Object, error) {
+	apiVersion, _ := item["apiVersion"].(string)
+	kind, _ := item["kind"].(string)
+	gv, err := schema.ParseGroupVersion(apiVersion)
+	if err != nil {
+		return nil, err
+	}
+	return scheme.Scheme.New(gv.WithKind(kind))
+}
+
+// mapToObject translate object in map into typed object, unknown kind is translated into Unstructured
+func mapToObject(item map[string]interface{}) (runtime.Object, error) {
+	byts, err := json.Marshal(item)
+	if err != nil {
+		return nil, err
+	}
+	o, _, err := scheme.Codecs.UniversalDeserializer().Decode(byts, nil, nil)
+	if err != nil {
+		if runtime.IsNotRegisteredError(err) {
+			return &unstructured.Unstructured{Object: item}, nil
+		}
+		return nil, err
+	}
+	return o, nil
+}
+
+// objectToMap translate Kubernetes resource object into map
+func objectToMap(o runtime.Object) (map[string]interface{}, error) {
+	byts, err := json.Marshal(o)
+	if err != nil {
+		return nil, err
+	}
+	item := make(map[string]interface{}, 0)
+	if err = json.Unmarshal(byts, &item); err != nil {
+		return nil, err
+	}
+	delete(item, "status")
+	delete(objectMeta(item), "creationTimestamp")
+	return item, nil
+}
+
+// Kustomize emit beku Bundle as kustomize base and overlays directory tree
+type Kustomize struct {
+	base     *Bundle
+	overlays map[string]*Bundle
+	err      error
+}
+
+// NewKustomize create Kustomize and chain function call begin with this function.
+// base is the Kubernetes resource objects which are written into base directory
+func NewKustomize(base *Bundle) *Kustomize {
+	return &Kustomize{base: base, overlays: make(map[string]*Bundle, 0)}
+}
+
+// AddOverlay add overlay which is written into overlays/name directory,
+// objects which are changed from base are written as patchesStrategicMerge,
+// objects which are not in base are written as resources,
+// objects which are only in base are deleted by '$patch: delete'
+func (obj *Kustomize) AddOverlay(name string, overlay *Bundle) *Kustomize {
+	if !helmChartName.MatchString(name) {
+		obj.error(fmt.Errorf("Kustomize overlay name:%q is not allowed,only lower case letters, numbers and '-' are allowed", name))
+		return obj
+	}
+	obj.overlays[name] = overlay
+	return obj
+}
+
+// Finish Chain function call end with this function
+// return kustomize files and error, key is file path relative to root directory, eg: base/kustomization.yaml
+func (obj *Kustomize) Finish() (map[string][]byte, error) {
+	obj.verify()
+	if obj.err != nil {
+		return nil, obj.err
+	}
+	files := make(map[string][]byte, 0)
+	baseObjs, err := obj.base.Finish()
+	if err != nil {
+		return nil, err
+	}
+	baseItems := make([]map[string]interface{}, 0, len(baseObjs))
+	k := &Kustomization{APIVersion: "kustomize.config.k8s.io/v1beta1", Kind: "Kustomization"}
+	for _, o := range baseObjs {
+		item, err := objectToMap(o)
+		if err != nil {
+			return nil, err
+		}
+		baseItems = append(baseItems, item)
+		name, err := addManifest(files, "base", "", item)
+		if err != nil {
+			return nil, err
+		}
+		k.Resources = append(k.Resources, name)
+	}
+	if files["base/kustomization.yaml"], err = ToYAML(k); err != nil {
+		return nil, err
+	}
+	for overlay, bundle := range obj.overlays {
+		if err = obj.overlay(files, overlay, bundle, baseItems); err != nil {
+			return nil, err
+		}
+	}
+	return files, nil
+}
+
+// overlay emit overlays/name directory
+func (obj *Kustomize) overlay(files map[string][]byte, name string, bundle *Bundle, baseItems []map[string]interface{}) error {
+	objs, err := bundle.Finish()
+	if err != nil {
+		return fmt.Errorf("Kustomize overlay %s err:%v", name, err)
+	}
+	dir := "overlays/" + name
+	k := &Kustomization{APIVersion: "kustomize.config.k8s.io/v1beta1", Kind: "Kustomization", Resources: []string{"../../base"}}
+	items := make([]map[string]interface{}, 0, len(objs))
+	for _, o := range objs {
+		item, err := objectToMap(o)
+		if err != nil {
+			return err
+		}
+		items = append(items, item)
+		kind, namespace, oname := objectKey(item)
+		base := findObject(baseItems, kind, namespace, oname)
+		if base == nil {
+			file, err := addManifest(files, dir, "", item)
+			if err != nil {
+				return err
+			}
+			k.Resources = append(k.Resources, file)
+			continue
+		}
+		patch, err := twoWayPatch(base, item, o)
+		if err != nil {
+			return fmt.Errorf("Kustomize overlay %s err:%v", name, err)
+		}
+		if patch == nil {
+			continue
+		}
+		file, err := addManifest(files, dir, "patch-", patch)
+		if err != nil {
+			return err
+		}
+		k.PatchesStrategicMerge = append(k.PatchesStrategicMerge, file)
+	}
+	for _, base := range baseItems {
+		kind, namespace, oname := objectKey(base)
+		if findObject(items, kind, namespace, oname) != nil {
+			continue
+		}
+		patch := map[string]interface{}{"apiVersion": base["apiVersion"], "kind": kind, "$patch": "delete",
+			"metadata": map[string]interface{}{"name": oname, "namespace": namespace}}
+		file, err := addManifest(files, dir, "delete-", patch)
+		if err != nil {
+			return err
+		}
+		k.PatchesStrategicMerge = append(k.PatchesStrategicMerge, file)
+	}
+	sort.Strings(k.PatchesStrategicMerge)
+	files[dir+"/kustomization.yaml"], err = ToYAML(k)
+	return err
+}
+
+// twoWayPatch create strategic merge patch from base to item, return nil when there is no difference
+func twoWayPatch(base, item map[string]interface{}, dataStruct runtime.Object) (map[string]interface{}, error) {
+	original, err := json.Marshal(base)
+	if err != nil {
+		return nil, err
+	}
+	modified, err := json.Marshal(item)
+	if err != nil {
+		return nil, err
+	}
+	byts, err := strategicpatch.CreateTwoWayMergePatch(original, modified, dataStruct)
+	if err != nil {
+		return nil, err
+	}
+	patch := make(map[string]interface{}, 0)
+	if err = json.Unmarshal(byts, &patch); err != nil {
+		return nil, err
+	}
+	if len(patch) <= 0 {
+		return nil, nil
+	}
+	kind, namespace, name := objectKey(item)
+	patch["apiVersion"] = item["apiVersion"]
+	patch["kind"] = kind
+	metadata := nestedMap(patch, true, "metadata")
+	metadata["name"] = name
+	if verifyString(namespace) {
+		metadata["namespace"] = namespace
+	}
+	return patch, nil
+}
+
+// addManifest add object yaml into files and return file name relative to dir
+func addManifest(files map[string][]byte, dir, prefix string, item map[string]interface{}) (string, error) {
+	kind, _, name := objectKey(item)
+	file := fmt.Sprintf("%s%s-%s.yaml", prefix, strings.ToLower(kind), name)
+	if _, ok := files[dir+"/"+file]; ok {
+		return "", fmt.Errorf("Kustomize err,file %s/%s is repeated", dir, file)
+	}
+	byts, err := ToYAML(item)
+	if err != nil {
+		return "", err
+	}
+	files[dir+"/"+file] = byts
+	return file, nil
+}
+
+// Write write kustomize files into dir
+func (obj *Kustomize) Write(dir string) error {
+	files, err := obj.Finish()
+	if err != nil {
+		return err
+	}
+	for name, byts := range files {
+		path := filepath.Join(dir, name)
+		if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+			return err
+		}
+		if err := ioutil.WriteFile(path, byts, 0644); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (obj *Kustomize) error(err error) {
+	if obj.err != nil {
+		return
+	}
+	obj.err = err
+}
+
+// verify check Kustomize necessary value
+func (obj *Kustomize) verify() {
+	if obj.err != nil {
+		return
+	}
+	if obj.base == nil {
+		obj.err = errors.New("Kustomize base is not allowed to be empty")
+		return
+	}
+}
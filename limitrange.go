@@ -0,0 +1,266 @@
+package beku
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+
+	"github.com/ghodss/yaml"
+	"k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/resource"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+// LimitRange include Kubernetes resource object LimitRange and error
+type LimitRange struct {
+	lr  *v1.LimitRange
+	err error
+}
+
+// NewLimitRange create LimitRange and chain function call begin with this function.
+func NewLimitRange() *LimitRange { return &LimitRange{lr: &v1.LimitRange{}} }
+
+// Finish Chain function call end with this function
+// return Kubernetes resource object LimitRange and error.
+// In the function, it will check necessary parameters,input the default field
+func (obj *LimitRange) Finish() (*v1.LimitRange, error) {
+	obj.verify()
+	return obj.lr, obj.err
+}
+
+// JSONNew use json data create LimitRange
+func (obj *LimitRange) JSONNew(jsonbyts []byte) *LimitRange {
+	obj.error(json.Unmarshal(jsonbyts, obj.lr))
+	return obj
+}
+
+// YAMLNew use yaml data create LimitRange
+func (obj *LimitRange) YAMLNew(yamlbyts []byte) *LimitRange {
+	obj.error(yaml.Unmarshal(yamlbyts, obj.lr))
+	return obj
+}
+
+// JSONNewTemplate use json template and values create LimitRange,
+// the template is rendered by RenderTemplate() and must be valid json
+func (obj *LimitRange) JSONNewTemplate(tpl []byte, values interface{}) *LimitRange {
+	jsonbyts, err := renderJSONTemplate(tpl, values)
+	if err != nil {
+		obj.error(err)
+		return obj
+	}
+	return obj.JSONNew(jsonbyts)
+}
+
+// YAMLNewTemplate use yaml template and values create LimitRange,
+// the template is rendered by RenderTemplate() and must be valid yaml
+func (obj *LimitRange) YAMLNewTemplate(tpl []byte, values interface{}) *LimitRange {
+	yamlbyts, err := renderYAMLTemplate(tpl, values)
+	if err != nil {
+		obj.error(err)
+		return obj
+	}
+	return obj.YAMLNew(yamlbyts)
+}
+
+// Replace replace LimitRange by Kubernetes resource object
+func (obj *LimitRange) Replace(lr *v1.LimitRange) *LimitRange {
+	if lr != nil {
+		obj.lr = lr
+	}
+	return obj
+}
+
+// SetName set LimitRange name
+func (obj *LimitRange) SetName(name string) *LimitRange {
+	obj.lr.SetName(name)
+	return obj
+}
+
+// SetNamespace set LimitRange namespace
+func (obj *LimitRange) SetNamespace(namespace string) *LimitRange {
+	obj.lr.SetNamespace(namespace)
+	return obj
+}
+
+// SetNamespaceAndName set LimitRange namespace and name
+func (obj *LimitRange) SetNamespaceAndName(namespace, name string) *LimitRange {
+	obj.SetNamespace(namespace)
+	obj.SetName(name)
+	return obj
+}
+
+// SetLabels set LimitRange labels
+func (obj *LimitRange) SetLabels(labels map[string]string) *LimitRange {
+	obj.lr.SetLabels(labels)
+	return obj
+}
+
+// SetAnnotations set LimitRange annotations
+func (obj *LimitRange) SetAnnotations(annotations map[string]string) *LimitRange {
+	if len(obj.lr.Annotations) <= 0 {
+		obj.lr.Annotations = annotations
+		return obj
+	}
+	for key, value := range annotations {
+		obj.lr.Annotations[key] = value
+	}
+	return obj
+}
+
+// SetLimit set limit of Container,Pod or PersistentVolumeClaim,
+// the limit of the same type will be replaced.
+func (obj *LimitRange) SetLimit(item LimitRangeItem) *LimitRange {
+	limitType := item.Type.ToK8s()
+	if limitType == "" {
+		obj.error(fmt.Errorf("SetLimit err,limit type %s is not supported", item.Type))
+		return obj
+	}
+	k8sItem := v1.LimitRangeItem{Type: limitType}
+	for _, data := range []struct {
+		name  string
+		maps  map[ResourceName]string
+		field *v1.ResourceList
+	}{
+		{"max", item.Max, &k8sItem.Max},
+		{"min", item.Min, &k8sItem.Min},
+		{"default", item.Default, &k8sItem.Default},
+		{"defaultRequest", item.DefaultRequest, &k8sItem.DefaultRequest},
+		{"maxLimitRequestRatio", item.MaxLimitRequestRatio, &k8sItem.MaxLimitRequestRatio},
+	} {
+		if len(data.maps) <= 0 {
+			continue
+		}
+		list, err := ResourceMapsToK8s(data.maps)
+		if err != nil {
+			obj.error(fmt.Errorf("SetLimit err,%s of %s:%v", data.name, item.Type, err))
+			return obj
+		}
+		*data.field = list
+	}
+	for index := range obj.lr.Spec.Limits {
+		if obj.lr.Spec.Limits[index].Type == limitType {
+			obj.lr.Spec.Limits[index] = k8sItem
+			return obj
+		}
+	}
+	obj.lr.Spec.Limits = append(obj.lr.Spec.Limits, k8sItem)
+	return obj
+}
+
+// SetContainerDefault set default requests and limits of containers which don't set them
+func (obj *LimitRange) SetContainerDefault(requests, limits map[ResourceName]string) *LimitRange {
+	return obj.SetLimit(LimitRangeItem{Type: LimitTypeContainer, DefaultRequest: requests, Default: limits})
+}
+
+// Release release LimitRange on Kubernetes
+func (obj *LimitRange) Release() (*v1.LimitRange, error) {
+	lr, err := obj.Finish()
+	if err != nil {
+		return nil, err
+	}
+	client, err := GetKubeClient()
+	if err != nil {
+		return nil, err
+	}
+	return client.CoreV1().LimitRanges(lr.GetNamespace()).Create(lr)
+}
+
+// Apply it will be updated when this resource object exists in K8s,
+// it will be created when it does not exist.
+func (obj *LimitRange) Apply() (*v1.LimitRange, error) {
+	lr, err := obj.Finish()
+	if err != nil {
+		return nil, err
+	}
+	client, err := GetKubeClient()
+	if err != nil {
+		return nil, err
+	}
+	_, err = client.CoreV1().LimitRanges(lr.GetNamespace()).Get(lr.GetName(), metav1.GetOptions{})
+	if err != nil {
+		return client.CoreV1().LimitRanges(lr.GetNamespace()).Create(lr)
+	}
+	return client.CoreV1().LimitRanges(lr.GetNamespace()).Update(lr)
+}
+
+// Delete delete LimitRange on Kubernetes
+func (obj *LimitRange) Delete() error {
+	lr, err := obj.Finish()
+	if err != nil {
+		return err
+	}
+	client, err := GetKubeClient()
+	if err != nil {
+		return err
+	}
+	return client.CoreV1().LimitRanges(lr.GetNamespace()).Delete(lr.GetName(), &metav1.DeleteOptions{})
+}
+
+func (obj *LimitRange) error(err error) {
+	if obj.err != nil {
+		return
+	}
+	obj.err = err
+}
+
+// verifyLimitRangeItem check min <= defaultRequest <= default <= max of every resource and maxLimitRequestRatio >= 1
+func verifyLimitRangeItem(item v1.LimitRangeItem) error {
+	if item.Type != v1.LimitTypeContainer && (len(item.Default) > 0 || len(item.DefaultRequest) > 0) {
+		return fmt.Errorf("LimitRange default and defaultRequest are not allowed in type %s,only Container is allowed", item.Type)
+	}
+	if item.Type == v1.LimitTypePersistentVolumeClaim {
+		for _, list := range []v1.ResourceList{item.Max, item.Min, item.MaxLimitRequestRatio} {
+			for name := range list {
+				if name != v1.ResourceStorage {
+					return fmt.Errorf("LimitRange resource %s is not allowed in type PersistentVolumeClaim,only storage is allowed", name)
+				}
+			}
+		}
+	}
+	// the order from small to large
+	ordered := []struct {
+		name string
+		list v1.ResourceList
+	}{{"min", item.Min}, {"defaultRequest", item.DefaultRequest}, {"default", item.Default}, {"max", item.Max}}
+	for i := 0; i < len(ordered); i++ {
+		for j := i + 1; j < len(ordered); j++ {
+			for name, small := range ordered[i].list {
+				large, ok := ordered[j].list[name]
+				if ok && small.Cmp(large) > 0 {
+					return fmt.Errorf("LimitRange %s %s %s is not allowed to be greater than %s %s of type %s",
+						name, ordered[i].name, small.String(), ordered[j].name, large.String(), item.Type)
+				}
+			}
+		}
+	}
+	one := resource.MustParse("1")
+	for name, ratio := range item.MaxLimitRequestRatio {
+		if ratio.Cmp(one) < 0 {
+			return fmt.Errorf("LimitRange %s maxLimitRequestRatio %s is not allowed to be less than 1 of type %s", name, ratio.String(), item.Type)
+		}
+	}
+	return nil
+}
+
+// verify check LimitRange necessary value, input the default field and input related data.
+func (obj *LimitRange) verify() {
+	if obj.err != nil {
+		return
+	}
+	if !verifyString(obj.lr.GetName()) {
+		obj.err = errors.New("LimitRange name is not allowed to be empty")
+		return
+	}
+	if len(obj.lr.Spec.Limits) <= 0 {
+		obj.err = errors.New("LimitRange limits is not allowed to be empty,you can call SetLimit() input")
+		return
+	}
+	for _, item := range obj.lr.Spec.Limits {
+		if err := verifyLimitRangeItem(item); err != nil {
+			obj.err = err
+			return
+		}
+	}
+	obj.lr.Kind = "LimitRange"
+	obj.lr.APIVersion = "v1"
+}
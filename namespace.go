@@ -1,16 +1,50 @@
 package beku
 
 import (
+	"encoding/json"
 	"errors"
+	"fmt"
+	"regexp"
+	"sort"
+	"strings"
+	"time"
 
+	"github.com/ghodss/yaml"
 	"k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/util/wait"
+	"k8s.io/client-go/kubernetes"
 )
 
+const (
+	// NamespaceNameLabelKey the label which has the Namespace name as value, it is set automatically,
+	// so NetworkPolicy can select Namespace by name, eg: NetworkPolicyPeer{NamespaceLabels: map[string]string{NamespaceNameLabelKey: "monitoring"}}
+	NamespaceNameLabelKey = "kubernetes.io/metadata.name"
+	// podSecurityLabelPrefix the label prefix of Pod Security admission
+	podSecurityLabelPrefix = "pod-security.kubernetes.io/"
+)
+
+// namespacePollInterval interval of checking Namespace is deleted in Delete()
+var namespacePollInterval = 2 * time.Second
+
+// protectedNamespaces the Namespaces which are refused to be deleted unless forced
+var protectedNamespaces = map[string]bool{
+	"default":         true,
+	"kube-system":     true,
+	"kube-public":     true,
+	"kube-node-lease": true,
+}
+
+var podSecurityVersion = regexp.MustCompile(`^(latest|v1\.(0|[1-9][0-9]*))$`)
+
 // Namespace include Kubernets resource object Namespace and err
 type Namespace struct {
-	ns  *v1.Namespace
-	err error
+	ns          *v1.Namespace
+	quotas      []*ResourceQuota
+	limitRanges []*LimitRange
+	err         error
 }
 
 // NewNs create Namespace and Chain function call begin with this function.
@@ -24,29 +58,210 @@ func (obj *Namespace) Finish() (*v1.Namespace, error) {
 	return obj.ns, obj.err
 }
 
+// Replace replace ns by Kubernetes resource object
+func (obj *Namespace) Replace(ns *v1.Namespace) *Namespace {
+	if ns != nil {
+		obj.ns = ns
+	}
+	return obj
+}
+
+// JSONNew use json data create Namespace
+func (obj *Namespace) JSONNew(jsonbyts []byte) *Namespace {
+	obj.error(json.Unmarshal(jsonbyts, obj.ns))
+	return obj
+}
+
+// YAMLNew use yaml data create Namespace
+func (obj *Namespace) YAMLNew(yamlbyts []byte) *Namespace {
+	obj.error(yaml.Unmarshal(yamlbyts, obj.ns))
+	return obj
+}
+
+// JSONNewTemplate use json template and values create Namespace,
+// the template is rendered by RenderTemplate() and must be valid json
+func (obj *Namespace) JSONNewTemplate(tpl []byte, values interface{}) *Namespace {
+	jsonbyts, err := renderJSONTemplate(tpl, values)
+	if err != nil {
+		obj.error(err)
+		return obj
+	}
+	return obj.JSONNew(jsonbyts)
+}
+
+// YAMLNewTemplate use yaml template and values create Namespace,
+// the template is rendered by RenderTemplate() and must be valid yaml
+func (obj *Namespace) YAMLNewTemplate(tpl []byte, values interface{}) *Namespace {
+	yamlbyts, err := renderYAMLTemplate(tpl, values)
+	if err != nil {
+		obj.error(err)
+		return obj
+	}
+	return obj.YAMLNew(yamlbyts)
+}
+
 // SetName set namespace name
 func (obj *Namespace) SetName(name string) *Namespace {
 	obj.ns.SetName(name)
 	return obj
 }
 
-// Release release Namespace on Kubernetes
-func (obj *Namespace) Release() (*v1.Namespace, error) {
+// SetLabels set Namespace labels, label kubernetes.io/metadata.name is set automatically
+func (obj *Namespace) SetLabels(labels map[string]string) *Namespace {
+	if len(obj.ns.Labels) <= 0 {
+		obj.ns.Labels = labels
+		return obj
+	}
+	for key, value := range labels {
+		obj.ns.Labels[key] = value
+	}
+	return obj
+}
+
+// SetAnnotations set Namespace annotations
+func (obj *Namespace) SetAnnotations(annotations map[string]string) *Namespace {
+	if len(obj.ns.Annotations) <= 0 {
+		obj.ns.Annotations = annotations
+		return obj
+	}
+	for key, value := range annotations {
+		obj.ns.Annotations[key] = value
+	}
+	return obj
+}
+
+// SetPodSecurity set Pod Security admission label of Namespace, it can be called once for every mode,
+// eg: SetPodSecurity(PodSecurityEnforce, PodSecurityBaseline).SetPodSecurity(PodSecurityWarn, PodSecurityRestricted)
+// version[0] is the version of Pod Security Standards, eg: latest, v1.25, default is latest
+func (obj *Namespace) SetPodSecurity(mode PodSecurityMode, level PodSecurityLevel, version ...string) *Namespace {
+	switch mode {
+	case PodSecurityEnforce, PodSecurityAudit, PodSecurityWarn:
+	default:
+		obj.error(fmt.Errorf("SetPodSecurity err,mode %s is not supported", mode))
+		return obj
+	}
+	switch level {
+	case PodSecurityPrivileged, PodSecurityBaseline, PodSecurityRestricted:
+	default:
+		obj.error(fmt.Errorf("SetPodSecurity err,level %s is not supported", level))
+		return obj
+	}
+	labels := map[string]string{podSecurityLabelPrefix + string(mode): string(level)}
+	if len(version) > 0 {
+		if !podSecurityVersion.MatchString(version[0]) {
+			obj.error(fmt.Errorf("SetPodSecurity err,version %s must be latest or v1.x", version[0]))
+			return obj
+		}
+		labels[podSecurityLabelPrefix+string(mode)+"-version"] = version[0]
+	}
+	return obj.SetLabels(labels)
+}
+
+// WithQuota attach ResourceQuota to Namespace, it's namespace will be set to Namespace name,
+// it can be called many times and will be released with Namespace
+func (obj *Namespace) WithQuota(quota *ResourceQuota) *Namespace {
+	if quota == nil {
+		obj.error(errors.New("WithQuota err,quota is not allowed to be nil"))
+		return obj
+	}
+	obj.quotas = append(obj.quotas, quota)
+	return obj
+}
+
+// WithLimitRange attach LimitRange to Namespace, it's namespace will be set to Namespace name,
+// it can be called many times and will be released with Namespace
+func (obj *Namespace) WithLimitRange(lr *LimitRange) *Namespace {
+	if lr == nil {
+		obj.error(errors.New("WithLimitRange err,limitRange is not allowed to be nil"))
+		return obj
+	}
+	obj.limitRanges = append(obj.limitRanges, lr)
+	return obj
+}
+
+// objects finish Namespace with it's ResourceQuotas and LimitRanges, Namespace is the first one
+func (obj *Namespace) objects() ([]runtime.Object, error) {
 	ns, err := obj.Finish()
 	if err != nil {
 		return nil, err
 	}
+	objs := []runtime.Object{ns}
+	for _, quota := range obj.quotas {
+		o, err := quota.SetNamespace(ns.GetName()).Finish()
+		if err != nil {
+			return nil, err
+		}
+		objs = append(objs, o)
+	}
+	for _, lr := range obj.limitRanges {
+		o, err := lr.SetNamespace(ns.GetName()).Finish()
+		if err != nil {
+			return nil, err
+		}
+		objs = append(objs, o)
+	}
+	return objs, nil
+}
+
+// finishAll finish Namespace with ResourceQuotas and LimitRanges, so their errors are returned before releasing
+func (obj *Namespace) finishAll() (*v1.Namespace, error) {
+	objs, err := obj.objects()
+	if err != nil {
+		return nil, err
+	}
+	return objs[0].(*v1.Namespace), nil
+}
+
+// releaseAttached release or apply ResourceQuotas and LimitRanges which are set by WithQuota() and WithLimitRange()
+func (obj *Namespace) releaseAttached(apply bool) error {
+	for _, quota := range obj.quotas {
+		var err error
+		if apply {
+			_, err = quota.Apply()
+		} else {
+			_, err = quota.Release()
+		}
+		if err != nil {
+			return err
+		}
+	}
+	for _, lr := range obj.limitRanges {
+		var err error
+		if apply {
+			_, err = lr.Apply()
+		} else {
+			_, err = lr.Release()
+		}
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// Release release Namespace on Kubernetes,
+// ResourceQuotas and LimitRanges will be released too when they are set by WithQuota() and WithLimitRange()
+func (obj *Namespace) Release() (*v1.Namespace, error) {
+	ns, err := obj.finishAll()
+	if err != nil {
+		return nil, err
+	}
 	client, err := GetKubeClient()
 	if err != nil {
 		return nil, err
 	}
-	return client.CoreV1().Namespaces().Create(ns)
+	ns, err = client.CoreV1().Namespaces().Create(ns)
+	if err != nil {
+		return nil, err
+	}
+	return ns, obj.releaseAttached(false)
 }
 
 // Apply  it will be updated when this resource object exists in K8s,
 // it will be created when it does not exist.
+// ResourceQuotas and LimitRanges will be applied too when they are set by WithQuota() and WithLimitRange()
 func (obj *Namespace) Apply() (*v1.Namespace, error) {
-	ns, err := obj.Finish()
+	ns, err := obj.finishAll()
 	if err != nil {
 		return nil, err
 	}
@@ -56,16 +271,137 @@ func (obj *Namespace) Apply() (*v1.Namespace, error) {
 	}
 	_, err = client.CoreV1().Namespaces().Get(ns.GetName(), metav1.GetOptions{})
 	if err != nil {
-		return client.CoreV1().Namespaces().Create(ns)
+		ns, err = client.CoreV1().Namespaces().Create(ns)
+	} else {
+		ns, err = client.CoreV1().Namespaces().Update(ns)
+	}
+	if err != nil {
+		return nil, err
 	}
-	return client.CoreV1().Namespaces().Update(ns)
+	return ns, obj.releaseAttached(true)
+}
+
+// Delete delete Namespace on Kubernetes and wait until it is removed or timeout, it does not wait when timeout <= 0.
+// The protected Namespaces(default, kube-system, kube-public, kube-node-lease) are refused to be deleted unless force[0] is true.
+// When timeout, the finalizers and the remaining resources which block termination are returned in error.
+func (obj *Namespace) Delete(timeout time.Duration, force ...bool) error {
+	ns, err := obj.Finish()
+	if err != nil {
+		return err
+	}
+	if protectedNamespaces[ns.GetName()] && (len(force) <= 0 || !force[0]) {
+		return fmt.Errorf("Namespace %s is protected,you can call Delete(timeout, true) to force deleting it", ns.GetName())
+	}
+	client, err := GetKubeClient()
+	if err != nil {
+		return err
+	}
+	err = client.CoreV1().Namespaces().Delete(ns.GetName(), &metav1.DeleteOptions{})
+	if err != nil || timeout <= 0 {
+		return err
+	}
+	var (
+		present *v1.Namespace
+		pollErr error
+	)
+	err = wait.PollImmediate(namespacePollInterval, timeout, func() (bool, error) {
+		current, err := client.CoreV1().Namespaces().Get(ns.GetName(), metav1.GetOptions{})
+		if apierrors.IsNotFound(err) {
+			return true, nil
+		}
+		// other errors may be transient, the Namespace is read again in next poll
+		if pollErr = err; err == nil {
+			present = current
+		}
+		return false, nil
+	})
+	if err != wait.ErrWaitTimeout {
+		return err
+	}
+	var blocking []string
+	if present != nil {
+		blocking = append(blocking, fmt.Sprintf("phase:%s", present.Status.Phase))
+		if len(present.Spec.Finalizers) > 0 {
+			blocking = append(blocking, fmt.Sprintf("spec.finalizers:%v", present.Spec.Finalizers))
+		}
+		if len(present.GetFinalizers()) > 0 {
+			blocking = append(blocking, fmt.Sprintf("metadata.finalizers:%v", present.GetFinalizers()))
+		}
+	}
+	if pollErr != nil {
+		blocking = append(blocking, fmt.Sprintf("last error:%v", pollErr))
+	}
+	if remaining := remainingResources(client, ns.GetName()); len(remaining) > 0 {
+		blocking = append(blocking, "remaining resources:"+strings.Join(remaining, ","))
+	}
+	return fmt.Errorf("Namespace %s is not deleted in %v,%s", ns.GetName(), timeout, strings.Join(blocking, ";"))
+}
+
+// remainingResources return the resources which are still in Namespace, eg: "Pod web-0 finalizers:[foregroundDeletion]",
+// all namespaced resources include custom resources are listed, the errors of listing are ignored
+func remainingResources(client *kubernetes.Clientset, namespace string) []string {
+	// the result is partial when some API groups are unavailable, it is one of the reasons of blocking
+	lists, _ := client.Discovery().ServerPreferredNamespacedResources()
+	var remaining []string
+	for _, list := range lists {
+		prefix := "/apis/" + list.GroupVersion
+		if list.GroupVersion == "v1" {
+			prefix = "/api/v1"
+		}
+		for _, resource := range list.APIResources {
+			if resource.Name == "events" || strings.Contains(resource.Name, "/") || !hasVerb(resource.Verbs, "list") {
+				continue
+			}
+			byts, err := client.Discovery().RESTClient().Get().AbsPath(prefix, "namespaces", namespace, resource.Name).DoRaw()
+			if err != nil {
+				continue
+			}
+			var items struct {
+				Items []struct {
+					Metadata metav1.ObjectMeta `json:"metadata"`
+				} `json:"items"`
+			}
+			if json.Unmarshal(byts, &items) != nil {
+				continue
+			}
+			for _, item := range items.Items {
+				desc := resource.Kind + " " + item.Metadata.Name
+				if len(item.Metadata.Finalizers) > 0 {
+					desc += fmt.Sprintf(" finalizers:%v", item.Metadata.Finalizers)
+				}
+				remaining = append(remaining, desc)
+			}
+		}
+	}
+	sort.Strings(remaining)
+	return remaining
+}
+
+func hasVerb(verbs metav1.Verbs, verb string) bool {
+	for _, v := range verbs {
+		if v == verb {
+			return true
+		}
+	}
+	return false
+}
+
+func (obj *Namespace) error(err error) {
+	if obj.err != nil {
+		return
+	}
+	obj.err = err
 }
 
 func (obj *Namespace) verify() {
+	if obj.err != nil {
+		return
+	}
 	if obj.ns.GetName() == "" {
 		obj.err = errors.New("Namespace.Name is not allowed to be empty")
 		return
 	}
+	obj.SetLabels(map[string]string{NamespaceNameLabelKey: obj.ns.GetName()})
 	obj.ns.APIVersion = "v1"
 	obj.ns.Kind = "Namespace"
 }
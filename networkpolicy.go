@@ -0,0 +1,424 @@
+package beku
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net"
+
+	"github.com/ghodss/yaml"
+	"k8s.io/api/core/v1"
+	networkingv1 "k8s.io/api/networking/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/util/intstr"
+)
+
+// NetworkPolicy include Kubernetes resource object NetworkPolicy and error
+type NetworkPolicy struct {
+	np  *networkingv1.NetworkPolicy
+	err error
+}
+
+// NewNetworkPolicy create NetworkPolicy and chain function call begin with this function.
+// NetworkPolicy select all Pods in namespace when SetPodSelector() is not called
+func NewNetworkPolicy() *NetworkPolicy {
+	return &NetworkPolicy{np: &networkingv1.NetworkPolicy{}}
+}
+
+// NewDefaultDenyNetworkPolicy create NetworkPolicy named default-deny which denies all traffic of Pods in namespace,
+// policyTypes default Ingress and Egress, Pods can't resolve DNS when Egress is denied,
+// so you may need NewAllowDNSNetworkPolicy() together.
+func NewDefaultDenyNetworkPolicy(namespace string, policyTypes ...PolicyType) *NetworkPolicy {
+	if len(policyTypes) <= 0 {
+		policyTypes = []PolicyType{PolicyTypeIngress, PolicyTypeEgress}
+	}
+	return NewNetworkPolicy().SetNamespaceAndName(namespace, "default-deny").SetPolicyTypes(policyTypes...)
+}
+
+// NewAllowDNSNetworkPolicy create NetworkPolicy named allow-dns which allows all Pods in namespace
+// to access kube-dns(CoreDNS) Pods which has label k8s-app=kube-dns on port 53 of UDP and TCP
+func NewAllowDNSNetworkPolicy(namespace string) *NetworkPolicy {
+	peer := NetworkPeer{
+		NamespaceLabels: map[string]string{},
+		PodLabels:       map[string]string{"k8s-app": "kube-dns"},
+	}
+	return NewNetworkPolicy().SetNamespaceAndName(namespace, "allow-dns").SetPolicyTypes(PolicyTypeEgress).
+		SetEgressRule([]NetworkPeer{peer}, NetworkPort{Protocol: ProtocolUDP, Port: FromInt(53)}, NetworkPort{Protocol: ProtocolTCP, Port: FromInt(53)})
+}
+
+// Finish Chain function call end with this function
+// return Kubernetes resource object NetworkPolicy and error.
+// In the function, it will check necessary parameters,input the default field
+func (obj *NetworkPolicy) Finish() (*networkingv1.NetworkPolicy, error) {
+	obj.verify()
+	return obj.np, obj.err
+}
+
+// JSONNew use json data create NetworkPolicy
+func (obj *NetworkPolicy) JSONNew(jsonbyts []byte) *NetworkPolicy {
+	obj.error(json.Unmarshal(jsonbyts, obj.np))
+	return obj
+}
+
+// YAMLNew use yaml data create NetworkPolicy
+func (obj *NetworkPolicy) YAMLNew(yamlbyts []byte) *NetworkPolicy {
+	obj.error(yaml.Unmarshal(yamlbyts, obj.np))
+	return obj
+}
+
+// JSONNewTemplate use json template and values create NetworkPolicy,
+// the template is rendered by RenderTemplate() and must be valid json
+func (obj *NetworkPolicy) JSONNewTemplate(tpl []byte, values interface{}) *NetworkPolicy {
+	jsonbyts, err := renderJSONTemplate(tpl, values)
+	if err != nil {
+		obj.error(err)
+		return obj
+	}
+	return obj.JSONNew(jsonbyts)
+}
+
+// YAMLNewTemplate use yaml template and values create NetworkPolicy,
+// the template is rendered by RenderTemplate() and must be valid yaml
+func (obj *NetworkPolicy) YAMLNewTemplate(tpl []byte, values interface{}) *NetworkPolicy {
+	yamlbyts, err := renderYAMLTemplate(tpl, values)
+	if err != nil {
+		obj.error(err)
+		return obj
+	}
+	return obj.YAMLNew(yamlbyts)
+}
+
+// Replace replace NetworkPolicy by Kubernetes resource object
+func (obj *NetworkPolicy) Replace(np *networkingv1.NetworkPolicy) *NetworkPolicy {
+	if np != nil {
+		obj.np = np
+	}
+	return obj
+}
+
+// SetName set NetworkPolicy name
+func (obj *NetworkPolicy) SetName(name string) *NetworkPolicy {
+	obj.np.SetName(name)
+	return obj
+}
+
+// SetNamespace set NetworkPolicy namespace
+func (obj *NetworkPolicy) SetNamespace(namespace string) *NetworkPolicy {
+	obj.np.SetNamespace(namespace)
+	return obj
+}
+
+// SetNamespaceAndName set NetworkPolicy namespace and name
+func (obj *NetworkPolicy) SetNamespaceAndName(namespace, name string) *NetworkPolicy {
+	obj.SetNamespace(namespace)
+	obj.SetName(name)
+	return obj
+}
+
+// SetLabels set NetworkPolicy labels
+func (obj *NetworkPolicy) SetLabels(labels map[string]string) *NetworkPolicy {
+	obj.np.SetLabels(labels)
+	return obj
+}
+
+// SetAnnotations set NetworkPolicy annotations
+func (obj *NetworkPolicy) SetAnnotations(annotations map[string]string) *NetworkPolicy {
+	if len(obj.np.Annotations) <= 0 {
+		obj.np.Annotations = annotations
+		return obj
+	}
+	for key, value := range annotations {
+		obj.np.Annotations[key] = value
+	}
+	return obj
+}
+
+// SetPodSelector set labels of Pods which NetworkPolicy applies to,
+// empty labels select all Pods in namespace
+func (obj *NetworkPolicy) SetPodSelector(labels map[string]string) *NetworkPolicy {
+	obj.np.Spec.PodSelector = metav1.LabelSelector{MatchLabels: labels}
+	return obj
+}
+
+// SetPolicyTypes set the traffic types which NetworkPolicy applies to:Ingress,Egress,
+// default Ingress and Egress when egress rule exists
+func (obj *NetworkPolicy) SetPolicyTypes(types ...PolicyType) *NetworkPolicy {
+	policyTypes := make([]networkingv1.PolicyType, 0, len(types))
+	for _, policyType := range types {
+		if policyType.ToK8s() == "" {
+			obj.error(fmt.Errorf("SetPolicyTypes err,policy type %s is not supported", policyType))
+			return obj
+		}
+		policyTypes = append(policyTypes, policyType.ToK8s())
+	}
+	obj.np.Spec.PolicyTypes = policyTypes
+	return obj
+}
+
+// SetIngressRule add ingress rule which allows traffic from peers to ports of selected Pods,
+// empty peers allow all sources, empty ports allow all ports
+func (obj *NetworkPolicy) SetIngressRule(peers []NetworkPeer, ports ...NetworkPort) *NetworkPolicy {
+	from, err := networkPeers(peers)
+	if err != nil {
+		obj.error(fmt.Errorf("SetIngressRule err,%s", err.Error()))
+		return obj
+	}
+	obj.np.Spec.Ingress = append(obj.np.Spec.Ingress, networkingv1.NetworkPolicyIngressRule{
+		From:  from,
+		Ports: networkPorts(ports),
+	})
+	return obj
+}
+
+// SetEgressRule add egress rule which allows traffic from selected Pods to ports of peers,
+// empty peers allow all destinations, empty ports allow all ports
+func (obj *NetworkPolicy) SetEgressRule(peers []NetworkPeer, ports ...NetworkPort) *NetworkPolicy {
+	to, err := networkPeers(peers)
+	if err != nil {
+		obj.error(fmt.Errorf("SetEgressRule err,%s", err.Error()))
+		return obj
+	}
+	obj.np.Spec.Egress = append(obj.np.Spec.Egress, networkingv1.NetworkPolicyEgressRule{
+		To:    to,
+		Ports: networkPorts(ports),
+	})
+	return obj
+}
+
+// AllowFrom add ingress rule which allows traffic from Pods of workload to ports of selected Pods,
+// workload only Deployment,StatefulSet,DaemonSet and Pod builder in the same namespace,
+// Pod labels of workload must be set before calling this function.
+func (obj *NetworkPolicy) AllowFrom(workload interface{}, ports ...NetworkPort) *NetworkPolicy {
+	var meta metav1.ObjectMeta
+	var labels map[string]string
+	switch v := workload.(type) {
+	case *Deployment:
+		meta, labels = v.dp.ObjectMeta, v.GetPodLabel()
+	case *StatefulSet:
+		meta, labels = v.sts.ObjectMeta, v.GetPodLabel()
+	case *DaemonSet:
+		meta, labels = v.ds.ObjectMeta, v.GetPodLabel()
+	case *Pod:
+		meta, labels = v.pod.ObjectMeta, v.pod.GetLabels()
+	default:
+		obj.error(fmt.Errorf("AllowFrom err,type %T is not supported", workload))
+		return obj
+	}
+	if len(labels) <= 0 {
+		obj.error(fmt.Errorf("AllowFrom err,Pod labels of %s is not allowed to be empty", meta.GetName()))
+		return obj
+	}
+	if err := obj.sameNamespace(meta.GetNamespace()); err != nil {
+		obj.error(fmt.Errorf("AllowFrom err,%s", err.Error()))
+		return obj
+	}
+	return obj.SetIngressRule([]NetworkPeer{{PodLabels: labels}}, ports...)
+}
+
+// AllowTo add egress rule which allows traffic from selected Pods to Pods of Service in the same namespace,
+// port is port number or port name of Service, it is translated into target port and protocol of Pods,
+// port is allowed to be empty when Service only has one port.
+func (obj *NetworkPolicy) AllowTo(service *Service, port ...intstr.IntOrString) *NetworkPolicy {
+	if service == nil {
+		obj.error(errors.New("AllowTo err,Service is not allowed to be empty"))
+		return obj
+	}
+	svc, err := service.Finish()
+	if err != nil {
+		obj.error(fmt.Errorf("AllowTo err,%s", err.Error()))
+		return obj
+	}
+	if len(svc.Spec.Selector) <= 0 {
+		obj.error(fmt.Errorf("AllowTo err,Service %s has no selector", svc.GetName()))
+		return obj
+	}
+	if err = obj.sameNamespace(svc.GetNamespace()); err != nil {
+		obj.error(fmt.Errorf("AllowTo err,%s", err.Error()))
+		return obj
+	}
+	var servicePort *intstr.IntOrString
+	if len(port) > 0 {
+		servicePort = &port[0]
+	}
+	matched, err := findServicePort(svc, servicePort)
+	if err != nil {
+		obj.error(fmt.Errorf("AllowTo err,%s", err.Error()))
+		return obj
+	}
+	var networkPort NetworkPort
+	for _, sp := range svc.Spec.Ports {
+		if (matched.Type == intstr.Int && sp.Port == matched.IntVal) || (matched.Type == intstr.String && sp.Name == matched.StrVal) {
+			networkPort = NetworkPort{Protocol: Protocol(sp.Protocol), Port: sp.TargetPort}
+			if sp.TargetPort.Type == intstr.Int && sp.TargetPort.IntVal == 0 {
+				networkPort.Port = FromInt(int(sp.Port))
+			}
+			break
+		}
+	}
+	return obj.SetEgressRule([]NetworkPeer{{PodLabels: svc.Spec.Selector}}, networkPort)
+}
+
+// sameNamespace check namespace of peer is the same as NetworkPolicy,
+// Pods in other namespace must be selected by NamespaceLabels
+func (obj *NetworkPolicy) sameNamespace(namespace string) error {
+	if verifyString(namespace) && verifyString(obj.np.GetNamespace()) && namespace != obj.np.GetNamespace() {
+		return fmt.Errorf("namespace %s is not the same as NetworkPolicy namespace %s,you can call SetIngressRule() or SetEgressRule() with NamespaceLabels",
+			namespace, obj.np.GetNamespace())
+	}
+	return nil
+}
+
+// networkPeers translate NetworkPeer into Kubernetes NetworkPolicyPeer
+func networkPeers(peers []NetworkPeer) ([]networkingv1.NetworkPolicyPeer, error) {
+	if len(peers) <= 0 {
+		return nil, nil
+	}
+	k8sPeers := make([]networkingv1.NetworkPolicyPeer, 0, len(peers))
+	for index, peer := range peers {
+		k8sPeer := networkingv1.NetworkPolicyPeer{}
+		if peer.PodLabels != nil {
+			k8sPeer.PodSelector = &metav1.LabelSelector{MatchLabels: peer.PodLabels}
+		}
+		if peer.NamespaceLabels != nil {
+			k8sPeer.NamespaceSelector = &metav1.LabelSelector{MatchLabels: peer.NamespaceLabels}
+		}
+		if verifyString(peer.CIDR) {
+			if k8sPeer.PodSelector != nil || k8sPeer.NamespaceSelector != nil {
+				return nil, fmt.Errorf("peers[%d] CIDR is not allowed to be set with PodLabels or NamespaceLabels", index)
+			}
+			if err := verifyIPBlock(peer.CIDR, peer.Except); err != nil {
+				return nil, fmt.Errorf("peers[%d] %s", index, err.Error())
+			}
+			k8sPeer.IPBlock = &networkingv1.IPBlock{CIDR: peer.CIDR, Except: peer.Except}
+		}
+		if k8sPeer.PodSelector == nil && k8sPeer.NamespaceSelector == nil && k8sPeer.IPBlock == nil {
+			return nil, fmt.Errorf("peers[%d] is not allowed to be empty", index)
+		}
+		k8sPeers = append(k8sPeers, k8sPeer)
+	}
+	return k8sPeers, nil
+}
+
+// verifyIPBlock check cidr is valid and except CIDRs are in cidr
+func verifyIPBlock(cidr string, except []string) error {
+	_, ipNet, err := net.ParseCIDR(cidr)
+	if err != nil {
+		return fmt.Errorf("CIDR %s is invalid:%s", cidr, err.Error())
+	}
+	ones, _ := ipNet.Mask.Size()
+	for _, data := range except {
+		exceptIP, exceptNet, err := net.ParseCIDR(data)
+		if err != nil {
+			return fmt.Errorf("except CIDR %s is invalid:%s", data, err.Error())
+		}
+		exceptOnes, _ := exceptNet.Mask.Size()
+		if !ipNet.Contains(exceptIP) || exceptOnes <= ones {
+			return fmt.Errorf("except CIDR %s must be in CIDR %s", data, cidr)
+		}
+	}
+	return nil
+}
+
+// networkPorts translate NetworkPort into Kubernetes NetworkPolicyPort
+func networkPorts(ports []NetworkPort) []networkingv1.NetworkPolicyPort {
+	if len(ports) <= 0 {
+		return nil
+	}
+	k8sPorts := make([]networkingv1.NetworkPolicyPort, 0, len(ports))
+	for _, port := range ports {
+		protocol := port.Protocol.ToK8s()
+		k8sPort := networkingv1.NetworkPolicyPort{Protocol: &protocol}
+		if port.Port.Type == intstr.String || port.Port.IntVal != 0 {
+			portVal := port.Port
+			k8sPort.Port = &portVal
+		}
+		k8sPorts = append(k8sPorts, k8sPort)
+	}
+	return k8sPorts
+}
+
+// Release release NetworkPolicy on Kubernetes
+func (obj *NetworkPolicy) Release() (*networkingv1.NetworkPolicy, error) {
+	np, err := obj.Finish()
+	if err != nil {
+		return nil, err
+	}
+	client, err := GetKubeClient()
+	if err != nil {
+		return nil, err
+	}
+	return client.NetworkingV1().NetworkPolicies(np.GetNamespace()).Create(np)
+}
+
+// Apply it will be updated when this resource object exists in K8s,
+// it will be created when it does not exist.
+func (obj *NetworkPolicy) Apply() (*networkingv1.NetworkPolicy, error) {
+	np, err := obj.Finish()
+	if err != nil {
+		return nil, err
+	}
+	client, err := GetKubeClient()
+	if err != nil {
+		return nil, err
+	}
+	_, err = client.NetworkingV1().NetworkPolicies(np.GetNamespace()).Get(np.GetName(), metav1.GetOptions{})
+	if err != nil {
+		return client.NetworkingV1().NetworkPolicies(np.GetNamespace()).Create(np)
+	}
+	return client.NetworkingV1().NetworkPolicies(np.GetNamespace()).Update(np)
+}
+
+// Delete delete NetworkPolicy on Kubernetes
+func (obj *NetworkPolicy) Delete() error {
+	np, err := obj.Finish()
+	if err != nil {
+		return err
+	}
+	client, err := GetKubeClient()
+	if err != nil {
+		return err
+	}
+	return client.NetworkingV1().NetworkPolicies(np.GetNamespace()).Delete(np.GetName(), &metav1.DeleteOptions{})
+}
+
+func (obj *NetworkPolicy) error(err error) {
+	if obj.err != nil {
+		return
+	}
+	obj.err = err
+}
+
+// verify check NetworkPolicy necessary value, input the default field and input related data.
+func (obj *NetworkPolicy) verify() {
+	if obj.err != nil {
+		return
+	}
+	if !verifyString(obj.np.GetName()) {
+		obj.err = errors.New("NetworkPolicy name is not allowed to be empty")
+		return
+	}
+	if len(obj.np.Spec.PolicyTypes) <= 0 {
+		obj.np.Spec.PolicyTypes = []networkingv1.PolicyType{networkingv1.PolicyTypeIngress}
+		if len(obj.np.Spec.Egress) > 0 {
+			obj.np.Spec.PolicyTypes = append(obj.np.Spec.PolicyTypes, networkingv1.PolicyTypeEgress)
+		}
+	}
+	for _, rule := range obj.np.Spec.Ingress {
+		for _, port := range rule.Ports {
+			if port.Protocol != nil && *port.Protocol != v1.ProtocolTCP && *port.Protocol != v1.ProtocolUDP && *port.Protocol != v1.ProtocolSCTP {
+				obj.err = fmt.Errorf("NetworkPolicy ingress port protocol %s is not supported", *port.Protocol)
+				return
+			}
+		}
+	}
+	for _, rule := range obj.np.Spec.Egress {
+		for _, port := range rule.Ports {
+			if port.Protocol != nil && *port.Protocol != v1.ProtocolTCP && *port.Protocol != v1.ProtocolUDP && *port.Protocol != v1.ProtocolSCTP {
+				obj.err = fmt.Errorf("NetworkPolicy egress port protocol %s is not supported", *port.Protocol)
+				return
+			}
+		}
+	}
+	obj.np.Kind = "NetworkPolicy"
+	obj.np.APIVersion = "networking.k8s.io/v1"
+}
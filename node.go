@@ -1,16 +1,39 @@
 package beku
 
 import (
+	"context"
 	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
 
 	"github.com/ghodss/yaml"
 	"k8s.io/api/core/v1"
+	policyv1beta1 "k8s.io/api/policy/v1beta1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/fields"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/apimachinery/pkg/util/strategicpatch"
+	"k8s.io/apimachinery/pkg/util/wait"
+	"k8s.io/client-go/kubernetes"
+	"k8s.io/client-go/util/retry"
 )
 
+// drainPollInterval interval of retrying eviction and checking Pods are deleted in Drain()
+var drainPollInterval = 5 * time.Second
+
+// mirrorPodKey the annotation of static Pod which is created by kubelet, it can't be evicted
+const mirrorPodKey = "kubernetes.io/config.mirror"
+
 // Node include Kubernetes resource object node and error
 type Node struct {
 	node *v1.Node
-	err  error
+	// origin is the node when it is read, Apply() only patches the changes from origin,
+	// it is empty for json and yaml data, so all of their labels, annotations and taints are applied
+	origin *v1.Node
+	err    error
 }
 
 /*
@@ -19,22 +42,49 @@ It is not allowed to create a new Node. because
 Serious errors may occur
 */
 
-// JSONNewNode use json data create Node
+// JSONNewNode use json data create Node,
+// Apply() adds or updates the labels, annotations and taints of json data on Node, the others on Node are kept
 func JSONNewNode(jsonbyts []byte) *Node {
-	obj := &Node{node: &v1.Node{}}
+	obj := &Node{node: &v1.Node{}, origin: &v1.Node{}}
 	obj.error(json.Unmarshal(jsonbyts, obj.node))
 	return obj
 }
 
-// YAMLNewNode use yaml data create Node
+// YAMLNewNode use yaml data create Node,
+// Apply() adds or updates the labels, annotations and taints of yaml data on Node, the others on Node are kept
 func YAMLNewNode(yamlbyts []byte) *Node {
-	obj := &Node{node: &v1.Node{}}
+	obj := &Node{node: &v1.Node{}, origin: &v1.Node{}}
 	obj.error(yaml.Unmarshal(yamlbyts, obj.node))
 	return obj
 }
 
-// ReadNewNode read new node
-func ReadNewNode(coreNode *v1.Node) *Node { return &Node{node: coreNode} }
+// ReadNewNode read new node, Apply() only patches the changes which are made by setters after reading
+func ReadNewNode(coreNode *v1.Node) *Node {
+	obj := &Node{node: coreNode}
+	if coreNode == nil {
+		obj.node = &v1.Node{}
+		obj.error(errors.New("ReadNewNode err,node is not allowed to be empty"))
+	}
+	obj.origin = obj.node.DeepCopy()
+	return obj
+}
+
+// GetNode read Node from Kubernetes by name, it is the beginning of node maintenance,
+// eg: GetNode("node-1").SetTaints("maintenance", "true", TaintEffectNoSchedule).Apply()
+func GetNode(name string) *Node {
+	obj := &Node{node: &v1.Node{}}
+	client, err := GetKubeClient()
+	if err != nil {
+		obj.error(err)
+		return obj
+	}
+	node, err := client.CoreV1().Nodes().Get(name, metav1.GetOptions{})
+	if err != nil {
+		obj.error(fmt.Errorf("GetNode err,%v", err))
+		return obj
+	}
+	return ReadNewNode(node)
+}
 
 // Finish Chain function call end with this function
 // return Kubernetes resource object Node and error.
@@ -82,21 +132,321 @@ func (obj *Node) SetAnnotations(annotations map[string]string) *Node {
 	return obj
 }
 
-// SetTaints set Taint
+// SetTaints set Taint, the taint which has the same key and effect will be replaced
 func (obj *Node) SetTaints(key, value string, effect TaintEffect) *Node {
 	taint := v1.Taint{
 		Key:    key,
 		Value:  value,
 		Effect: effect.ToK8s(),
 	}
-	if len(obj.node.Spec.Taints) <= 0 {
-		obj.node.Spec.Taints = []v1.Taint{taint}
+	if taint.Effect == "" {
+		obj.error(fmt.Errorf("SetTaints err,effect %s is not supported", effect))
 		return obj
 	}
+	for index, present := range obj.node.Spec.Taints {
+		if present.Key == key && present.Effect == taint.Effect {
+			obj.node.Spec.Taints[index] = taint
+			return obj
+		}
+	}
 	obj.node.Spec.Taints = append(obj.node.Spec.Taints, taint)
 	return obj
 }
 
+// RemoveTaint remove the taint which has the key and effect,
+// the taints of all effects which have the key are removed when effect is ""
+func (obj *Node) RemoveTaint(key string, effect TaintEffect) *Node {
+	if effect != "" && effect.ToK8s() == "" {
+		obj.error(fmt.Errorf("RemoveTaint err,effect %s is not supported", effect))
+		return obj
+	}
+	var taints []v1.Taint
+	for _, taint := range obj.node.Spec.Taints {
+		if taint.Key == key && (effect == "" || taint.Effect == effect.ToK8s()) {
+			continue
+		}
+		taints = append(taints, taint)
+	}
+	obj.node.Spec.Taints = taints
+	return obj
+}
+
+// Apply patch the changes of labels, annotations, taints and unschedulable on Kubernetes,
+// the changes are applied on the latest Node, so the changes by others are kept
+func (obj *Node) Apply() (*v1.Node, error) {
+	node, err := obj.Finish()
+	if err != nil {
+		return nil, err
+	}
+	client, err := GetKubeClient()
+	if err != nil {
+		return nil, err
+	}
+	var result *v1.Node
+	err = retry.RetryOnConflict(retry.DefaultRetry, func() error {
+		current, err := client.CoreV1().Nodes().Get(node.GetName(), metav1.GetOptions{})
+		if err != nil {
+			return err
+		}
+		patch, err := nodePatch(current, obj.origin, node)
+		if err != nil || patch == nil {
+			result = current
+			return err
+		}
+		result, err = client.CoreV1().Nodes().Patch(node.GetName(), types.StrategicMergePatchType, patch)
+		return err
+	})
+	if err != nil {
+		return nil, err
+	}
+	obj.node, obj.origin = result, result.DeepCopy()
+	return result, nil
+}
+
+// nodePatch apply the changes from origin to desired on current Node and return the strategic merge patch,
+// the patch includes resourceVersion, so it is failed with conflict when Node is changed by others.
+// return nil when there is no change
+func nodePatch(current, origin, desired *v1.Node) ([]byte, error) {
+	modified := current.DeepCopy()
+	modified.Labels = mergeChanges(modified.Labels, origin.Labels, desired.Labels)
+	modified.Annotations = mergeChanges(modified.Annotations, origin.Annotations, desired.Annotations)
+	if origin.Spec.Unschedulable != desired.Spec.Unschedulable {
+		modified.Spec.Unschedulable = desired.Spec.Unschedulable
+	}
+	for _, taint := range origin.Spec.Taints {
+		if !hasTaint(desired.Spec.Taints, taint) {
+			modified.Spec.Taints = removeTaint(modified.Spec.Taints, taint)
+		}
+	}
+	for _, taint := range desired.Spec.Taints {
+		if !hasTaint(origin.Spec.Taints, taint) {
+			modified.Spec.Taints = append(removeTaint(modified.Spec.Taints, taint), taint)
+		}
+	}
+	currentJSON, err := json.Marshal(current)
+	if err != nil {
+		return nil, err
+	}
+	modifiedJSON, err := json.Marshal(modified)
+	if err != nil {
+		return nil, err
+	}
+	patch, err := strategicpatch.CreateTwoWayMergePatch(currentJSON, modifiedJSON, v1.Node{})
+	if err != nil || string(patch) == "{}" {
+		return nil, err
+	}
+	patchMap := make(map[string]interface{})
+	if err = json.Unmarshal(patch, &patchMap); err != nil {
+		return nil, err
+	}
+	metadata, _ := patchMap["metadata"].(map[string]interface{})
+	if metadata == nil {
+		metadata = make(map[string]interface{})
+		patchMap["metadata"] = metadata
+	}
+	metadata["resourceVersion"] = current.GetResourceVersion()
+	return json.Marshal(patchMap)
+}
+
+// mergeChanges apply the changes from origin to desired on current map
+func mergeChanges(current, origin, desired map[string]string) map[string]string {
+	if current == nil {
+		current = make(map[string]string)
+	}
+	for key := range origin {
+		if _, ok := desired[key]; !ok {
+			delete(current, key)
+		}
+	}
+	for key, value := range desired {
+		if present, ok := origin[key]; !ok || present != value {
+			current[key] = value
+		}
+	}
+	return current
+}
+
+// hasTaint check taints include the same taint, TimeAdded is ignored
+func hasTaint(taints []v1.Taint, taint v1.Taint) bool {
+	for _, present := range taints {
+		if present.Key == taint.Key && present.Effect == taint.Effect && present.Value == taint.Value {
+			return true
+		}
+	}
+	return false
+}
+
+// removeTaint remove the taint which has the same key and effect
+func removeTaint(taints []v1.Taint, taint v1.Taint) []v1.Taint {
+	var result []v1.Taint
+	for _, present := range taints {
+		if present.Key == taint.Key && present.Effect == taint.Effect {
+			continue
+		}
+		result = append(result, present)
+	}
+	return result
+}
+
+// Cordon mark Node as unschedulable, the running Pods are not affected
+func (obj *Node) Cordon() (*v1.Node, error) {
+	obj.node.Spec.Unschedulable = true
+	return obj.Apply()
+}
+
+// Uncordon mark Node as schedulable
+func (obj *Node) Uncordon() (*v1.Node, error) {
+	obj.node.Spec.Unschedulable = false
+	return obj.Apply()
+}
+
+// Drain cordon Node and evict its Pods through eviction API, then wait until the Pods are deleted,
+// PodDisruptionBudgets are respected, the eviction is retried until PodDisruptionBudget allows it or timeout,
+// transient API errors are retried too and the last one is reported on timeout.
+// DaemonSet Pods and static Pods are skipped. Pods using emptyDir and the Pods without controller
+// are refused unless opts.DeleteEmptyDirData and opts.Force are set.
+// ctx can cancel the draining, opts.Timeout is applied on ctx.
+func (obj *Node) Drain(ctx context.Context, opts DrainOptions) error {
+	node, err := obj.Cordon()
+	if err != nil {
+		return err
+	}
+	client, err := GetKubeClient()
+	if err != nil {
+		return err
+	}
+	if opts.Timeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, opts.Timeout)
+		defer cancel()
+	}
+	podList, err := client.CoreV1().Pods(metav1.NamespaceAll).List(metav1.ListOptions{
+		FieldSelector: fields.OneTermEqualSelector("spec.nodeName", node.GetName()).String(),
+		LabelSelector: opts.PodSelector,
+	})
+	if err != nil {
+		return err
+	}
+	pods, err := drainPods(podList.Items, opts)
+	if err != nil {
+		return fmt.Errorf("Drain Node %s err,%v", node.GetName(), err)
+	}
+	pending := make(map[string]v1.Pod, len(pods))
+	for _, pod := range pods {
+		pending[pod.Namespace+"/"+pod.Name] = pod
+	}
+	evicted := make(map[string]bool, len(pods))
+	blocked := make(map[string]string)
+	var pollErr error
+	err = wait.PollImmediateUntil(drainPollInterval, func() (bool, error) {
+		for key, pod := range pending {
+			if !evicted[key] {
+				reason, err := evictPod(client, pod, opts.GracePeriodSeconds)
+				if err != nil {
+					return false, err
+				}
+				if verifyString(reason) {
+					blocked[key] = reason
+					continue
+				}
+				evicted[key] = true
+				delete(blocked, key)
+			}
+			present, err := client.CoreV1().Pods(pod.Namespace).Get(pod.Name, metav1.GetOptions{})
+			if apierrors.IsNotFound(err) || (err == nil && present.UID != pod.UID) {
+				delete(pending, key)
+				continue
+			}
+			// other errors may be transient, the Pod is read again in next poll
+			if err != nil {
+				pollErr = err
+			}
+		}
+		return len(pending) <= 0, nil
+	}, ctx.Done())
+	if err != wait.ErrWaitTimeout {
+		return err
+	}
+	var remaining []string
+	for key := range pending {
+		if reason, ok := blocked[key]; ok {
+			remaining = append(remaining, fmt.Sprintf("%s(%s)", key, reason))
+			continue
+		}
+		remaining = append(remaining, key+"(terminating)")
+	}
+	if pollErr != nil {
+		return fmt.Errorf("Drain Node %s is not completed,%v,remaining Pods:%s,last error:%v",
+			node.GetName(), ctx.Err(), strings.Join(remaining, ","), pollErr)
+	}
+	return fmt.Errorf("Drain Node %s is not completed,%v,remaining Pods:%s", node.GetName(), ctx.Err(), strings.Join(remaining, ","))
+}
+
+// drainPods filter the Pods which should be evicted,
+// return error which includes all refused Pods when there are Pods refused by opts
+func drainPods(pods []v1.Pod, opts DrainOptions) ([]v1.Pod, error) {
+	var (
+		result  []v1.Pod
+		refused []string
+	)
+	for _, pod := range pods {
+		if _, ok := pod.Annotations[mirrorPodKey]; ok {
+			continue
+		}
+		controller := metav1.GetControllerOf(&pod)
+		if controller != nil && controller.Kind == "DaemonSet" {
+			continue
+		}
+		// the finished Pods are evicted without checking, they are not running
+		if pod.Status.Phase != v1.PodSucceeded && pod.Status.Phase != v1.PodFailed {
+			if controller == nil && !opts.Force {
+				refused = append(refused, fmt.Sprintf("%s/%s(no controller,set Force)", pod.Namespace, pod.Name))
+				continue
+			}
+			if usesEmptyDir(pod) && !opts.DeleteEmptyDirData {
+				refused = append(refused, fmt.Sprintf("%s/%s(emptyDir,set DeleteEmptyDirData)", pod.Namespace, pod.Name))
+				continue
+			}
+		}
+		result = append(result, pod)
+	}
+	if len(refused) > 0 {
+		return nil, fmt.Errorf("Pods are refused to be evicted:%s", strings.Join(refused, ","))
+	}
+	return result, nil
+}
+
+func usesEmptyDir(pod v1.Pod) bool {
+	for _, volume := range pod.Spec.Volumes {
+		if volume.EmptyDir != nil {
+			return true
+		}
+	}
+	return false
+}
+
+// evictPod evict Pod through eviction API, return the reason when eviction is refused by PodDisruptionBudget
+// or failed with transient error, so it is retried, return error only when eviction is rejected
+func evictPod(client *kubernetes.Clientset, pod v1.Pod, gracePeriodSeconds *int64) (string, error) {
+	eviction := &policyv1beta1.Eviction{
+		ObjectMeta:    metav1.ObjectMeta{Namespace: pod.Namespace, Name: pod.Name},
+		DeleteOptions: &metav1.DeleteOptions{GracePeriodSeconds: gracePeriodSeconds},
+	}
+	err := client.PolicyV1beta1().Evictions(pod.Namespace).Evict(eviction)
+	switch {
+	case err == nil, apierrors.IsNotFound(err):
+		return "", nil
+	case apierrors.IsTooManyRequests(err):
+		return "blocked by PodDisruptionBudget:" + err.Error(), nil
+	case apierrors.IsForbidden(err), apierrors.IsUnauthorized(err), apierrors.IsBadRequest(err),
+		apierrors.IsInvalid(err), apierrors.IsMethodNotSupported(err):
+		// eviction is rejected, retrying can't fix it
+		return "", fmt.Errorf("evict Pod %s/%s err:%v", pod.Namespace, pod.Name, err)
+	}
+	// other errors may be transient, the eviction is retried in next poll
+	return "evict err:" + err.Error(), nil
+}
+
 func (obj *Node) error(err error) {
 	if obj.err != nil {
 		return
@@ -108,4 +458,13 @@ func (obj *Node) verify() {
 	if obj.err != nil {
 		return
 	}
+	if !verifyString(obj.node.GetName()) {
+		obj.err = errors.New("Node name is not allowed to be empty")
+		return
+	}
+	if obj.origin == nil {
+		obj.origin = &v1.Node{}
+	}
+	obj.node.APIVersion = "v1"
+	obj.node.Kind = "Node"
 }
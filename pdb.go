@@ -0,0 +1,319 @@
+package beku
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/ghodss/yaml"
+	appsv1 "k8s.io/api/apps/v1"
+	"k8s.io/api/policy/v1beta1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/util/intstr"
+)
+
+// PDB include Kubernetes resource object PodDisruptionBudget and error
+type PDB struct {
+	pdb *v1beta1.PodDisruptionBudget
+	// replicas of the workload which is protected by PDB, it is used to check whether PDB blocks all evictions
+	replicas *int32
+	warnings []string
+	err      error
+}
+
+// NewPDB create PodDisruptionBudget(PDB) and chain function call begin with this function.
+func NewPDB() *PDB { return &PDB{pdb: &v1beta1.PodDisruptionBudget{}} }
+
+// Finish Chain function call end with this function
+// return Kubernetes resource object PodDisruptionBudget and error.
+// In the function, it will check necessary parameters,input the default field,
+// the problems which don't stop releasing can be got by Warnings()
+func (obj *PDB) Finish() (*v1beta1.PodDisruptionBudget, error) {
+	obj.verify()
+	return obj.pdb, obj.err
+}
+
+// Warnings return the warnings found by Finish(),eg: PDB blocks all evictions
+func (obj *PDB) Warnings() []string { return obj.warnings }
+
+// JSONNew use json data create PDB
+func (obj *PDB) JSONNew(jsonbyts []byte) *PDB {
+	obj.error(json.Unmarshal(jsonbyts, obj.pdb))
+	return obj
+}
+
+// YAMLNew use yaml data create PDB
+func (obj *PDB) YAMLNew(yamlbyts []byte) *PDB {
+	obj.error(yaml.Unmarshal(yamlbyts, obj.pdb))
+	return obj
+}
+
+// JSONNewTemplate use json template and values create PDB,
+// the template is rendered by RenderTemplate() and must be valid json
+func (obj *PDB) JSONNewTemplate(tpl []byte, values interface{}) *PDB {
+	jsonbyts, err := renderJSONTemplate(tpl, values)
+	if err != nil {
+		obj.error(err)
+		return obj
+	}
+	return obj.JSONNew(jsonbyts)
+}
+
+// YAMLNewTemplate use yaml template and values create PDB,
+// the template is rendered by RenderTemplate() and must be valid yaml
+func (obj *PDB) YAMLNewTemplate(tpl []byte, values interface{}) *PDB {
+	yamlbyts, err := renderYAMLTemplate(tpl, values)
+	if err != nil {
+		obj.error(err)
+		return obj
+	}
+	return obj.YAMLNew(yamlbyts)
+}
+
+// Replace replace PDB by Kubernetes resource object
+func (obj *PDB) Replace(pdb *v1beta1.PodDisruptionBudget) *PDB {
+	if pdb != nil {
+		obj.pdb = pdb
+	}
+	return obj
+}
+
+// SetName set PDB name
+func (obj *PDB) SetName(name string) *PDB {
+	obj.pdb.SetName(name)
+	return obj
+}
+
+// SetNamespace set PDB namespace, it must be the same as the namespace of Pods
+func (obj *PDB) SetNamespace(namespace string) *PDB {
+	obj.pdb.SetNamespace(namespace)
+	return obj
+}
+
+// SetNamespaceAndName set PDB namespace and name
+func (obj *PDB) SetNamespaceAndName(namespace, name string) *PDB {
+	obj.SetNamespace(namespace)
+	obj.SetName(name)
+	return obj
+}
+
+// SetLabels set PDB labels
+func (obj *PDB) SetLabels(labels map[string]string) *PDB {
+	obj.pdb.SetLabels(labels)
+	return obj
+}
+
+// SetAnnotations set PDB annotations
+func (obj *PDB) SetAnnotations(annotations map[string]string) *PDB {
+	if len(obj.pdb.Annotations) <= 0 {
+		obj.pdb.Annotations = annotations
+		return obj
+	}
+	for key, value := range annotations {
+		obj.pdb.Annotations[key] = value
+	}
+	return obj
+}
+
+// SetSelector set label selector of Pods which are protected by PDB
+func (obj *PDB) SetSelector(labels map[string]string) *PDB {
+	if len(labels) <= 0 {
+		obj.error(errors.New("SetSelector err,label is not allowed to be empty"))
+		return obj
+	}
+	obj.pdb.Spec.Selector = &metav1.LabelSelector{MatchLabels: labels}
+	return obj
+}
+
+// SetReplicas set replicas of the workload which is protected by PDB,
+// it is only used to check whether PDB blocks all evictions and is not released
+func (obj *PDB) SetReplicas(replicas int32) *PDB {
+	obj.replicas = &replicas
+	return obj
+}
+
+// SetDeployment protect Pods of Deployment,it use selector and replicas of Deployment,
+// PDB name and namespace are the same as Deployment when they are empty
+func (obj *PDB) SetDeployment(dp *appsv1.Deployment) *PDB {
+	if dp == nil {
+		obj.error(errors.New("SetDeployment err,Deployment is not allowed to be empty"))
+		return obj
+	}
+	obj.setWorkload("Deployment", dp.ObjectMeta, dp.Spec.Selector, dp.Spec.Replicas)
+	return obj
+}
+
+// SetStatefulSet protect Pods of StatefulSet,it use selector and replicas of StatefulSet,
+// PDB name and namespace are the same as StatefulSet when they are empty
+func (obj *PDB) SetStatefulSet(sts *appsv1.StatefulSet) *PDB {
+	if sts == nil {
+		obj.error(errors.New("SetStatefulSet err,StatefulSet is not allowed to be empty"))
+		return obj
+	}
+	obj.setWorkload("StatefulSet", sts.ObjectMeta, sts.Spec.Selector, sts.Spec.Replicas)
+	return obj
+}
+
+func (obj *PDB) setWorkload(kind string, meta metav1.ObjectMeta, selector *metav1.LabelSelector, replicas *int32) {
+	if selector == nil {
+		obj.error(fmt.Errorf("%s %s selector is not allowed to be empty", kind, meta.GetName()))
+		return
+	}
+	obj.pdb.Spec.Selector = selector.DeepCopy()
+	if !verifyString(obj.pdb.GetName()) {
+		obj.pdb.SetName(meta.GetName())
+	}
+	if !verifyString(obj.pdb.GetNamespace()) {
+		obj.pdb.SetNamespace(meta.GetNamespace())
+	}
+	// Kubernetes default replicas is 1
+	obj.SetReplicas(1)
+	if replicas != nil {
+		obj.SetReplicas(*replicas)
+	}
+}
+
+// SetMinAvailable set the number or percentage of Pods which must be available after eviction,
+// eg: FromInt(2),Parse("50%"), it is not allowed to set with SetMaxUnavailable() at the same time
+func (obj *PDB) SetMinAvailable(val intstr.IntOrString) *PDB {
+	if err := verifyIntOrPercent(val); err != nil {
+		obj.error(fmt.Errorf("SetMinAvailable err,%s", err.Error()))
+		return obj
+	}
+	obj.pdb.Spec.MinAvailable = &val
+	return obj
+}
+
+// SetMaxUnavailable set the number or percentage of Pods which can be unavailable after eviction,
+// eg: FromInt(1),Parse("25%"), it is not allowed to set with SetMinAvailable() at the same time
+func (obj *PDB) SetMaxUnavailable(val intstr.IntOrString) *PDB {
+	if err := verifyIntOrPercent(val); err != nil {
+		obj.error(fmt.Errorf("SetMaxUnavailable err,%s", err.Error()))
+		return obj
+	}
+	obj.pdb.Spec.MaxUnavailable = &val
+	return obj
+}
+
+// verifyIntOrPercent check val is non-negative integer or percentage between 0% and 100%
+func verifyIntOrPercent(val intstr.IntOrString) error {
+	if val.Type == intstr.Int {
+		if val.IntVal < 0 {
+			return fmt.Errorf("%d is not allowed to be less than 0", val.IntVal)
+		}
+		return nil
+	}
+	if !strings.HasSuffix(val.StrVal, "%") {
+		return fmt.Errorf("%s must be integer or percentage,eg: 1,50%%", val.StrVal)
+	}
+	percent, err := strconv.Atoi(strings.TrimSuffix(val.StrVal, "%"))
+	if err != nil || percent < 0 || percent > 100 {
+		return fmt.Errorf("%s must be percentage between 0%% and 100%%", val.StrVal)
+	}
+	return nil
+}
+
+// Release release PDB on Kubernetes
+func (obj *PDB) Release() (*v1beta1.PodDisruptionBudget, error) {
+	pdb, err := obj.Finish()
+	if err != nil {
+		return nil, err
+	}
+	client, err := GetKubeClient()
+	if err != nil {
+		return nil, err
+	}
+	return client.PolicyV1beta1().PodDisruptionBudgets(pdb.GetNamespace()).Create(pdb)
+}
+
+// Apply it will be updated when this resource object exists in K8s,
+// it will be created when it does not exist.
+func (obj *PDB) Apply() (*v1beta1.PodDisruptionBudget, error) {
+	pdb, err := obj.Finish()
+	if err != nil {
+		return nil, err
+	}
+	client, err := GetKubeClient()
+	if err != nil {
+		return nil, err
+	}
+	_, err = client.PolicyV1beta1().PodDisruptionBudgets(pdb.GetNamespace()).Get(pdb.GetName(), metav1.GetOptions{})
+	if err != nil {
+		return client.PolicyV1beta1().PodDisruptionBudgets(pdb.GetNamespace()).Create(pdb)
+	}
+	return client.PolicyV1beta1().PodDisruptionBudgets(pdb.GetNamespace()).Update(pdb)
+}
+
+// Delete delete PDB on Kubernetes
+func (obj *PDB) Delete() error {
+	pdb, err := obj.Finish()
+	if err != nil {
+		return err
+	}
+	client, err := GetKubeClient()
+	if err != nil {
+		return err
+	}
+	return client.PolicyV1beta1().PodDisruptionBudgets(pdb.GetNamespace()).Delete(pdb.GetName(), &metav1.DeleteOptions{})
+}
+
+func (obj *PDB) error(err error) {
+	if obj.err != nil {
+		return
+	}
+	obj.err = err
+}
+
+// blockWarning return warning when PDB does not allow any Pod to be evicted with the replicas,
+// the number of percentage is rounded up as Kubernetes disruption controller does
+func (obj *PDB) blockWarning() string {
+	if obj.replicas == nil || *obj.replicas <= 0 {
+		return ""
+	}
+	replicas := int(*obj.replicas)
+	if obj.pdb.Spec.MinAvailable != nil {
+		minAvailable, err := intstr.GetValueFromIntOrPercent(obj.pdb.Spec.MinAvailable, replicas, true)
+		if err == nil && minAvailable >= replicas {
+			return fmt.Sprintf("PDB %s minAvailable %s is not less than replicas %d,it blocks all evictions and node drain will hang",
+				obj.pdb.GetName(), obj.pdb.Spec.MinAvailable.String(), replicas)
+		}
+		return ""
+	}
+	maxUnavailable, err := intstr.GetValueFromIntOrPercent(obj.pdb.Spec.MaxUnavailable, replicas, true)
+	if err == nil && maxUnavailable <= 0 {
+		return fmt.Sprintf("PDB %s maxUnavailable %s allows no Pod of replicas %d to be unavailable,it blocks all evictions and node drain will hang",
+			obj.pdb.GetName(), obj.pdb.Spec.MaxUnavailable.String(), replicas)
+	}
+	return ""
+}
+
+// verify check PDB necessary value, input the default field and input related data.
+func (obj *PDB) verify() {
+	obj.warnings = nil
+	if obj.err != nil {
+		return
+	}
+	if !verifyString(obj.pdb.GetName()) {
+		obj.err = errors.New("PDB name is not allowed to be empty")
+		return
+	}
+	if obj.pdb.Spec.Selector == nil {
+		obj.err = errors.New("PDB selector is not allowed to be empty,you can call SetSelector() or SetDeployment() input")
+		return
+	}
+	if obj.pdb.Spec.MinAvailable == nil && obj.pdb.Spec.MaxUnavailable == nil {
+		obj.err = errors.New("PDB minAvailable and maxUnavailable are not allowed to be empty at the same time,you can call SetMinAvailable() or SetMaxUnavailable() input")
+		return
+	}
+	if obj.pdb.Spec.MinAvailable != nil && obj.pdb.Spec.MaxUnavailable != nil {
+		obj.err = errors.New("PDB minAvailable and maxUnavailable are not allowed to be set at the same time")
+		return
+	}
+	if warning := obj.blockWarning(); warning != "" {
+		obj.warnings = append(obj.warnings, warning)
+	}
+	obj.pdb.Kind = "PodDisruptionBudget"
+	obj.pdb.APIVersion = "policy/v1beta1"
+}
@@ -4,11 +4,14 @@ import (
 	"encoding/json"
 	"errors"
 	"fmt"
+	"reflect"
+	"strings"
 
 	"github.com/ghodss/yaml"
 	"github.com/yulibaozi/mapper"
 	"k8s.io/api/core/v1"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/util/validation"
 )
 
 // PersistentVolume include Kubernetes resource object PersistentVolume(pv) and error.
@@ -40,6 +43,28 @@ func (obj *PersistentVolume) YAMLNew(yamlbyts []byte) *PersistentVolume {
 	return obj
 }
 
+// JSONNewTemplate use json template and values create PersistentVolume,
+// the template is rendered by RenderTemplate() and must be valid json
+func (obj *PersistentVolume) JSONNewTemplate(tpl []byte, values interface{}) *PersistentVolume {
+	jsonbyts, err := renderJSONTemplate(tpl, values)
+	if err != nil {
+		obj.error(err)
+		return obj
+	}
+	return obj.JSONNew(jsonbyts)
+}
+
+// YAMLNewTemplate use yaml template and values create PersistentVolume,
+// the template is rendered by RenderTemplate() and must be valid yaml
+func (obj *PersistentVolume) YAMLNewTemplate(tpl []byte, values interface{}) *PersistentVolume {
+	yamlbyts, err := renderYAMLTemplate(tpl, values)
+	if err != nil {
+		obj.error(err)
+		return obj
+	}
+	return obj.YAMLNew(yamlbyts)
+}
+
 // Replace replace PersistentVolume by Kubernetes resource object
 func (obj *PersistentVolume) Replace(pv *v1.PersistentVolume) *PersistentVolume {
 	if pv != nil {
@@ -138,8 +163,8 @@ func (obj *PersistentVolume) SetCephFS(cephFs *CephFSPersistentVolumeSource) *Pe
 	}
 	if cephFs.SecretRef != nil {
 		ceph.SecretRef = &v1.SecretReference{
-			Name:      ceph.SecretRef.Name,
-			Namespace: ceph.SecretRef.Namespace,
+			Name:      cephFs.SecretRef.Name,
+			Namespace: cephFs.SecretRef.Namespace,
 		}
 	}
 	obj.pv.Spec.PersistentVolumeSource.CephFS = ceph
@@ -185,6 +210,222 @@ func (obj *PersistentVolume) SetRBD(rbd *RBDPersistentVolumeSource) *PersistentV
 	return obj
 }
 
+// SetHostPath set PersistentVolume(pv) volume source is hostPath,
+// it only works in single node cluster and is used for development and testing
+func (obj *PersistentVolume) SetHostPath(path string, hostPathType ...HostPathType) *PersistentVolume {
+	if !verifyString(path) {
+		obj.error(errors.New("SetHostPath err,path is not allowed to be empty"))
+		return obj
+	}
+	hostPath := &v1.HostPathVolumeSource{Path: path}
+	if len(hostPathType) > 0 {
+		k8sType, ok := hostPathType[0].ToK8s()
+		if !ok {
+			obj.error(fmt.Errorf("SetHostPath err,hostPathType %s is not supported", hostPathType[0]))
+			return obj
+		}
+		hostPath.Type = &k8sType
+	}
+	obj.pv.Spec.PersistentVolumeSource.HostPath = hostPath
+	return obj
+}
+
+// SetLocal set PersistentVolume(pv) volume source is local disk, partition or directory of node,
+// path is the full path on node, fsType[0] is filesystem type when path is a block device.
+// local PersistentVolume must be bound to node, you can call SetRequiredORNodeAffinity() or SetNodes() input
+func (obj *PersistentVolume) SetLocal(path string, fsType ...string) *PersistentVolume {
+	if !verifyString(path) {
+		obj.error(errors.New("SetLocal err,path is not allowed to be empty"))
+		return obj
+	}
+	local := &v1.LocalVolumeSource{Path: path}
+	if len(fsType) > 0 && verifyString(fsType[0]) {
+		local.FSType = &fsType[0]
+	}
+	obj.pv.Spec.PersistentVolumeSource.Local = local
+	return obj
+}
+
+// SetRequiredORNodeAffinity set PersistentVolume(pv) node affinity,
+// Pods which use the PersistentVolume are scheduled to the matched nodes, many key do OR operation.
+func (obj *PersistentVolume) SetRequiredORNodeAffinity(key string, value []string, operator NodeSelectorOperator) *PersistentVolume {
+	nsRequirement := v1.NodeSelectorRequirement{
+		Key:      key,
+		Operator: operator.ToK8s(),
+		Values:   value,
+	}
+	obj.pv.Spec.NodeAffinity = pvNodeAffinity(obj.pv.Spec.NodeAffinity)
+	obj.pv.Spec.NodeAffinity.Required = orNodeSelector(obj.pv.Spec.NodeAffinity.Required, nsRequirement)
+	return obj
+}
+
+// SetRequiredAndNodeAffinity set PersistentVolume(pv) node affinity,
+// Pods which use the PersistentVolume are scheduled to the matched nodes, many key do AND operation.
+func (obj *PersistentVolume) SetRequiredAndNodeAffinity(key string, value []string, operator NodeSelectorOperator) *PersistentVolume {
+	nsRequirement := v1.NodeSelectorRequirement{
+		Key:      key,
+		Operator: operator.ToK8s(),
+		Values:   value,
+	}
+	obj.pv.Spec.NodeAffinity = pvNodeAffinity(obj.pv.Spec.NodeAffinity)
+	obj.pv.Spec.NodeAffinity.Required = andNodeSelector(obj.pv.Spec.NodeAffinity.Required, nsRequirement)
+	return obj
+}
+
+// SetNodes bind PersistentVolume(pv) to nodes by hostname, it is usually used by local PersistentVolume
+func (obj *PersistentVolume) SetNodes(hostnames ...string) *PersistentVolume {
+	if len(hostnames) <= 0 {
+		obj.error(errors.New("SetNodes err,hostnames is not allowed to be empty"))
+		return obj
+	}
+	return obj.SetRequiredAndNodeAffinity(v1.LabelHostname, hostnames, NodeSelectorOpIn)
+}
+
+func pvNodeAffinity(nodeAffinity *v1.VolumeNodeAffinity) *v1.VolumeNodeAffinity {
+	if nodeAffinity == nil {
+		return &v1.VolumeNodeAffinity{}
+	}
+	return nodeAffinity
+}
+
+// SetISCSI set PersistentVolume(pv) volume source is iSCSI
+func (obj *PersistentVolume) SetISCSI(iscsi *ISCSIPersistentVolumeSource) *PersistentVolume {
+	if iscsi == nil {
+		obj.error(errors.New("SetISCSI err,iscsi is not allowed to be empty"))
+		return obj
+	}
+	if !verifyString(iscsi.TargetPortal) {
+		obj.error(errors.New("SetISCSI err,targetPortal is not allowed to be empty"))
+		return obj
+	}
+	if !verifyISCSIQualifiedName(iscsi.IQN) {
+		obj.error(fmt.Errorf("SetISCSI err,iqn %s must start with iqn.,eui. or naa.", iscsi.IQN))
+		return obj
+	}
+	if iscsi.Lun < 0 || iscsi.Lun > 255 {
+		obj.error(fmt.Errorf("SetISCSI err,lun %d is not between 0 and 255", iscsi.Lun))
+		return obj
+	}
+	if (iscsi.DiscoveryCHAPAuth || iscsi.SessionCHAPAuth) && (iscsi.SecretRef == nil || !verifyString(iscsi.SecretRef.Name)) {
+		obj.error(errors.New("SetISCSI err,secretRef is not allowed to be empty when CHAP authentication is enabled"))
+		return obj
+	}
+	source := &v1.ISCSIPersistentVolumeSource{
+		TargetPortal:      iscsi.TargetPortal,
+		IQN:               iscsi.IQN,
+		Lun:               iscsi.Lun,
+		ISCSIInterface:    iscsi.ISCSIInterface,
+		FSType:            iscsi.FSType,
+		ReadOnly:          iscsi.ReadOnly,
+		Portals:           iscsi.Portals,
+		DiscoveryCHAPAuth: iscsi.DiscoveryCHAPAuth,
+		SessionCHAPAuth:   iscsi.SessionCHAPAuth,
+		SecretRef:         iscsi.SecretRef.ToK8s(),
+	}
+	if verifyString(iscsi.InitiatorName) {
+		source.InitiatorName = &iscsi.InitiatorName
+	}
+	obj.pv.Spec.PersistentVolumeSource.ISCSI = source
+	return obj
+}
+
+// verifyISCSIQualifiedName check iSCSI qualified name format
+func verifyISCSIQualifiedName(iqn string) bool {
+	for _, prefix := range []string{"iqn.", "eui.", "naa."} {
+		if strings.HasPrefix(iqn, prefix) && len(iqn) > len(prefix) {
+			return true
+		}
+	}
+	return false
+}
+
+// SetGlusterFS set PersistentVolume(pv) volume source is GlusterFS
+// endpoints is the name of Endpoints which contains the GlusterFS servers,
+// endpointsNamespace[0] is the namespace of Endpoints, default is the namespace of PersistentVolumeClaim
+func (obj *PersistentVolume) SetGlusterFS(endpoints, path string, readOnly bool, endpointsNamespace ...string) *PersistentVolume {
+	if !verifyString(endpoints) {
+		obj.error(errors.New("SetGlusterFS err,endpoints is not allowed to be empty"))
+		return obj
+	}
+	if !verifyString(path) {
+		obj.error(errors.New("SetGlusterFS err,path is not allowed to be empty"))
+		return obj
+	}
+	gluster := &v1.GlusterfsPersistentVolumeSource{
+		EndpointsName: endpoints,
+		Path:          path,
+		ReadOnly:      readOnly,
+	}
+	if len(endpointsNamespace) > 0 && verifyString(endpointsNamespace[0]) {
+		gluster.EndpointsNamespace = &endpointsNamespace[0]
+	}
+	obj.pv.Spec.PersistentVolumeSource.Glusterfs = gluster
+	return obj
+}
+
+// SetFC set PersistentVolume(pv) volume source is Fibre Channel
+func (obj *PersistentVolume) SetFC(fc *FCVolumeSource) *PersistentVolume {
+	if fc == nil {
+		obj.error(errors.New("SetFC err,fc is not allowed to be empty"))
+		return obj
+	}
+	byWWN := len(fc.TargetWWNs) > 0 || fc.Lun != nil
+	if byWWN == (len(fc.WWIDs) > 0) {
+		obj.error(errors.New("SetFC err,one of targetWWNs with lun and wwids must be set"))
+		return obj
+	}
+	if byWWN && (len(fc.TargetWWNs) <= 0 || fc.Lun == nil) {
+		obj.error(errors.New("SetFC err,targetWWNs and lun must be set at the same time"))
+		return obj
+	}
+	obj.pv.Spec.PersistentVolumeSource.FC = &v1.FCVolumeSource{
+		TargetWWNs: fc.TargetWWNs,
+		Lun:        fc.Lun,
+		FSType:     fc.FSType,
+		ReadOnly:   fc.ReadOnly,
+		WWIDs:      fc.WWIDs,
+	}
+	return obj
+}
+
+// SetCSI set PersistentVolume(pv) volume source is CSI driver,
+// it is used to bind the volume which already exists in storage system
+func (obj *PersistentVolume) SetCSI(csi *CSIPersistentVolumeSource) *PersistentVolume {
+	if csi == nil {
+		obj.error(errors.New("SetCSI err,csi is not allowed to be empty"))
+		return obj
+	}
+	if errs := validation.IsDNS1123Subdomain(strings.ToLower(csi.Driver)); len(errs) > 0 || len(csi.Driver) > 63 {
+		obj.error(fmt.Errorf("SetCSI err,driver %s is invalid,it must be a domain name no more than 63 characters", csi.Driver))
+		return obj
+	}
+	if !verifyString(csi.VolumeHandle) {
+		obj.error(errors.New("SetCSI err,volumeHandle is not allowed to be empty"))
+		return obj
+	}
+	for name, ref := range map[string]*SecretReference{
+		"controllerPublishSecretRef": csi.ControllerPublishSecretRef,
+		"nodeStageSecretRef":         csi.NodeStageSecretRef,
+		"nodePublishSecretRef":       csi.NodePublishSecretRef,
+	} {
+		if ref != nil && (!verifyString(ref.Name) || !verifyString(ref.Namespace)) {
+			obj.error(fmt.Errorf("SetCSI err,name and namespace of %s are not allowed to be empty", name))
+			return obj
+		}
+	}
+	obj.pv.Spec.PersistentVolumeSource.CSI = &v1.CSIPersistentVolumeSource{
+		Driver:                     csi.Driver,
+		VolumeHandle:               csi.VolumeHandle,
+		ReadOnly:                   csi.ReadOnly,
+		FSType:                     csi.FSType,
+		VolumeAttributes:           csi.VolumeAttributes,
+		ControllerPublishSecretRef: csi.ControllerPublishSecretRef.ToK8s(),
+		NodeStageSecretRef:         csi.NodeStageSecretRef.ToK8s(),
+		NodePublishSecretRef:       csi.NodePublishSecretRef.ToK8s(),
+	}
+	return obj
+}
+
 // Release release PersistentVolume on Kubernetes
 func (obj *PersistentVolume) Release() (*v1.PersistentVolume, error) {
 	pv, err := obj.Finish()
@@ -216,6 +457,19 @@ func (obj *PersistentVolume) Apply() (*v1.PersistentVolume, error) {
 	return client.CoreV1().PersistentVolumes().Update(pv)
 }
 
+// Delete delete PersistentVolume on Kubernetes
+func (obj *PersistentVolume) Delete() error {
+	pv, err := obj.Finish()
+	if err != nil {
+		return err
+	}
+	client, err := GetKubeClient()
+	if err != nil {
+		return err
+	}
+	return client.CoreV1().PersistentVolumes().Delete(pv.GetName(), &metav1.DeleteOptions{})
+}
+
 func (obj *PersistentVolume) error(err error) {
 	if obj.err != nil {
 		return
@@ -245,6 +499,49 @@ func (obj *PersistentVolume) verify() {
 		obj.err = errors.New("PersistentVolume.Spec.PersistentVolumeSource is not allowed to be empty")
 		return
 	}
+	if sources := pvSources(obj.pv.Spec.PersistentVolumeSource); len(sources) > 1 {
+		obj.err = fmt.Errorf("PersistentVolume.Spec.PersistentVolumeSource only allows one source,but %v are set", sources)
+		return
+	}
+	if obj.pv.Spec.Local != nil && (obj.pv.Spec.NodeAffinity == nil || obj.pv.Spec.NodeAffinity.Required == nil ||
+		len(obj.pv.Spec.NodeAffinity.Required.NodeSelectorTerms) <= 0) {
+		obj.err = errors.New("PersistentVolume.Spec.NodeAffinity is not allowed to be empty when volume source is local,you can call SetNodes() input")
+		return
+	}
+	sources := pvSources(obj.pv.Spec.PersistentVolumeSource)
+	if modes, ok := pvSourceAccessModes[sources[0]]; ok {
+		for _, mode := range obj.pv.Spec.AccessModes {
+			if !modes[mode] {
+				obj.err = fmt.Errorf("PersistentVolume access mode %s is not supported by volume source %s", mode, sources[0])
+				return
+			}
+		}
+	}
 	obj.pv.Kind = "PersistentVolume"
 	obj.pv.APIVersion = "v1"
 }
+
+// pvSourceAccessModes the access modes which are supported by volume source,
+// the volume source which is not in it supports all access modes or depends on its driver
+var pvSourceAccessModes = map[string]map[v1.PersistentVolumeAccessMode]bool{
+	"HostPath": {v1.ReadWriteOnce: true},
+	"Local":    {v1.ReadWriteOnce: true},
+	"ISCSI":    {v1.ReadWriteOnce: true, v1.ReadOnlyMany: true},
+	"FC":       {v1.ReadWriteOnce: true, v1.ReadOnlyMany: true},
+	"RBD":      {v1.ReadWriteOnce: true, v1.ReadOnlyMany: true},
+}
+
+// pvBlockSources the volume sources which support raw block volume mode
+var pvBlockSources = map[string]bool{"Local": true, "ISCSI": true, "FC": true, "RBD": true, "CSI": true}
+
+// pvSources return the names of volume sources which are set
+func pvSources(source v1.PersistentVolumeSource) []string {
+	var names []string
+	value := reflect.ValueOf(source)
+	for i := 0; i < value.NumField(); i++ {
+		if !value.Field(i).IsNil() {
+			names = append(names, value.Type().Field(i).Name)
+		}
+	}
+	return names
+}
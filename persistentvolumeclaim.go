@@ -39,6 +39,28 @@ func (obj *PersistentVolumeClaim) YAMLNew(yamlbyts []byte) *PersistentVolumeClai
 	return obj
 }
 
+// JSONNewTemplate use json template and values create PersistentVolumeClaim,
+// the template is rendered by RenderTemplate() and must be valid json
+func (obj *PersistentVolumeClaim) JSONNewTemplate(tpl []byte, values interface{}) *PersistentVolumeClaim {
+	jsonbyts, err := renderJSONTemplate(tpl, values)
+	if err != nil {
+		obj.error(err)
+		return obj
+	}
+	return obj.JSONNew(jsonbyts)
+}
+
+// YAMLNewTemplate use yaml template and values create PersistentVolumeClaim,
+// the template is rendered by RenderTemplate() and must be valid yaml
+func (obj *PersistentVolumeClaim) YAMLNewTemplate(tpl []byte, values interface{}) *PersistentVolumeClaim {
+	yamlbyts, err := renderYAMLTemplate(tpl, values)
+	if err != nil {
+		obj.error(err)
+		return obj
+	}
+	return obj.YAMLNew(yamlbyts)
+}
+
 // Replace replace PersistentVolumeClaim by Kubernetes resource object
 func (obj *PersistentVolumeClaim) Replace(pvc *v1.PersistentVolumeClaim) *PersistentVolumeClaim {
 	if pvc != nil {
@@ -30,6 +30,36 @@ func (obj *Pod) YAMLNew(yamlbyts []byte) *Pod {
 	return obj
 }
 
+// JSONNewTemplate use json template and values create Pod,
+// the template is rendered by RenderTemplate() and must be valid json
+func (obj *Pod) JSONNewTemplate(tpl []byte, values interface{}) *Pod {
+	jsonbyts, err := renderJSONTemplate(tpl, values)
+	if err != nil {
+		obj.error(err)
+		return obj
+	}
+	return obj.JSONNew(jsonbyts)
+}
+
+// YAMLNewTemplate use yaml template and values create Pod,
+// the template is rendered by RenderTemplate() and must be valid yaml
+func (obj *Pod) YAMLNewTemplate(tpl []byte, values interface{}) *Pod {
+	yamlbyts, err := renderYAMLTemplate(tpl, values)
+	if err != nil {
+		obj.error(err)
+		return obj
+	}
+	return obj.YAMLNew(yamlbyts)
+}
+
+// Replace replace pod by Kubernetes resource object
+func (obj *Pod) Replace(pod *v1.Pod) *Pod {
+	if pod != nil {
+		obj.pod = pod
+	}
+	return obj
+}
+
 // Finish Chain function call end with this function
 // return Kubernetes resource object Pod and error.
 // In the function, it will check necessary parametersainput the default field
@@ -103,6 +133,114 @@ func (obj *Pod) SetContainer(name, image string, containerPort int32) *Pod {
 	return obj
 }
 
+// SetServiceAccount set Pod ServiceAccount
+// serviceAccountName is Kubernetes resource object ServiceAccount name, eg: UnionRBAC.GetName()
+// automount[0] set whether the ServiceAccount token should be automatically mounted,default use ServiceAccount setting.
+func (obj *Pod) SetServiceAccount(serviceAccountName string, automount ...bool) *Pod {
+	podTemp := &v1.PodTemplateSpec{Spec: obj.pod.Spec}
+	if err := setServiceAccount(podTemp, serviceAccountName, automount...); err != nil {
+		obj.error(err)
+		return obj
+	}
+	obj.pod.Spec = podTemp.Spec
+	return obj
+}
+
+// SetConfigMapVolume declare ConfigMap volume on Pod Pod, volumeName is used by SetVolumeMount(),
+// cm.Items is the keys which are projected, all keys are projected when it is empty
+func (obj *Pod) SetConfigMapVolume(volumeName string, cm *ConfigMapVolumeSource) *Pod {
+	podTemp := &v1.PodTemplateSpec{Spec: obj.pod.Spec}
+	if err := setConfigMapVolume(podTemp, volumeName, cm); err != nil {
+		obj.error(err)
+		return obj
+	}
+	obj.pod.Spec = podTemp.Spec
+	return obj
+}
+
+// SetSecretVolume declare Secret volume on Pod Pod, volumeName is used by SetVolumeMount(),
+// secret.Items is the keys which are projected, all keys are projected when it is empty
+func (obj *Pod) SetSecretVolume(volumeName string, secret *SecretVolumeSource) *Pod {
+	podTemp := &v1.PodTemplateSpec{Spec: obj.pod.Spec}
+	if err := setSecretVolume(podTemp, volumeName, secret); err != nil {
+		obj.error(err)
+		return obj
+	}
+	obj.pod.Spec = podTemp.Spec
+	return obj
+}
+
+// SetEmptyDirVolume declare EmptyDir volume on Pod Pod, it is deleted when Pod is deleted,
+// memory: use tmpfs, sizeLimit[0] is the max size of volume, eg: 1Gi
+func (obj *Pod) SetEmptyDirVolume(volumeName string, memory bool, sizeLimit ...string) *Pod {
+	podTemp := &v1.PodTemplateSpec{Spec: obj.pod.Spec}
+	if err := setEmptyDirVolume(podTemp, volumeName, memory, sizeLimit...); err != nil {
+		obj.error(err)
+		return obj
+	}
+	obj.pod.Spec = podTemp.Spec
+	return obj
+}
+
+// SetHostPathVolume declare HostPath volume on Pod Pod, path is the absolute path on node
+func (obj *Pod) SetHostPathVolume(volumeName, path string, hostPathType ...HostPathType) *Pod {
+	podTemp := &v1.PodTemplateSpec{Spec: obj.pod.Spec}
+	if err := setHostPathVolume(podTemp, volumeName, path, hostPathType...); err != nil {
+		obj.error(err)
+		return obj
+	}
+	obj.pod.Spec = podTemp.Spec
+	return obj
+}
+
+// SetDownwardAPIVolume declare DownwardAPI volume on Pod Pod, Pod fields and container resources are exposed as files
+func (obj *Pod) SetDownwardAPIVolume(volumeName string, files []DownwardAPIVolumeFile, defaultMode ...int32) *Pod {
+	podTemp := &v1.PodTemplateSpec{Spec: obj.pod.Spec}
+	if err := setDownwardAPIVolume(podTemp, volumeName, files, defaultMode...); err != nil {
+		obj.error(err)
+		return obj
+	}
+	obj.pod.Spec = podTemp.Spec
+	return obj
+}
+
+// SetProjectedVolume declare projected volume on Pod Pod, it projects ConfigMap, Secret,
+// DownwardAPI and ServiceAccount token into the same directory
+func (obj *Pod) SetProjectedVolume(volumeName string, sources []VolumeProjection, defaultMode ...int32) *Pod {
+	podTemp := &v1.PodTemplateSpec{Spec: obj.pod.Spec}
+	if err := setProjectedVolume(podTemp, volumeName, sources, defaultMode...); err != nil {
+		obj.error(err)
+		return obj
+	}
+	obj.pod.Spec = podTemp.Spec
+	return obj
+}
+
+// SetVolumeMount mount volume on Pod container, mount.Container is the container name, default is the first container,
+// the volume must be declared by SetPVClaim(), SetConfigMapVolume() and the other volume functions
+func (obj *Pod) SetVolumeMount(mount VolumeMount) *Pod {
+	podTemp := &v1.PodTemplateSpec{Spec: obj.pod.Spec}
+	if err := setVolumeMount(podTemp, mount); err != nil {
+		obj.error(err)
+		return obj
+	}
+	obj.pod.Spec = podTemp.Spec
+	return obj
+}
+
+// SetConfigMapFiles mount every key of ConfigMap as a read-only file in mountPath of Pod container,
+// container[0] is the container name, default is the first container.
+// eg: NewCM().SetName("nginx").FromDir("conf") and SetConfigMapFiles(cm, "/etc/nginx/conf.d")
+func (obj *Pod) SetConfigMapFiles(cm *ConfigMap, mountPath string, container ...string) *Pod {
+	podTemp := &v1.PodTemplateSpec{Spec: obj.pod.Spec}
+	if err := setConfigMapFiles(podTemp, cm, mountPath, container...); err != nil {
+		obj.error(err)
+		return obj
+	}
+	obj.pod.Spec = podTemp.Spec
+	return obj
+}
+
 func (obj *Pod) error(err error) {
 	if obj.err != nil {
 		return
@@ -116,6 +254,7 @@ func (obj *Pod) verify() {
 		return
 	}
 	obj.error(containerRepeated(obj.pod.Spec.Containers))
+	obj.error(verifyVolumeMounts(obj.pod.Spec))
 	obj.pod.Kind = "Pod"
 	obj.pod.APIVersion = "v1"
 }
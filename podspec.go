@@ -9,6 +9,7 @@ import (
 	"k8s.io/api/core/v1"
 	"k8s.io/apimachinery/pkg/api/resource"
 	"k8s.io/apimachinery/pkg/util/sets"
+	"k8s.io/apimachinery/pkg/util/validation"
 )
 
 func setTolerations(podTemp *v1.PodTemplateSpec, toleration v1.Toleration) {
@@ -132,58 +133,45 @@ func requirementKeyExist(keys []string, key string) bool {
 }
 
 func setRequiredORNodeAffinity(podTemp *v1.PodTemplateSpec, nsRequirement v1.NodeSelectorRequirement) (err error) {
-	term := v1.NodeSelectorTerm{MatchExpressions: []v1.NodeSelectorRequirement{nsRequirement}}
-	if podTemp.Spec.Affinity != nil {
-		if podTemp.Spec.Affinity.NodeAffinity != nil {
-			if podTemp.Spec.Affinity.NodeAffinity.RequiredDuringSchedulingIgnoredDuringExecution != nil {
-				if len(podTemp.Spec.Affinity.NodeAffinity.RequiredDuringSchedulingIgnoredDuringExecution.NodeSelectorTerms) > 0 {
-					podTemp.Spec.Affinity.NodeAffinity.RequiredDuringSchedulingIgnoredDuringExecution.NodeSelectorTerms =
-						append(podTemp.Spec.Affinity.NodeAffinity.RequiredDuringSchedulingIgnoredDuringExecution.NodeSelectorTerms, term)
-					return
-				}
-				podTemp.Spec.Affinity.NodeAffinity.RequiredDuringSchedulingIgnoredDuringExecution.NodeSelectorTerms = []v1.NodeSelectorTerm{term}
-				return
-			}
-			podTemp.Spec.Affinity.NodeAffinity.RequiredDuringSchedulingIgnoredDuringExecution = &v1.NodeSelector{NodeSelectorTerms: []v1.NodeSelectorTerm{term}}
-			return
-		}
-		podTemp.Spec.Affinity.NodeAffinity = &v1.NodeAffinity{
-			RequiredDuringSchedulingIgnoredDuringExecution: &v1.NodeSelector{NodeSelectorTerms: []v1.NodeSelectorTerm{term}},
-		}
-		return
-	}
-	podTemp.Spec.Affinity = &v1.Affinity{NodeAffinity: &v1.NodeAffinity{
-		RequiredDuringSchedulingIgnoredDuringExecution: &v1.NodeSelector{NodeSelectorTerms: []v1.NodeSelectorTerm{term}}}}
+	nodeAffinity := requiredNodeAffinity(podTemp)
+	nodeAffinity.RequiredDuringSchedulingIgnoredDuringExecution = orNodeSelector(nodeAffinity.RequiredDuringSchedulingIgnoredDuringExecution, nsRequirement)
 	return
 }
 
 func setRequiredAndNodeAffinity(podTemp *v1.PodTemplateSpec, nsRequirement v1.NodeSelectorRequirement) (err error) {
+	nodeAffinity := requiredNodeAffinity(podTemp)
+	nodeAffinity.RequiredDuringSchedulingIgnoredDuringExecution = andNodeSelector(nodeAffinity.RequiredDuringSchedulingIgnoredDuringExecution, nsRequirement)
+	return
+}
+
+// requiredNodeAffinity return NodeAffinity of Pod, create it when it does not exist
+func requiredNodeAffinity(podTemp *v1.PodTemplateSpec) *v1.NodeAffinity {
+	if podTemp.Spec.Affinity == nil {
+		podTemp.Spec.Affinity = &v1.Affinity{}
+	}
+	if podTemp.Spec.Affinity.NodeAffinity == nil {
+		podTemp.Spec.Affinity.NodeAffinity = &v1.NodeAffinity{}
+	}
+	return podTemp.Spec.Affinity.NodeAffinity
+}
+
+// orNodeSelector append requirement into NodeSelector as a new term, terms do OR operation
+func orNodeSelector(selector *v1.NodeSelector, nsRequirement v1.NodeSelectorRequirement) *v1.NodeSelector {
 	term := v1.NodeSelectorTerm{MatchExpressions: []v1.NodeSelectorRequirement{nsRequirement}}
-	if podTemp.Spec.Affinity != nil {
-		if podTemp.Spec.Affinity.NodeAffinity != nil {
-			if podTemp.Spec.Affinity.NodeAffinity.RequiredDuringSchedulingIgnoredDuringExecution != nil {
-				if len(podTemp.Spec.Affinity.NodeAffinity.RequiredDuringSchedulingIgnoredDuringExecution.NodeSelectorTerms) > 0 {
-					if len(podTemp.Spec.Affinity.NodeAffinity.RequiredDuringSchedulingIgnoredDuringExecution.NodeSelectorTerms[0].MatchExpressions) > 0 {
-						podTemp.Spec.Affinity.NodeAffinity.RequiredDuringSchedulingIgnoredDuringExecution.NodeSelectorTerms[0].MatchExpressions =
-							append(podTemp.Spec.Affinity.NodeAffinity.RequiredDuringSchedulingIgnoredDuringExecution.NodeSelectorTerms[0].MatchExpressions, nsRequirement)
-						return
-					}
-					podTemp.Spec.Affinity.NodeAffinity.RequiredDuringSchedulingIgnoredDuringExecution.NodeSelectorTerms[0].MatchExpressions =
-						[]v1.NodeSelectorRequirement{nsRequirement}
-					return
-				}
-				podTemp.Spec.Affinity.NodeAffinity.RequiredDuringSchedulingIgnoredDuringExecution.NodeSelectorTerms = []v1.NodeSelectorTerm{term}
-				return
-			}
-			podTemp.Spec.Affinity.NodeAffinity.RequiredDuringSchedulingIgnoredDuringExecution = &v1.NodeSelector{NodeSelectorTerms: []v1.NodeSelectorTerm{term}}
-			return
-		}
-		podTemp.Spec.Affinity.NodeAffinity = &v1.NodeAffinity{RequiredDuringSchedulingIgnoredDuringExecution: &v1.NodeSelector{NodeSelectorTerms: []v1.NodeSelectorTerm{term}}}
-		return
+	if selector == nil {
+		return &v1.NodeSelector{NodeSelectorTerms: []v1.NodeSelectorTerm{term}}
 	}
-	podTemp.Spec.Affinity = &v1.Affinity{NodeAffinity: &v1.NodeAffinity{
-		RequiredDuringSchedulingIgnoredDuringExecution: &v1.NodeSelector{NodeSelectorTerms: []v1.NodeSelectorTerm{term}}}}
-	return
+	selector.NodeSelectorTerms = append(selector.NodeSelectorTerms, term)
+	return selector
+}
+
+// andNodeSelector append requirement into the first term of NodeSelector, requirements of a term do AND operation
+func andNodeSelector(selector *v1.NodeSelector, nsRequirement v1.NodeSelectorRequirement) *v1.NodeSelector {
+	if selector == nil || len(selector.NodeSelectorTerms) <= 0 {
+		return orNodeSelector(selector, nsRequirement)
+	}
+	selector.NodeSelectorTerms[0].MatchExpressions = append(selector.NodeSelectorTerms[0].MatchExpressions, nsRequirement)
+	return selector
 }
 
 func setPreferredNodeAffinity(podTemp *v1.PodTemplateSpec, nsRequirement v1.NodeSelectorRequirement, weight int32) (err error) {
@@ -217,15 +205,20 @@ func setContainer(podTemp *v1.PodTemplateSpec, name, image string, containerPort
 	if containerPort <= 0 || containerPort >= 65536 {
 		return errors.New("SetContainer err, container Port range: 0 < containerPort < 65536")
 	}
+	return addContainer(podTemp, name, image, []v1.ContainerPort{{ContainerPort: containerPort}})
+}
+
+// addContainer add container, the container which has no image will be filled firstly
+// ports is allowed to be empty,eg: Job container
+func addContainer(podTemp *v1.PodTemplateSpec, name, image string, ports []v1.ContainerPort) error {
 	if !verifyString(image) {
 		return errors.New("SetContainer err, image is not allowed to be empty")
 
 	}
-	port := v1.ContainerPort{ContainerPort: containerPort}
 	container := v1.Container{
 		Name:  name,
 		Image: image,
-		Ports: []v1.ContainerPort{port},
+		Ports: ports,
 	}
 	containersLen := len(podTemp.Spec.Containers)
 	if containersLen < 1 {
@@ -237,7 +230,7 @@ func setContainer(podTemp *v1.PodTemplateSpec, name, image string, containerPort
 		if img == "" || len(img) <= 0 {
 			podTemp.Spec.Containers[index].Name = name
 			podTemp.Spec.Containers[index].Image = image
-			podTemp.Spec.Containers[index].Ports = []v1.ContainerPort{port}
+			podTemp.Spec.Containers[index].Ports = ports
 			return nil
 		}
 	}
@@ -245,6 +238,21 @@ func setContainer(podTemp *v1.PodTemplateSpec, name, image string, containerPort
 	return nil
 }
 
+// setCommand set command and args of the last container
+func setCommand(podTemp *v1.PodTemplateSpec, command []string, args ...string) error {
+	if len(command) <= 0 {
+		return errors.New("SetCommand err,command is not allowed to be empty")
+	}
+	containerLen := len(podTemp.Spec.Containers)
+	if containerLen < 1 {
+		podTemp.Spec.Containers = []v1.Container{{Command: command, Args: args}}
+		return nil
+	}
+	podTemp.Spec.Containers[containerLen-1].Command = command
+	podTemp.Spec.Containers[containerLen-1].Args = args
+	return nil
+}
+
 func setResourceLimit(podTemp *v1.PodTemplateSpec, limits map[ResourceName]string) error {
 	data, err := ResourceMapsToK8s(limits)
 	if err != nil {
@@ -375,6 +383,17 @@ func setPodPriorityClass(podTemp *v1.PodTemplateSpec, priorityClassName string)
 
 }
 
+func setServiceAccount(podTemp *v1.PodTemplateSpec, serviceAccountName string, automount ...bool) error {
+	if !verifyString(serviceAccountName) {
+		return errors.New("SetServiceAccount err,serviceAccountName is not allowed to be empty")
+	}
+	podTemp.Spec.ServiceAccountName = serviceAccountName
+	if len(automount) > 0 {
+		podTemp.Spec.AutomountServiceAccountToken = &automount[0]
+	}
+	return nil
+}
+
 func setEnvs(podTemp *v1.PodTemplateSpec, envMap map[string]string) error {
 	envs, err := mapToEnvs(envMap)
 	if err != nil {
@@ -428,6 +447,411 @@ func setPVClaim(podTemp *v1.PodTemplateSpec, volumeName, claimName string) error
 	return nil
 }
 
+// addVolume add volume into Pod template, volume name must be a DNS label and unique
+func addVolume(podTemp *v1.PodTemplateSpec, volume v1.Volume) error {
+	if errs := validation.IsDNS1123Label(volume.Name); len(errs) > 0 {
+		return fmt.Errorf("volume name '%s' is invalid:%s", volume.Name, strings.Join(errs, ","))
+	}
+	for _, present := range podTemp.Spec.Volumes {
+		if present.Name == volume.Name {
+			return fmt.Errorf("volume name '%s' is repeated", volume.Name)
+		}
+	}
+	podTemp.Spec.Volumes = append(podTemp.Spec.Volumes, volume)
+	return nil
+}
+
+func setConfigMapVolume(podTemp *v1.PodTemplateSpec, volumeName string, cm *ConfigMapVolumeSource) error {
+	if cm == nil || !verifyString(cm.Name) {
+		return errors.New("SetConfigMapVolume err,ConfigMap name is not allowed to be empty")
+	}
+	items, err := keyToPaths(cm.Items)
+	if err != nil {
+		return fmt.Errorf("SetConfigMapVolume err,%v", err)
+	}
+	if err = verifyFileMode(cm.DefaultMode); err != nil {
+		return fmt.Errorf("SetConfigMapVolume err,%v", err)
+	}
+	optional := cm.Optional
+	return addVolume(podTemp, v1.Volume{
+		Name: volumeName,
+		VolumeSource: v1.VolumeSource{
+			ConfigMap: &v1.ConfigMapVolumeSource{
+				LocalObjectReference: v1.LocalObjectReference{Name: cm.Name},
+				Items:                items,
+				DefaultMode:          cm.DefaultMode,
+				Optional:             &optional,
+			},
+		},
+	})
+}
+
+func setSecretVolume(podTemp *v1.PodTemplateSpec, volumeName string, secret *SecretVolumeSource) error {
+	if secret == nil || !verifyString(secret.SecretName) {
+		return errors.New("SetSecretVolume err,Secret name is not allowed to be empty")
+	}
+	items, err := keyToPaths(secret.Items)
+	if err != nil {
+		return fmt.Errorf("SetSecretVolume err,%v", err)
+	}
+	if err = verifyFileMode(secret.DefaultMode); err != nil {
+		return fmt.Errorf("SetSecretVolume err,%v", err)
+	}
+	optional := secret.Optional
+	return addVolume(podTemp, v1.Volume{
+		Name: volumeName,
+		VolumeSource: v1.VolumeSource{
+			Secret: &v1.SecretVolumeSource{
+				SecretName:  secret.SecretName,
+				Items:       items,
+				DefaultMode: secret.DefaultMode,
+				Optional:    &optional,
+			},
+		},
+	})
+}
+
+func setEmptyDirVolume(podTemp *v1.PodTemplateSpec, volumeName string, memory bool, sizeLimit ...string) error {
+	emptyDir := &v1.EmptyDirVolumeSource{}
+	if memory {
+		emptyDir.Medium = v1.StorageMediumMemory
+	}
+	if len(sizeLimit) > 0 && verifyString(sizeLimit[0]) {
+		quantity, err := resource.ParseQuantity(sizeLimit[0])
+		if err != nil {
+			return fmt.Errorf("SetEmptyDirVolume err,sizeLimit %s is invalid:%v", sizeLimit[0], err)
+		}
+		emptyDir.SizeLimit = &quantity
+	}
+	return addVolume(podTemp, v1.Volume{Name: volumeName, VolumeSource: v1.VolumeSource{EmptyDir: emptyDir}})
+}
+
+func setHostPathVolume(podTemp *v1.PodTemplateSpec, volumeName, path string, hostPathType ...HostPathType) error {
+	if !strings.HasPrefix(path, "/") {
+		return fmt.Errorf("SetHostPathVolume err,path '%s' must be absolute path", path)
+	}
+	hostPath := &v1.HostPathVolumeSource{Path: path}
+	if len(hostPathType) > 0 {
+		k8sType, ok := hostPathType[0].ToK8s()
+		if !ok {
+			return fmt.Errorf("SetHostPathVolume err,hostPathType %s is not supported", hostPathType[0])
+		}
+		hostPath.Type = &k8sType
+	}
+	return addVolume(podTemp, v1.Volume{Name: volumeName, VolumeSource: v1.VolumeSource{HostPath: hostPath}})
+}
+
+func setDownwardAPIVolume(podTemp *v1.PodTemplateSpec, volumeName string, files []DownwardAPIVolumeFile, defaultMode ...int32) error {
+	items, err := downwardAPIFiles(files)
+	if err != nil {
+		return fmt.Errorf("SetDownwardAPIVolume err,%v", err)
+	}
+	downwardAPI := &v1.DownwardAPIVolumeSource{Items: items}
+	if len(defaultMode) > 0 {
+		if err = verifyFileMode(&defaultMode[0]); err != nil {
+			return fmt.Errorf("SetDownwardAPIVolume err,%v", err)
+		}
+		downwardAPI.DefaultMode = &defaultMode[0]
+	}
+	return addVolume(podTemp, v1.Volume{Name: volumeName, VolumeSource: v1.VolumeSource{DownwardAPI: downwardAPI}})
+}
+
+func setProjectedVolume(podTemp *v1.PodTemplateSpec, volumeName string, sources []VolumeProjection, defaultMode ...int32) error {
+	if len(sources) <= 0 {
+		return errors.New("SetProjectedVolume err,sources is not allowed to be empty")
+	}
+	projected := &v1.ProjectedVolumeSource{}
+	for index, source := range sources {
+		projection, err := volumeProjection(source)
+		if err != nil {
+			return fmt.Errorf("SetProjectedVolume err,sources[%d] %v", index, err)
+		}
+		projected.Sources = append(projected.Sources, projection)
+	}
+	if len(defaultMode) > 0 {
+		if err := verifyFileMode(&defaultMode[0]); err != nil {
+			return fmt.Errorf("SetProjectedVolume err,%v", err)
+		}
+		projected.DefaultMode = &defaultMode[0]
+	}
+	return addVolume(podTemp, v1.Volume{Name: volumeName, VolumeSource: v1.VolumeSource{Projected: projected}})
+}
+
+// volumeProjection translate VolumeProjection into Kubernetes VolumeProjection, only one source is allowed
+func volumeProjection(source VolumeProjection) (v1.VolumeProjection, error) {
+	var projection v1.VolumeProjection
+	count := 0
+	optional := source.Optional
+	if verifyString(source.ConfigMap) {
+		count++
+		items, err := keyToPaths(source.Items)
+		if err != nil {
+			return projection, err
+		}
+		projection.ConfigMap = &v1.ConfigMapProjection{
+			LocalObjectReference: v1.LocalObjectReference{Name: source.ConfigMap},
+			Items:                items,
+			Optional:             &optional,
+		}
+	}
+	if verifyString(source.Secret) {
+		count++
+		items, err := keyToPaths(source.Items)
+		if err != nil {
+			return projection, err
+		}
+		projection.Secret = &v1.SecretProjection{
+			LocalObjectReference: v1.LocalObjectReference{Name: source.Secret},
+			Items:                items,
+			Optional:             &optional,
+		}
+	}
+	if len(source.DownwardAPI) > 0 {
+		count++
+		items, err := downwardAPIFiles(source.DownwardAPI)
+		if err != nil {
+			return projection, err
+		}
+		projection.DownwardAPI = &v1.DownwardAPIProjection{Items: items}
+	}
+	if token := source.ServiceAccountToken; token != nil {
+		count++
+		if err := verifyRelativePath(token.Path); err != nil {
+			return projection, err
+		}
+		if token.ExpirationSeconds != 0 && token.ExpirationSeconds < 600 {
+			return projection, fmt.Errorf("serviceAccountToken expirationSeconds %d is less than 600", token.ExpirationSeconds)
+		}
+		projection.ServiceAccountToken = &v1.ServiceAccountTokenProjection{Audience: token.Audience, Path: token.Path}
+		if token.ExpirationSeconds != 0 {
+			projection.ServiceAccountToken.ExpirationSeconds = &token.ExpirationSeconds
+		}
+	}
+	if count != 1 {
+		return projection, errors.New("only one of configMap,secret,downwardAPI and serviceAccountToken must be set")
+	}
+	return projection, nil
+}
+
+// keyToPaths translate KeyToPath into Kubernetes KeyToPath and check key,path and mode
+func keyToPaths(items []KeyToPath) ([]v1.KeyToPath, error) {
+	var paths []v1.KeyToPath
+	for _, item := range items {
+		if !verifyString(item.Key) {
+			return nil, errors.New("items key is not allowed to be empty")
+		}
+		if err := verifyRelativePath(item.Path); err != nil {
+			return nil, err
+		}
+		if err := verifyFileMode(item.Mode); err != nil {
+			return nil, err
+		}
+		paths = append(paths, v1.KeyToPath{Key: item.Key, Path: item.Path, Mode: item.Mode})
+	}
+	return paths, nil
+}
+
+// downwardAPIFiles translate DownwardAPIVolumeFile into Kubernetes DownwardAPIVolumeFile
+func downwardAPIFiles(files []DownwardAPIVolumeFile) ([]v1.DownwardAPIVolumeFile, error) {
+	if len(files) <= 0 {
+		return nil, errors.New("downwardAPI items is not allowed to be empty")
+	}
+	var items []v1.DownwardAPIVolumeFile
+	for _, file := range files {
+		if err := verifyRelativePath(file.Path); err != nil {
+			return nil, err
+		}
+		if err := verifyFileMode(file.Mode); err != nil {
+			return nil, err
+		}
+		item := v1.DownwardAPIVolumeFile{Path: file.Path, Mode: file.Mode}
+		switch {
+		case verifyString(file.FieldPath) && verifyString(file.Resource):
+			return nil, fmt.Errorf("downwardAPI file %s is not allowed to set both fieldPath and resource", file.Path)
+		case verifyString(file.FieldPath):
+			item.FieldRef = &v1.ObjectFieldSelector{FieldPath: file.FieldPath}
+		case verifyString(file.Resource):
+			item.ResourceFieldRef = &v1.ResourceFieldSelector{ContainerName: file.ContainerName, Resource: file.Resource}
+		default:
+			return nil, fmt.Errorf("downwardAPI file %s must set one of fieldPath and resource", file.Path)
+		}
+		items = append(items, item)
+	}
+	return items, nil
+}
+
+// verifyRelativePath check the file path in volume is relative and does not contain '..'
+func verifyRelativePath(path string) error {
+	if !verifyString(path) {
+		return errors.New("path is not allowed to be empty")
+	}
+	if strings.HasPrefix(path, "/") {
+		return fmt.Errorf("path '%s' must be relative path", path)
+	}
+	for _, item := range strings.Split(path, "/") {
+		if item == ".." {
+			return fmt.Errorf("path '%s' is not allowed to contain '..'", path)
+		}
+	}
+	return nil
+}
+
+// verifyFileMode check the file permission bits is between 0 and 0777
+func verifyFileMode(mode *int32) error {
+	if mode != nil && (*mode < 0 || *mode > 0777) {
+		return fmt.Errorf("mode %o is not between 0 and 0777", *mode)
+	}
+	return nil
+}
+
+// setVolumeMount mount volume on the container which is named mount.Container, default is the first container
+func setVolumeMount(podTemp *v1.PodTemplateSpec, mount VolumeMount) error {
+	if !verifyString(mount.Name) {
+		return errors.New("SetVolumeMount err,volume name is not allowed to be empty")
+	}
+	if !strings.HasPrefix(mount.MountPath, "/") {
+		return fmt.Errorf("SetVolumeMount err,mountPath '%s' must be absolute path", mount.MountPath)
+	}
+	if verifyString(mount.SubPath) {
+		if err := verifyRelativePath(mount.SubPath); err != nil {
+			return fmt.Errorf("SetVolumeMount err,subPath %v", err)
+		}
+	}
+	volumeMount := v1.VolumeMount{
+		Name:      mount.Name,
+		MountPath: mount.MountPath,
+		SubPath:   mount.SubPath,
+		ReadOnly:  mount.ReadOnly,
+	}
+	if len(podTemp.Spec.Containers) <= 0 {
+		if verifyString(mount.Container) {
+			return fmt.Errorf("SetVolumeMount err,container %s is not found,you can call SetContainer() first", mount.Container)
+		}
+		podTemp.Spec.Containers = []v1.Container{{VolumeMounts: []v1.VolumeMount{volumeMount}}}
+		return nil
+	}
+	index := 0
+	if verifyString(mount.Container) {
+		index = -1
+		for i, container := range podTemp.Spec.Containers {
+			if container.Name == mount.Container {
+				index = i
+				break
+			}
+		}
+		if index < 0 {
+			return fmt.Errorf("SetVolumeMount err,container %s is not found,you can call SetContainer() first", mount.Container)
+		}
+	}
+	for _, present := range podTemp.Spec.Containers[index].VolumeMounts {
+		if present.MountPath == mount.MountPath {
+			return fmt.Errorf("SetVolumeMount err,mountPath '%s' is repeated in container %s", mount.MountPath, podTemp.Spec.Containers[index].Name)
+		}
+	}
+	podTemp.Spec.Containers[index].VolumeMounts = append(podTemp.Spec.Containers[index].VolumeMounts, volumeMount)
+	return nil
+}
+
+// setConfigMapFiles mount every key of ConfigMap as a file in mountPath, the volume name is ConfigMap name,
+// the volume is shared when the same ConfigMap is mounted into many containers
+func setConfigMapFiles(podTemp *v1.PodTemplateSpec, cm *ConfigMap, mountPath string, container ...string) error {
+	if cm == nil || !verifyString(cm.cm.GetName()) {
+		return errors.New("SetConfigMapFiles err,ConfigMap name is not allowed to be empty")
+	}
+	if cm.err != nil {
+		return fmt.Errorf("SetConfigMapFiles err,%v", cm.err)
+	}
+	volumeName := strings.Trim(strings.Replace(cm.cm.GetName(), ".", "-", -1), "-")
+	if len(volumeName) > validation.DNS1123LabelMaxLength {
+		volumeName = strings.Trim(volumeName[:validation.DNS1123LabelMaxLength], "-")
+	}
+	declared := false
+	for _, volume := range podTemp.Spec.Volumes {
+		if volume.Name != volumeName {
+			continue
+		}
+		if volume.ConfigMap == nil || volume.ConfigMap.Name != cm.cm.GetName() {
+			return fmt.Errorf("SetConfigMapFiles err,volume name '%s' is used by another volume", volumeName)
+		}
+		declared = true
+	}
+	if !declared {
+		if err := setConfigMapVolume(podTemp, volumeName, &ConfigMapVolumeSource{Name: cm.cm.GetName()}); err != nil {
+			return err
+		}
+	}
+	mount := VolumeMount{Name: volumeName, MountPath: mountPath, ReadOnly: true}
+	if len(container) > 0 {
+		mount.Container = container[0]
+	}
+	return setVolumeMount(podTemp, mount)
+}
+
+// verifyVolumeMounts check every volume mount of containers references a declared volume,
+// claimTemplates is the names of StatefulSet VolumeClaimTemplates which are also declared volumes
+func verifyVolumeMounts(spec v1.PodSpec, claimTemplates ...string) error {
+	volumes := make(map[string]bool, len(spec.Volumes)+len(claimTemplates))
+	for _, volume := range spec.Volumes {
+		if volumes[volume.Name] {
+			return fmt.Errorf("volume name '%s' is repeated", volume.Name)
+		}
+		volumes[volume.Name] = true
+	}
+	for _, name := range claimTemplates {
+		volumes[name] = true
+	}
+	containers := append(append([]v1.Container{}, spec.InitContainers...), spec.Containers...)
+	for _, container := range containers {
+		for _, mount := range container.VolumeMounts {
+			if !volumes[mount.Name] {
+				return fmt.Errorf("container %s volume mount '%s' is not declared in volumes", container.Name, mount.Name)
+			}
+		}
+	}
+	return defaultResourceFieldContainers(spec, containers)
+}
+
+// defaultResourceFieldContainers input the first container as the container name of downwardAPI resource files
+// which do not set it, Kubernetes requires it in volumes, and check the container name exists
+func defaultResourceFieldContainers(spec v1.PodSpec, containers []v1.Container) error {
+	var selectors []*v1.ResourceFieldSelector
+	for _, volume := range spec.Volumes {
+		var files []v1.DownwardAPIVolumeFile
+		if volume.DownwardAPI != nil {
+			files = append(files, volume.DownwardAPI.Items...)
+		}
+		if volume.Projected != nil {
+			for _, source := range volume.Projected.Sources {
+				if source.DownwardAPI != nil {
+					files = append(files, source.DownwardAPI.Items...)
+				}
+			}
+		}
+		for _, file := range files {
+			if file.ResourceFieldRef != nil {
+				selectors = append(selectors, file.ResourceFieldRef)
+			}
+		}
+	}
+	for _, selector := range selectors {
+		if !verifyString(selector.ContainerName) {
+			if len(spec.Containers) <= 0 {
+				return fmt.Errorf("downwardAPI resource %s need container,you can call SetContainer() input", selector.Resource)
+			}
+			selector.ContainerName = spec.Containers[0].Name
+			continue
+		}
+		found := false
+		for _, container := range containers {
+			found = found || container.Name == selector.ContainerName
+		}
+		if !found {
+			return fmt.Errorf("downwardAPI resource %s container %s is not found", selector.Resource, selector.ContainerName)
+		}
+	}
+	return nil
+}
+
 func setLiveness(podTemp *v1.PodTemplateSpec, probe *v1.Probe) error {
 	if len(podTemp.Spec.Containers) <= 0 {
 		podTemp.Spec.Containers = []v1.Container{{LivenessProbe: probe}}
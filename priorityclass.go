@@ -1,45 +1,133 @@
 package beku
 
-/*
 import (
+	"encoding/json"
 	"errors"
+	"fmt"
+	"strings"
 
-	"k8s.io/kubernetes/pkg/apis/scheduling"
+	"github.com/ghodss/yaml"
+	schedulingv1 "k8s.io/api/scheduling/v1"
+	schedulingv1beta1 "k8s.io/api/scheduling/v1beta1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/client-go/kubernetes"
 )
 
+const (
+	// HighestUserDefinablePriority the highest priority value of user defined PriorityClass,
+	// the larger values are reserved for system PriorityClasses
+	HighestUserDefinablePriority int32 = 1000000000
+	// systemPriorityClassPrefix the name prefix which is reserved for system PriorityClasses
+	systemPriorityClassPrefix = "system-"
+)
+
+// systemPriorityClasses the built-in PriorityClasses which exist in every cluster
+var systemPriorityClasses = map[string]bool{
+	"system-cluster-critical": true,
+	"system-node-critical":    true,
+}
+
 // PriorityClass defines the mapping from a priority class name to the priority
 // integer value. The value can be any valid integer and err
 type PriorityClass struct {
-	pc  *scheduling.PriorityClass
+	pc  *schedulingv1.PriorityClass
 	err error
 }
 
 // NewPriorityClass create PriorityClass and Chain function call begin with this function.
-func NewPriorityClass() *PriorityClass { return &PriorityClass{pc: &scheduling.PriorityClass{}} }
+func NewPriorityClass() *PriorityClass { return &PriorityClass{pc: &schedulingv1.PriorityClass{}} }
 
 // Finish Chain function call end with this function
 // return real PriorityClass(really service is kubernetes resource object PriorityClass and error
 // In the function, it will check necessary parametersainput the default field
-func (obj *PriorityClass) Finish() (pc *scheduling.PriorityClass, err error) {
+func (obj *PriorityClass) Finish() (pc *schedulingv1.PriorityClass, err error) {
 	obj.verify()
 	pc, err = obj.pc, obj.err
 	return
 }
 
-// SetName set priorityClass name
+// JSONNew use json data create PriorityClass
+func (obj *PriorityClass) JSONNew(jsonbyts []byte) *PriorityClass {
+	obj.error(json.Unmarshal(jsonbyts, obj.pc))
+	return obj
+}
+
+// YAMLNew use yaml data create PriorityClass
+func (obj *PriorityClass) YAMLNew(yamlbyts []byte) *PriorityClass {
+	obj.error(yaml.Unmarshal(yamlbyts, obj.pc))
+	return obj
+}
+
+// JSONNewTemplate use json template and values create PriorityClass,
+// the template is rendered by RenderTemplate() and must be valid json
+func (obj *PriorityClass) JSONNewTemplate(tpl []byte, values interface{}) *PriorityClass {
+	jsonbyts, err := renderJSONTemplate(tpl, values)
+	if err != nil {
+		obj.error(err)
+		return obj
+	}
+	return obj.JSONNew(jsonbyts)
+}
+
+// YAMLNewTemplate use yaml template and values create PriorityClass,
+// the template is rendered by RenderTemplate() and must be valid yaml
+func (obj *PriorityClass) YAMLNewTemplate(tpl []byte, values interface{}) *PriorityClass {
+	yamlbyts, err := renderYAMLTemplate(tpl, values)
+	if err != nil {
+		obj.error(err)
+		return obj
+	}
+	return obj.YAMLNew(yamlbyts)
+}
+
+// Replace replace PriorityClass by Kubernetes resource object
+func (obj *PriorityClass) Replace(pc *schedulingv1.PriorityClass) *PriorityClass {
+	if pc != nil {
+		obj.pc = pc
+	}
+	return obj
+}
+
+// SetName set priorityClass name, the prefix "system-" is reserved
 func (obj *PriorityClass) SetName(name string) *PriorityClass {
 	obj.pc.SetName(name)
 	return obj
 }
 
+// SetLabels set priorityClass labels
+func (obj *PriorityClass) SetLabels(labels map[string]string) *PriorityClass {
+	obj.pc.SetLabels(labels)
+	return obj
+}
+
+// SetAnnotations set priorityClass annotations
+func (obj *PriorityClass) SetAnnotations(annotations map[string]string) *PriorityClass {
+	if len(obj.pc.Annotations) <= 0 {
+		obj.pc.Annotations = annotations
+		return obj
+	}
+	for key, value := range annotations {
+		obj.pc.Annotations[key] = value
+	}
+	return obj
+}
+
 // SetValue set priorityClass priority value,The higher the value, the higher the priority.
-// The value range of 0<=prioriry<1000000000
-func (obj *PriorityClass) SetValue(prioriry int32) *PriorityClass {
-	if prioriry < 0 && prioriry > 1000000000 {
-		obj.error(errors.New("PriorityClass.Value must be in the range of 0 to 1000000000"))
+// the value is not allowed to be greater than HighestUserDefinablePriority(1000000000)
+func (obj *PriorityClass) SetValue(priority int32) *PriorityClass {
+	if priority > HighestUserDefinablePriority {
+		obj.error(fmt.Errorf("SetValue err,PriorityClass value %d is greater than %d", priority, HighestUserDefinablePriority))
 		return obj
 	}
-	obj.pc.Value = prioriry
+	obj.pc.Value = priority
+	return obj
+}
+
+// SetNameAndValue set PriorityClass name priority value
+func (obj *PriorityClass) SetNameAndValue(name string, priority int32) *PriorityClass {
+	obj.SetName(name)
+	obj.SetValue(priority)
 	return obj
 }
 
@@ -48,16 +136,20 @@ func (obj *PriorityClass) SetValue(prioriry int32) *PriorityClass {
 // Only one PriorityClass can be marked as `globalDefault`. However, if more than
 // one PriorityClasses exists with their `globalDefault` field set to true,
 // the smallest value of such global default PriorityClasses will be used as the default priority.
-// +optional
 func (obj *PriorityClass) SetGlobalDefault(global bool) *PriorityClass {
 	obj.pc.GlobalDefault = global
 	return obj
 }
 
-// SetNameAnddValue set PriorityClass name priority value
-func (obj *PriorityClass) SetNameAnddValue(name string, prioriry int32) *PriorityClass {
-	obj.SetName(name)
-	obj.SetValue(prioriry)
+// SetPreemptionPolicy set priorityClass preemption policy:PreemptLowerPriority,PreemptNever,
+// Pods with PreemptNever are placed ahead of lower priority pods in the scheduling queue but never preempt them
+func (obj *PriorityClass) SetPreemptionPolicy(policy PreemptionPolicy) *PriorityClass {
+	k8sPolicy := policy.ToK8s()
+	if k8sPolicy == "" {
+		obj.error(fmt.Errorf("SetPreemptionPolicy err,policy %s is not supported", policy))
+		return obj
+	}
+	obj.pc.PreemptionPolicy = &k8sPolicy
 	return obj
 }
 
@@ -68,6 +160,68 @@ func (obj *PriorityClass) SetDescription(desc string) *PriorityClass {
 	return obj
 }
 
+// Release release PriorityClass on Kubernetes,
+// it will be released by scheduling.k8s.io/v1beta1 when scheduling.k8s.io/v1 is not supported
+func (obj *PriorityClass) Release() (*schedulingv1.PriorityClass, error) {
+	pc, err := obj.Finish()
+	if err != nil {
+		return nil, err
+	}
+	client, err := GetKubeClient()
+	if err != nil {
+		return nil, err
+	}
+	if !schedulingV1Supported(client) {
+		_, err = client.SchedulingV1beta1().PriorityClasses().Create(pcToV1beta1(pc))
+		return pc, err
+	}
+	return client.SchedulingV1().PriorityClasses().Create(pc)
+}
+
+// Apply it will be updated when this resource object exists in K8s,
+// it will be created when it does not exist.
+func (obj *PriorityClass) Apply() (*schedulingv1.PriorityClass, error) {
+	pc, err := obj.Finish()
+	if err != nil {
+		return nil, err
+	}
+	client, err := GetKubeClient()
+	if err != nil {
+		return nil, err
+	}
+	if !schedulingV1Supported(client) {
+		v1beta1pc := pcToV1beta1(pc)
+		_, err = client.SchedulingV1beta1().PriorityClasses().Get(pc.GetName(), metav1.GetOptions{})
+		if err != nil {
+			_, err = client.SchedulingV1beta1().PriorityClasses().Create(v1beta1pc)
+			return pc, err
+		}
+		_, err = client.SchedulingV1beta1().PriorityClasses().Update(v1beta1pc)
+		return pc, err
+	}
+	_, err = client.SchedulingV1().PriorityClasses().Get(pc.GetName(), metav1.GetOptions{})
+	if err != nil {
+		return client.SchedulingV1().PriorityClasses().Create(pc)
+	}
+	return client.SchedulingV1().PriorityClasses().Update(pc)
+}
+
+// Delete delete PriorityClass on Kubernetes
+func (obj *PriorityClass) Delete() error {
+	pc, err := obj.Finish()
+	if err != nil {
+		return err
+	}
+	client, err := GetKubeClient()
+	if err != nil {
+		return err
+	}
+	if !schedulingV1Supported(client) {
+		return client.SchedulingV1beta1().PriorityClasses().Delete(pc.GetName(), &metav1.DeleteOptions{})
+	}
+	return client.SchedulingV1().PriorityClasses().Delete(pc.GetName(), &metav1.DeleteOptions{})
+}
+
 func (obj *PriorityClass) error(err error) {
 	if obj.err != nil {
 		return
@@ -79,12 +233,63 @@ func (obj *PriorityClass) verify() {
 	if obj.err != nil {
 		return
 	}
-
 	if !verifyString(obj.pc.GetName()) {
 		obj.err = errors.New("pc.Name is not allowed to be empty")
 		return
 	}
-	obj.pc.APIVersion = "scheduling.k8s.io/v1beta1"
+	if strings.HasPrefix(obj.pc.GetName(), systemPriorityClassPrefix) {
+		obj.err = fmt.Errorf("PriorityClass name %s is not allowed,prefix %s is reserved by Kubernetes", obj.pc.GetName(), systemPriorityClassPrefix)
+		return
+	}
+	if obj.pc.Value > HighestUserDefinablePriority {
+		obj.err = fmt.Errorf("PriorityClass value %d is not allowed to be greater than %d", obj.pc.Value, HighestUserDefinablePriority)
+		return
+	}
+	obj.pc.APIVersion = "scheduling.k8s.io/v1"
 	obj.pc.Kind = "PriorityClass"
 }
-*/
+
+// schedulingV1Supported check whether Kubernetes supports scheduling.k8s.io/v1
+func schedulingV1Supported(client *kubernetes.Clientset) bool {
+	_, err := client.Discovery().ServerResourcesForGroupVersion(schedulingv1.SchemeGroupVersion.String())
+	return err == nil
+}
+
+// pcToV1beta1 translate PriorityClass into scheduling.k8s.io/v1beta1
+func pcToV1beta1(pc *schedulingv1.PriorityClass) *schedulingv1beta1.PriorityClass {
+	return &schedulingv1beta1.PriorityClass{
+		TypeMeta:         metav1.TypeMeta{Kind: "PriorityClass", APIVersion: "scheduling.k8s.io/v1beta1"},
+		ObjectMeta:       pc.ObjectMeta,
+		Value:            pc.Value,
+		GlobalDefault:    pc.GlobalDefault,
+		Description:      pc.Description,
+		PreemptionPolicy: pc.PreemptionPolicy,
+	}
+}
+
+// priorityClassExists check PriorityClass which is referenced by Pod exists on Kubernetes,
+// it returns nil when name is empty or it is a system PriorityClass
+func priorityClassExists(client *kubernetes.Clientset, name string) error {
+	if !verifyString(name) || systemPriorityClasses[name] {
+		return nil
+	}
+	var err error
+	if schedulingV1Supported(client) {
+		_, err = client.SchedulingV1().PriorityClasses().Get(name, metav1.GetOptions{})
+	} else {
+		_, err = client.SchedulingV1beta1().PriorityClasses().Get(name, metav1.GetOptions{})
+	}
+	if err != nil {
+		return fmt.Errorf("PriorityClass %s is not found on Kubernetes,you can release it by NewPriorityClass() first:%v", name, err)
+	}
+	return nil
+}
+
+// podPriorityClassName return the PriorityClass name which is referenced by Pod of workload,
+// return "" when it is not a workload
+func podPriorityClassName(o runtime.Object) string {
+	if spec := podSpecOf(o); spec != nil {
+		return spec.PriorityClassName
+	}
+	return ""
+}
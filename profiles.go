@@ -0,0 +1,450 @@
+package beku
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"reflect"
+	"sort"
+	"strings"
+
+	jsonpatch "github.com/evanphx/json-patch"
+	"github.com/ghodss/yaml"
+	appsv1 "k8s.io/api/apps/v1"
+	"k8s.io/api/autoscaling/v2beta2"
+	batchv1 "k8s.io/api/batch/v1"
+	batchv1beta1 "k8s.io/api/batch/v1beta1"
+	"k8s.io/api/core/v1"
+	networkingv1 "k8s.io/api/networking/v1"
+	networkingv1beta1 "k8s.io/api/networking/v1beta1"
+	policyv1beta1 "k8s.io/api/policy/v1beta1"
+	"k8s.io/api/rbac/v1beta1"
+	schedulingv1 "k8s.io/api/scheduling/v1"
+	storagev1 "k8s.io/api/storage/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/util/strategicpatch"
+)
+
+// ProfileBase the profile name of the fields which are set by base builder
+const ProfileBase = "base"
+
+// ProfileReport record which profile set each field,
+// map[kind/namespace/name]map[field path]profile, eg: map["Deployment/default/mysql"]["spec.replicas"]="prod"
+type ProfileReport map[string]map[string]string
+
+// String print report sorted by object and field path
+func (r ProfileReport) String() string {
+	var lines []string
+	for key, fields := range r {
+		for path, profile := range fields {
+			lines = append(lines, fmt.Sprintf("%s %s: %s", key, path, profile))
+		}
+	}
+	sort.Strings(lines)
+	return strings.Join(lines, "\n")
+}
+
+// profileOverlay is Go function or yaml patch of profile, names limit the objects which function is applied to
+type profileOverlay struct {
+	fn      reflect.Value
+	names   []string
+	patches []map[string]interface{}
+}
+
+// Profiles include base builder or Bundle, and named overlays of every profile and error
+type Profiles struct {
+	base     interface{}
+	overlays map[string][]profileOverlay
+	err      error
+}
+
+// NewProfiles create Profiles and chain function call begin with this function.
+// base is beku builder(eg: *Deployment) or *Bundle, base is not modified by profiles.
+func NewProfiles(base interface{}) *Profiles {
+	return &Profiles{base: base, overlays: make(map[string][]profileOverlay, 0)}
+}
+
+// AddFunc add Go function overlay into profile,
+// fn must be func(*Builder) or func(*Builder) *Builder, eg: func(dp *beku.Deployment) { dp.SetReplicas(3) },
+// fn is applied to every object whose builder type is *Builder, names limit the objects by name when base is Bundle
+func (obj *Profiles) AddFunc(profile string, fn interface{}, names ...string) *Profiles {
+	if !verifyString(profile) || profile == ProfileBase {
+		obj.error(fmt.Errorf("Profiles profile name:%q is not allowed", profile))
+		return obj
+	}
+	fnVal := reflect.ValueOf(fn)
+	if fnVal.Kind() != reflect.Func {
+		obj.error(fmt.Errorf("Profiles profile %s func is not allowed,it must be func(*Builder) or func(*Builder) *Builder", profile))
+		return obj
+	}
+	fnType := fnVal.Type()
+	if fnType.NumIn() != 1 || !profileBuilders[fnType.In(0)] ||
+		fnType.NumOut() > 1 || (fnType.NumOut() == 1 && fnType.Out(0) != fnType.In(0)) {
+		obj.error(fmt.Errorf("Profiles profile %s func type %s is not allowed,it must be func(*Builder) or func(*Builder) *Builder", profile, fnType))
+		return obj
+	}
+	obj.overlays[profile] = append(obj.overlays[profile], profileOverlay{fn: fnVal, names: names})
+	return obj
+}
+
+// AddPatch add yaml strategic merge patch overlay into profile,
+// patch can include multiple documents, every document need apiVersion,kind and metadata.name to find the object when base is Bundle,
+// patch document without kind is applied to the only object when base is single builder
+func (obj *Profiles) AddPatch(profile string, patch []byte) *Profiles {
+	if !verifyString(profile) || profile == ProfileBase {
+		obj.error(fmt.Errorf("Profiles profile name:%q is not allowed", profile))
+		return obj
+	}
+	var patches []map[string]interface{}
+	for _, doc := range splitYAML(patch) {
+		p := make(map[string]interface{}, 0)
+		if err := yaml.Unmarshal(doc, &p); err != nil {
+			obj.error(fmt.Errorf("Profiles profile %s patch err:%v", profile, err))
+			return obj
+		}
+		if len(p) > 0 {
+			patches = append(patches, p)
+		}
+	}
+	if len(patches) <= 0 {
+		obj.error(fmt.Errorf("Profiles profile %s patch is not allowed to be empty", profile))
+		return obj
+	}
+	obj.overlays[profile] = append(obj.overlays[profile], profileOverlay{patches: patches})
+	return obj
+}
+
+// Finish Chain function call end with this function
+// apply overlays of profiles in order on base, return merged Kubernetes resource objects,
+// the report which profile set each field and error.
+// Finish() without profile return base objects.
+func (obj *Profiles) Finish(profiles ...string) ([]runtime.Object, ProfileReport, error) {
+	obj.verify()
+	if obj.err != nil {
+		return nil, nil, obj.err
+	}
+	var (
+		baseObjs []runtime.Object
+		err      error
+	)
+	if bundle, ok := obj.base.(*Bundle); ok {
+		baseObjs, err = bundle.Finish()
+	} else {
+		baseObjs, err = finishObject(obj.base)
+	}
+	if err != nil {
+		return nil, nil, err
+	}
+	objs := make([]runtime.Object, len(baseObjs))
+	fields := make([]map[string]string, len(baseObjs))
+	for index, o := range baseObjs {
+		objs[index] = o.DeepCopyObject()
+		item, err := objectToMap(objs[index])
+		if err != nil {
+			return nil, nil, err
+		}
+		fields[index] = make(map[string]string, 0)
+		for path := range flattenFields(item) {
+			fields[index][path] = ProfileBase
+		}
+	}
+	for _, profile := range profiles {
+		overlays, ok := obj.overlays[profile]
+		if !ok {
+			return nil, nil, fmt.Errorf("Profiles profile %s is not registered", profile)
+		}
+		for _, overlay := range overlays {
+			if err = overlay.apply(objs, fields, profile); err != nil {
+				return nil, nil, fmt.Errorf("Profiles profile %s err:%v", profile, err)
+			}
+		}
+	}
+	report := make(ProfileReport, len(objs))
+	for index, o := range objs {
+		item, err := objectToMap(o)
+		if err != nil {
+			return nil, nil, err
+		}
+		kind, namespace, name := objectKey(item)
+		report[fmt.Sprintf("%s/%s/%s", kind, namespace, name)] = fields[index]
+	}
+	return objs, report, nil
+}
+
+// apply apply overlay on objects, the fields which are changed by overlay are recorded as profile
+func (overlay profileOverlay) apply(objs []runtime.Object, fields []map[string]string, profile string) error {
+	if overlay.fn.IsValid() {
+		for index, o := range objs {
+			builder := wrapObject(o.DeepCopyObject())
+			if builder == nil || reflect.TypeOf(builder) != overlay.fn.Type().In(0) {
+				continue
+			}
+			_, _, name := objectKeyOf(o)
+			if len(overlay.names) > 0 && !containsString(overlay.names, name) {
+				continue
+			}
+			out := overlay.fn.Call([]reflect.Value{reflect.ValueOf(builder)})
+			if len(out) == 1 && !out[0].IsNil() {
+				builder = out[0].Interface()
+			}
+			if err := overlay.replace(objs, fields, index, builder, profile); err != nil {
+				return err
+			}
+		}
+		return nil
+	}
+	for _, patch := range overlay.patches {
+		index, err := patchTarget(objs, patch)
+		if err != nil {
+			return err
+		}
+		item, err := objectToMap(objs[index])
+		if err != nil {
+			return err
+		}
+		// patch maybe modified by merging, copy it to keep overlay reusable
+		patchbyts, err := json.Marshal(patch)
+		if err != nil {
+			return err
+		}
+		patch = make(map[string]interface{}, 0)
+		if err = json.Unmarshal(patchbyts, &patch); err != nil {
+			return err
+		}
+		var patched map[string]interface{}
+		if dataStruct, err := newTypedObject(item); err == nil {
+			patched, err = strategicpatch.StrategicMergeMapPatch(item, patch, dataStruct)
+			if err != nil {
+				return err
+			}
+		} else {
+			original, _ := json.Marshal(item)
+			byts, err := jsonpatch.MergePatch(original, patchbyts)
+			if err != nil {
+				return err
+			}
+			if err = json.Unmarshal(byts, &patched); err != nil {
+				return err
+			}
+		}
+		o, err := mapToObject(patched)
+		if err != nil {
+			return err
+		}
+		var builder interface{} = o
+		if b := wrapObject(o); b != nil {
+			builder = b
+		}
+		if err = overlay.replace(objs, fields, index, builder, profile); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// replace finish builder, replace the object at index and record the changed fields
+func (overlay profileOverlay) replace(objs []runtime.Object, fields []map[string]string, index int, builder interface{}, profile string) error {
+	finished, err := finishObject(builder)
+	if err != nil {
+		return err
+	}
+	before, err := objectToMap(objs[index])
+	if err != nil {
+		return err
+	}
+	after, err := objectToMap(finished[0])
+	if err != nil {
+		return err
+	}
+	beforeFields, afterFields := flattenFields(before), flattenFields(after)
+	for path, val := range afterFields {
+		if old, ok := beforeFields[path]; !ok || !reflect.DeepEqual(old, val) {
+			fields[index][path] = profile
+		}
+	}
+	for path := range beforeFields {
+		if _, ok := afterFields[path]; !ok {
+			delete(fields[index], path)
+		}
+	}
+	objs[index] = finished[0]
+	return nil
+}
+
+// patchTarget find the object which patch is applied to
+func patchTarget(objs []runtime.Object, patch map[string]interface{}) (int, error) {
+	kind, namespace, name := objectKey(patch)
+	if emptyString(kind) && len(objs) == 1 {
+		return 0, nil
+	}
+	for index, o := range objs {
+		k, ns, n := objectKeyOf(o)
+		if k == kind && n == name && (emptyString(namespace) || ns == namespace) {
+			return index, nil
+		}
+	}
+	return -1, fmt.Errorf("patch target %s %s is not found", kind, name)
+}
+
+// objectKeyOf return kind, namespace and name of Kubernetes resource object
+func objectKeyOf(o runtime.Object) (kind, namespace, name string) {
+	item, err := objectToMap(o)
+	if err != nil {
+		return
+	}
+	return objectKey(item)
+}
+
+// flattenFields flatten object in map into map[field path]value, only leaf field is included
+func flattenFields(item map[string]interface{}) map[string]interface{} {
+	fields := make(map[string]interface{}, 0)
+	var flatten func(prefix string, v interface{})
+	flatten = func(prefix string, v interface{}) {
+		switch val := v.(type) {
+		case map[string]interface{}:
+			if len(val) == 0 {
+				fields[prefix] = val
+			}
+			for key, sub := range val {
+				path := prefix + "." + key
+				if strings.ContainsAny(key, "./") {
+					path = fmt.Sprintf("%s[%q]", prefix, key)
+				}
+				flatten(path, sub)
+			}
+		case []interface{}:
+			if len(val) == 0 {
+				fields[prefix] = val
+			}
+			for index, sub := range val {
+				flatten(fmt.Sprintf("%s[%d]", prefix, index), sub)
+			}
+		default:
+			fields[prefix] = val
+		}
+	}
+	for key, val := range item {
+		if key == "apiVersion" || key == "kind" {
+			continue
+		}
+		flatten(key, val)
+	}
+	return fields
+}
+
+// profileBuilders builder types which are supported by Profiles func overlay
+var profileBuilders = map[reflect.Type]bool{
+	reflect.TypeOf(&Deployment{}):            true,
+	reflect.TypeOf(&StatefulSet{}):           true,
+	reflect.TypeOf(&DaemonSet{}):             true,
+	reflect.TypeOf(&Pod{}):                   true,
+	reflect.TypeOf(&Service{}):               true,
+	reflect.TypeOf(&ConfigMap{}):             true,
+	reflect.TypeOf(&Secret{}):                true,
+	reflect.TypeOf(&PersistentVolume{}):      true,
+	reflect.TypeOf(&PersistentVolumeClaim{}): true,
+	reflect.TypeOf(&StorageClass{}):          true,
+	reflect.TypeOf(&ServiceAccount{}):        true,
+	reflect.TypeOf(&ClusterRole{}):           true,
+	reflect.TypeOf(&ClusterRoleBinding{}):    true,
+	reflect.TypeOf(&Role{}):                  true,
+	reflect.TypeOf(&RoleBinding{}):           true,
+	reflect.TypeOf(&Namespace{}):             true,
+	reflect.TypeOf(&Ingress{}):               true,
+	reflect.TypeOf(&Job{}):                   true,
+	reflect.TypeOf(&CronJob{}):               true,
+	reflect.TypeOf(&HPA{}):                   true,
+	reflect.TypeOf(&PDB{}):                   true,
+	reflect.TypeOf(&NetworkPolicy{}):         true,
+	reflect.TypeOf(&ResourceQuota{}):         true,
+	reflect.TypeOf(&LimitRange{}):            true,
+	reflect.TypeOf(&Endpoints{}):             true,
+	reflect.TypeOf(&PriorityClass{}):         true,
+}
+
+// wrapObject wrap Kubernetes resource object into beku builder by Replace(),
+// return nil when the object has no builder
+func wrapObject(o runtime.Object) interface{} {
+	switch v := o.(type) {
+	case *appsv1.Deployment:
+		return NewDeployment().Replace(v)
+	case *appsv1.StatefulSet:
+		return NewSts().Replace(v)
+	case *appsv1.DaemonSet:
+		return NewDS().Replace(v)
+	case *v1.Pod:
+		return NewPod().Replace(v)
+	case *v1.Service:
+		return NewSvc().Replace(v)
+	case *v1.ConfigMap:
+		return NewCM().Replace(v)
+	case *v1.Secret:
+		return NewSecret().Replace(v)
+	case *v1.PersistentVolume:
+		return NewPV().Replace(v)
+	case *v1.PersistentVolumeClaim:
+		return NewPVC().Replace(v)
+	case *storagev1.StorageClass:
+		return NewStorageClass().Replace(v)
+	case *v1.ServiceAccount:
+		return NewSa().Replace(v)
+	case *v1beta1.ClusterRole:
+		return NewClusterRole().Replace(v)
+	case *v1beta1.ClusterRoleBinding:
+		return NewClusterRoleBinding().Replace(v)
+	case *v1beta1.Role:
+		return NewRole().Replace(v)
+	case *v1beta1.RoleBinding:
+		return NewRoleBinding().Replace(v)
+	case *v1.Namespace:
+		return NewNs().Replace(v)
+	case *networkingv1beta1.Ingress:
+		return NewIngress().Replace(v)
+	case *batchv1.Job:
+		return NewJob().Replace(v)
+	case *batchv1beta1.CronJob:
+		return NewCronJob().Replace(v)
+	case *v2beta2.HorizontalPodAutoscaler:
+		return NewHPA().Replace(v)
+	case *policyv1beta1.PodDisruptionBudget:
+		return NewPDB().Replace(v)
+	case *networkingv1.NetworkPolicy:
+		return NewNetworkPolicy().Replace(v)
+	case *v1.ResourceQuota:
+		return NewResourceQuota().Replace(v)
+	case *v1.LimitRange:
+		return NewLimitRange().Replace(v)
+	case *v1.Endpoints:
+		return NewEndpoints().Replace(v)
+	case *schedulingv1.PriorityClass:
+		return NewPriorityClass().Replace(v)
+	}
+	return nil
+}
+
+func containsString(strs []string, str string) bool {
+	for _, s := range strs {
+		if s == str {
+			return true
+		}
+	}
+	return false
+}
+
+func (obj *Profiles) error(err error) {
+	if obj.err != nil {
+		return
+	}
+	obj.err = err
+}
+
+// verify check Profiles necessary value
+func (obj *Profiles) verify() {
+	if obj.err != nil {
+		return
+	}
+	if obj.base == nil {
+		obj.err = errors.New("Profiles base is not allowed to be empty")
+		return
+	}
+}
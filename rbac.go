@@ -0,0 +1,150 @@
+package beku
+
+import (
+	"errors"
+	"fmt"
+
+	"k8s.io/api/rbac/v1beta1"
+)
+
+// rbacAPIGroup the apiGroup of RBAC resource objects
+const rbacAPIGroup = "rbac.authorization.k8s.io"
+
+// AggregateToKeyPrefix ClusterRole with label AggregateToKeyPrefix+name=true will be aggregated into ClusterRole name,
+// eg: rbac.authorization.k8s.io/aggregate-to-view: "true"
+const AggregateToKeyPrefix = "rbac.authorization.k8s.io/aggregate-to-"
+
+// PolicyRule holds information that describes a policy rule
+// Verbs: such as "get", "watch", "list","create", "delete" ..., "*" is all verbs
+// APIGroups: such as "", "apps", "rbac.authorization.k8s.io"..., "" is core group, "*" is all groups
+// Resources: such as "pods", "deployments", "pods/log"..., "*" is all resources
+// ResourceNames: the names of resource objects which rule applies to, empty is all objects
+// NonResourceURLs: such as "/healthz", "/metrics", "/api/*", it is only allowed in ClusterRole and can't be set with Resources
+type PolicyRule struct {
+	Verbs           []string
+	APIGroups       []string
+	Resources       []string
+	ResourceNames   []string
+	NonResourceURLs []string
+}
+
+// ToK8s translate into Kubernetes PolicyRule
+func (rule PolicyRule) ToK8s() v1beta1.PolicyRule {
+	return v1beta1.PolicyRule{
+		Verbs:           rule.Verbs,
+		APIGroups:       rule.APIGroups,
+		Resources:       rule.Resources,
+		ResourceNames:   rule.ResourceNames,
+		NonResourceURLs: rule.NonResourceURLs,
+	}
+}
+
+// RolePreset the built-in rules of Role and ClusterRole
+type RolePreset string
+
+const (
+	// PresetReadOnly read common workload,service and config resources,secrets are not included
+	PresetReadOnly RolePreset = "read-only"
+	// PresetNamespaceEditor read and write common workload,service,config and secret resources,
+	// RBAC resources are not included, but it can run Pods as any ServiceAccount in the namespace and read all secrets,
+	// so it has the permissions of every ServiceAccount in the namespace, only grant it to the namespace owner
+	PresetNamespaceEditor RolePreset = "namespace-editor"
+	// PresetSecretsReader read secrets
+	PresetSecretsReader RolePreset = "secrets-reader"
+)
+
+var (
+	readVerbs  = []string{"get", "list", "watch"}
+	writeVerbs = []string{"get", "list", "watch", "create", "update", "patch", "delete", "deletecollection"}
+	// presetResources apiGroup->resources which are readable by PresetReadOnly and writable by PresetNamespaceEditor
+	presetResources = []PolicyRule{
+		{APIGroups: []string{""}, Resources: []string{"pods", "pods/log", "services", "endpoints", "configmaps",
+			"persistentvolumeclaims", "replicationcontrollers", "serviceaccounts", "events"}},
+		{APIGroups: []string{"apps"}, Resources: []string{"deployments", "deployments/scale", "statefulsets",
+			"statefulsets/scale", "daemonsets", "replicasets"}},
+		{APIGroups: []string{"batch"}, Resources: []string{"jobs", "cronjobs"}},
+		{APIGroups: []string{"autoscaling"}, Resources: []string{"horizontalpodautoscalers"}},
+		{APIGroups: []string{"policy"}, Resources: []string{"poddisruptionbudgets"}},
+		{APIGroups: []string{"networking.k8s.io", "extensions"}, Resources: []string{"ingresses", "networkpolicies"}},
+	}
+)
+
+// Rules return rules of preset, return nil when it is not supported
+func (preset RolePreset) Rules() []v1beta1.PolicyRule {
+	var rules []v1beta1.PolicyRule
+	switch preset {
+	case PresetReadOnly:
+		for _, rule := range presetResources {
+			rule.Verbs = readVerbs
+			rules = append(rules, rule.ToK8s())
+		}
+	case PresetNamespaceEditor:
+		for _, rule := range presetResources {
+			rule.Verbs = writeVerbs
+			rules = append(rules, rule.ToK8s())
+		}
+		rules = append(rules,
+			PolicyRule{Verbs: writeVerbs, APIGroups: []string{""}, Resources: []string{"secrets"}}.ToK8s(),
+			PolicyRule{Verbs: []string{"create"}, APIGroups: []string{""}, Resources: []string{"pods/exec", "pods/portforward"}}.ToK8s())
+	case PresetSecretsReader:
+		rules = append(rules, PolicyRule{Verbs: readVerbs, APIGroups: []string{""}, Resources: []string{"secrets"}}.ToK8s())
+	}
+	return rules
+}
+
+// verifyPolicyRules check every rule has verbs and has resources or nonResourceURLs,
+// nonResourceURLs is not allowed in namespaced Role
+func verifyPolicyRules(kind string, rules []v1beta1.PolicyRule, namespaced bool) error {
+	for index, rule := range rules {
+		if len(rule.Verbs) <= 0 {
+			return fmt.Errorf("%s rules[%d] verbs is not allowed to be empty", kind, index)
+		}
+		if len(rule.NonResourceURLs) > 0 {
+			if namespaced {
+				return fmt.Errorf("%s rules[%d] nonResourceURLs is not allowed in namespaced %s", kind, index, kind)
+			}
+			if len(rule.Resources) > 0 || len(rule.APIGroups) > 0 || len(rule.ResourceNames) > 0 {
+				return fmt.Errorf("%s rules[%d] nonResourceURLs is not allowed to be set with apiGroups,resources or resourceNames", kind, index)
+			}
+			continue
+		}
+		if len(rule.Resources) <= 0 || len(rule.APIGroups) <= 0 {
+			return fmt.Errorf("%s rules[%d] apiGroups and resources are not allowed to be empty when nonResourceURLs is empty", kind, index)
+		}
+	}
+	return nil
+}
+
+// newSubject create binding subject,
+// kind only support "User", "Group", "ServiceAccount", namespace is required when kind is "ServiceAccount"
+func newSubject(name string, kind SubKind, namespace string) (v1beta1.Subject, error) {
+	if kindMaps[kind] == "" {
+		return v1beta1.Subject{}, fmt.Errorf("Set subject err. kind:%v is not supported, only support User/Group/ServiceAccount ", kind)
+	}
+	if !verifyString(name) {
+		return v1beta1.Subject{}, errors.New("Set subject err. name is not allowed to be empty")
+	}
+	subject := v1beta1.Subject{
+		Name: name,
+		Kind: string(kind),
+	}
+	if kindMaps[kind] == "namespace" {
+		subject.Namespace = namespace
+	} else {
+		subject.APIGroup = kindMaps[kind]
+	}
+	return subject, nil
+}
+
+// verifySubjects check subjects is not empty and ServiceAccount subject has namespace
+func verifySubjects(kind string, subjects []v1beta1.Subject) error {
+	if len(subjects) <= 0 {
+		return fmt.Errorf("Set %s err,subejects is not allowed to be empty", kind)
+	}
+	for index, subject := range subjects {
+		if subject.Kind == string(SA) && !verifyString(subject.Namespace) {
+			return fmt.Errorf("%s subjects[%d] namespace of ServiceAccount %s is not allowed to be empty", kind, index, subject.Name)
+		}
+	}
+	return nil
+}
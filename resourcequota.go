@@ -0,0 +1,256 @@
+package beku
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+
+	"github.com/ghodss/yaml"
+	"k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+// ResourceQuota include Kubernetes resource object ResourceQuota and error
+type ResourceQuota struct {
+	quota *v1.ResourceQuota
+	err   error
+}
+
+// NewResourceQuota create ResourceQuota and chain function call begin with this function.
+func NewResourceQuota() *ResourceQuota { return &ResourceQuota{quota: &v1.ResourceQuota{}} }
+
+// Finish Chain function call end with this function
+// return Kubernetes resource object ResourceQuota and error.
+// In the function, it will check necessary parameters,input the default field
+func (obj *ResourceQuota) Finish() (*v1.ResourceQuota, error) {
+	obj.verify()
+	return obj.quota, obj.err
+}
+
+// JSONNew use json data create ResourceQuota
+func (obj *ResourceQuota) JSONNew(jsonbyts []byte) *ResourceQuota {
+	obj.error(json.Unmarshal(jsonbyts, obj.quota))
+	return obj
+}
+
+// YAMLNew use yaml data create ResourceQuota
+func (obj *ResourceQuota) YAMLNew(yamlbyts []byte) *ResourceQuota {
+	obj.error(yaml.Unmarshal(yamlbyts, obj.quota))
+	return obj
+}
+
+// JSONNewTemplate use json template and values create ResourceQuota,
+// the template is rendered by RenderTemplate() and must be valid json
+func (obj *ResourceQuota) JSONNewTemplate(tpl []byte, values interface{}) *ResourceQuota {
+	jsonbyts, err := renderJSONTemplate(tpl, values)
+	if err != nil {
+		obj.error(err)
+		return obj
+	}
+	return obj.JSONNew(jsonbyts)
+}
+
+// YAMLNewTemplate use yaml template and values create ResourceQuota,
+// the template is rendered by RenderTemplate() and must be valid yaml
+func (obj *ResourceQuota) YAMLNewTemplate(tpl []byte, values interface{}) *ResourceQuota {
+	yamlbyts, err := renderYAMLTemplate(tpl, values)
+	if err != nil {
+		obj.error(err)
+		return obj
+	}
+	return obj.YAMLNew(yamlbyts)
+}
+
+// Replace replace ResourceQuota by Kubernetes resource object
+func (obj *ResourceQuota) Replace(quota *v1.ResourceQuota) *ResourceQuota {
+	if quota != nil {
+		obj.quota = quota
+	}
+	return obj
+}
+
+// SetName set ResourceQuota name
+func (obj *ResourceQuota) SetName(name string) *ResourceQuota {
+	obj.quota.SetName(name)
+	return obj
+}
+
+// SetNamespace set ResourceQuota namespace
+func (obj *ResourceQuota) SetNamespace(namespace string) *ResourceQuota {
+	obj.quota.SetNamespace(namespace)
+	return obj
+}
+
+// SetNamespaceAndName set ResourceQuota namespace and name
+func (obj *ResourceQuota) SetNamespaceAndName(namespace, name string) *ResourceQuota {
+	obj.SetNamespace(namespace)
+	obj.SetName(name)
+	return obj
+}
+
+// SetLabels set ResourceQuota labels
+func (obj *ResourceQuota) SetLabels(labels map[string]string) *ResourceQuota {
+	obj.quota.SetLabels(labels)
+	return obj
+}
+
+// SetAnnotations set ResourceQuota annotations
+func (obj *ResourceQuota) SetAnnotations(annotations map[string]string) *ResourceQuota {
+	if len(obj.quota.Annotations) <= 0 {
+		obj.quota.Annotations = annotations
+		return obj
+	}
+	for key, value := range annotations {
+		obj.quota.Annotations[key] = value
+	}
+	return obj
+}
+
+// SetHard set hard limits of namespace, it can be called many times.
+// compute resources: ResourceCPU(same as ResourceRequestsCPU),ResourceLimitsCPU,ResourceMemory,ResourceLimitsMemory...
+// storage resources: ResourceRequestsStorage,ResourcePersistentVolumeClaims,StorageClassResource()
+// object counts: ResourcePods,ResourceServices,ResourceSecrets,ResourceConfigMaps...,ResourceCount("deployments.apps")
+func (obj *ResourceQuota) SetHard(hard map[ResourceName]string) *ResourceQuota {
+	data, err := resourceMapsToK8s(hard, stringToQuotaResourceName)
+	if err != nil {
+		obj.error(fmt.Errorf("SetHard err:%v", err))
+		return obj
+	}
+	if obj.quota.Spec.Hard == nil {
+		obj.quota.Spec.Hard = make(v1.ResourceList, len(data))
+	}
+	for name, quantity := range data {
+		obj.quota.Spec.Hard[name] = quantity
+	}
+	return obj
+}
+
+// SetScopes set filters which must match each Pod tracked by quota:
+// Terminating,NotTerminating,BestEffort,NotBestEffort
+func (obj *ResourceQuota) SetScopes(scopes ...ResourceQuotaScope) *ResourceQuota {
+	for _, scope := range scopes {
+		if scope.ToK8s() == "" {
+			obj.error(fmt.Errorf("SetScopes err,scope %s is not supported", scope))
+			return obj
+		}
+		obj.quota.Spec.Scopes = append(obj.quota.Spec.Scopes, scope.ToK8s())
+	}
+	return obj
+}
+
+// SetPriorityClassScope only track Pods which use one of PriorityClass names
+func (obj *ResourceQuota) SetPriorityClassScope(priorityClassNames ...string) *ResourceQuota {
+	if len(priorityClassNames) <= 0 {
+		obj.error(errors.New("SetPriorityClassScope err,priorityClassNames is not allowed to be empty"))
+		return obj
+	}
+	obj.quota.Spec.ScopeSelector = &v1.ScopeSelector{
+		MatchExpressions: []v1.ScopedResourceSelectorRequirement{{
+			ScopeName: v1.ResourceQuotaScopePriorityClass,
+			Operator:  v1.ScopeSelectorOpIn,
+			Values:    priorityClassNames,
+		}},
+	}
+	return obj
+}
+
+// Release release ResourceQuota on Kubernetes
+func (obj *ResourceQuota) Release() (*v1.ResourceQuota, error) {
+	quota, err := obj.Finish()
+	if err != nil {
+		return nil, err
+	}
+	client, err := GetKubeClient()
+	if err != nil {
+		return nil, err
+	}
+	return client.CoreV1().ResourceQuotas(quota.GetNamespace()).Create(quota)
+}
+
+// Apply it will be updated when this resource object exists in K8s,
+// it will be created when it does not exist.
+func (obj *ResourceQuota) Apply() (*v1.ResourceQuota, error) {
+	quota, err := obj.Finish()
+	if err != nil {
+		return nil, err
+	}
+	client, err := GetKubeClient()
+	if err != nil {
+		return nil, err
+	}
+	_, err = client.CoreV1().ResourceQuotas(quota.GetNamespace()).Get(quota.GetName(), metav1.GetOptions{})
+	if err != nil {
+		return client.CoreV1().ResourceQuotas(quota.GetNamespace()).Create(quota)
+	}
+	return client.CoreV1().ResourceQuotas(quota.GetNamespace()).Update(quota)
+}
+
+// Delete delete ResourceQuota on Kubernetes
+func (obj *ResourceQuota) Delete() error {
+	quota, err := obj.Finish()
+	if err != nil {
+		return err
+	}
+	client, err := GetKubeClient()
+	if err != nil {
+		return err
+	}
+	return client.CoreV1().ResourceQuotas(quota.GetNamespace()).Delete(quota.GetName(), &metav1.DeleteOptions{})
+}
+
+func (obj *ResourceQuota) error(err error) {
+	if obj.err != nil {
+		return
+	}
+	obj.err = err
+}
+
+// quotaScopeResources the resources which can be tracked by scopes
+var quotaScopeResources = map[v1.ResourceName]bool{
+	v1.ResourcePods:           true,
+	v1.ResourceCPU:            true,
+	v1.ResourceMemory:         true,
+	v1.ResourceRequestsCPU:    true,
+	v1.ResourceRequestsMemory: true,
+	v1.ResourceLimitsCPU:      true,
+	v1.ResourceLimitsMemory:   true,
+}
+
+// verify check ResourceQuota necessary value, input the default field and input related data.
+func (obj *ResourceQuota) verify() {
+	if obj.err != nil {
+		return
+	}
+	if !verifyString(obj.quota.GetName()) {
+		obj.err = errors.New("ResourceQuota name is not allowed to be empty")
+		return
+	}
+	if len(obj.quota.Spec.Hard) <= 0 {
+		obj.err = errors.New("ResourceQuota hard is not allowed to be empty,you can call SetHard() input")
+		return
+	}
+	scopes := make(map[v1.ResourceQuotaScope]bool, len(obj.quota.Spec.Scopes))
+	for _, scope := range obj.quota.Spec.Scopes {
+		scopes[scope] = true
+	}
+	if (scopes[v1.ResourceQuotaScopeTerminating] && scopes[v1.ResourceQuotaScopeNotTerminating]) ||
+		(scopes[v1.ResourceQuotaScopeBestEffort] && scopes[v1.ResourceQuotaScopeNotBestEffort]) {
+		obj.err = errors.New("ResourceQuota scopes Terminating and NotTerminating,BestEffort and NotBestEffort are not allowed at the same time")
+		return
+	}
+	if len(scopes) > 0 || obj.quota.Spec.ScopeSelector != nil {
+		for name := range obj.quota.Spec.Hard {
+			if !quotaScopeResources[name] {
+				obj.err = fmt.Errorf("ResourceQuota hard %s is not allowed with scopes,only pods,cpu,memory,requests and limits of cpu and memory are allowed", name)
+				return
+			}
+			// BestEffort Pods have no requests and limits
+			if scopes[v1.ResourceQuotaScopeBestEffort] && name != v1.ResourcePods {
+				obj.err = fmt.Errorf("ResourceQuota hard %s is not allowed with BestEffort scope,only pods is allowed", name)
+				return
+			}
+		}
+	}
+	obj.quota.Kind = "ResourceQuota"
+	obj.quota.APIVersion = "v1"
+}
@@ -0,0 +1,213 @@
+package beku
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+
+	"github.com/ghodss/yaml"
+	"k8s.io/api/rbac/v1beta1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+// Role include kubernetes resource object Role and error,
+// Role only grants access to resources in it's own namespace
+type Role struct {
+	role *v1beta1.Role
+	err  error
+}
+
+// NewRole create Role and chain function call begin with this function.
+func NewRole() *Role { return &Role{role: &v1beta1.Role{}} }
+
+// Finish Chain function call end with this function
+// return Kubernetes resource object Role and error.
+// In the function, it will check necessary parameters,input the default field
+func (obj *Role) Finish() (*v1beta1.Role, error) {
+	obj.verify()
+	return obj.role, obj.err
+}
+
+// JSONNew use json data create Role
+func (obj *Role) JSONNew(jsonbyts []byte) *Role {
+	obj.error(json.Unmarshal(jsonbyts, obj.role))
+	return obj
+}
+
+// YAMLNew use yaml data create Role
+func (obj *Role) YAMLNew(yamlbyts []byte) *Role {
+	obj.error(yaml.Unmarshal(yamlbyts, obj.role))
+	return obj
+}
+
+// JSONNewTemplate use json template and values create Role,
+// the template is rendered by RenderTemplate() and must be valid json
+func (obj *Role) JSONNewTemplate(tpl []byte, values interface{}) *Role {
+	jsonbyts, err := renderJSONTemplate(tpl, values)
+	if err != nil {
+		obj.error(err)
+		return obj
+	}
+	return obj.JSONNew(jsonbyts)
+}
+
+// YAMLNewTemplate use yaml template and values create Role,
+// the template is rendered by RenderTemplate() and must be valid yaml
+func (obj *Role) YAMLNewTemplate(tpl []byte, values interface{}) *Role {
+	yamlbyts, err := renderYAMLTemplate(tpl, values)
+	if err != nil {
+		obj.error(err)
+		return obj
+	}
+	return obj.YAMLNew(yamlbyts)
+}
+
+// Replace replace role by Kubernetes resource object
+func (obj *Role) Replace(role *v1beta1.Role) *Role {
+	if role != nil {
+		obj.role = role
+	}
+	return obj
+}
+
+// SetName set Role name
+func (obj *Role) SetName(name string) *Role {
+	obj.role.SetName(name)
+	return obj
+}
+
+// SetNamespace set Role namespace
+func (obj *Role) SetNamespace(namespace string) *Role {
+	obj.role.SetNamespace(namespace)
+	return obj
+}
+
+// SetNamespaceAndName set Role namespace and name
+func (obj *Role) SetNamespaceAndName(namespace, name string) *Role {
+	obj.SetNamespace(namespace)
+	obj.SetName(name)
+	return obj
+}
+
+// SetLabels set Role labels
+func (obj *Role) SetLabels(labels map[string]string) *Role {
+	obj.role.SetLabels(labels)
+	return obj
+}
+
+// SetAnnotations set Role annotations
+func (obj *Role) SetAnnotations(annotations map[string]string) *Role {
+	if len(obj.role.Annotations) <= 0 {
+		obj.role.Annotations = annotations
+		return obj
+	}
+	for key, value := range annotations {
+		obj.role.Annotations[key] = value
+	}
+	return obj
+}
+
+// SetRole append role rule, it can be called many times to set many rules
+// verbs is func method. such as "get", "watch", "list","create", "delete" ..., you can set "*" if you want to use all the func method
+// apiGroups is resource apiGroup. such as "", "apps", "rbac.authorization.k8s.io"... , you can set "*" if you want to use all the resource apiGroup
+// resources is resources object. such as "daemonsets", "deployments","replicasets", you can set "*" if you want to use all the resource object.
+func (obj *Role) SetRole(verbs, apiGroups, resources []string) *Role {
+	return obj.SetRule(PolicyRule{Verbs: verbs, APIGroups: apiGroups, Resources: resources})
+}
+
+// SetRule append role rule which supports resourceNames, nonResourceURLs is not allowed in Role
+func (obj *Role) SetRule(rule PolicyRule) *Role {
+	obj.role.Rules = append(obj.role.Rules, rule.ToK8s())
+	return obj
+}
+
+// setRules append rules
+func (obj *Role) setRules(rules []v1beta1.PolicyRule) *Role {
+	obj.role.Rules = append(obj.role.Rules, rules...)
+	return obj
+}
+
+// SetPreset append built-in rules:PresetReadOnly,PresetNamespaceEditor,PresetSecretsReader
+func (obj *Role) SetPreset(preset RolePreset) *Role {
+	rules := preset.Rules()
+	if len(rules) <= 0 {
+		obj.error(fmt.Errorf("SetPreset err,preset %s is not supported", preset))
+		return obj
+	}
+	return obj.setRules(rules)
+}
+
+// Release release Role on Kubernetes
+func (obj *Role) Release() (*v1beta1.Role, error) {
+	role, err := obj.Finish()
+	if err != nil {
+		return nil, err
+	}
+	client, err := GetKubeClient()
+	if err != nil {
+		return nil, err
+	}
+	return client.RbacV1beta1().Roles(role.GetNamespace()).Create(role)
+}
+
+// Apply it will be updated when this resource object exists in K8s,
+// it will be created when it does not exist.
+func (obj *Role) Apply() (*v1beta1.Role, error) {
+	role, err := obj.Finish()
+	if err != nil {
+		return nil, err
+	}
+	client, err := GetKubeClient()
+	if err != nil {
+		return nil, err
+	}
+	_, err = client.RbacV1beta1().Roles(role.GetNamespace()).Get(role.GetName(), metav1.GetOptions{})
+	if err != nil {
+		return client.RbacV1beta1().Roles(role.GetNamespace()).Create(role)
+	}
+	return client.RbacV1beta1().Roles(role.GetNamespace()).Update(role)
+}
+
+// Delete delete Role on Kubernetes
+func (obj *Role) Delete() error {
+	role, err := obj.Finish()
+	if err != nil {
+		return err
+	}
+	client, err := GetKubeClient()
+	if err != nil {
+		return err
+	}
+	return client.RbacV1beta1().Roles(role.GetNamespace()).Delete(role.GetName(), &metav1.DeleteOptions{})
+}
+
+func (obj *Role) error(err error) {
+	if obj.err != nil {
+		return
+	}
+	obj.err = err
+}
+
+// verify check Role necessary value, input the default field and input related data.
+func (obj *Role) verify() {
+	if obj.err != nil {
+		return
+	}
+	if !verifyString(obj.role.GetName()) {
+		obj.err = errors.New("Role name is not allowed to be empty")
+		return
+	}
+	if !verifyString(obj.role.GetNamespace()) {
+		obj.role.SetNamespace("default")
+	}
+	if len(obj.role.Rules) <= 0 {
+		obj.err = errors.New("Role rules is not allowed to be empty,you can call SetRole() input")
+		return
+	}
+	if err := verifyPolicyRules("Role", obj.role.Rules, true); err != nil {
+		obj.err = err
+		return
+	}
+	obj.role.APIVersion = "rbac.authorization.k8s.io/v1beta1"
+	obj.role.Kind = "Role"
+}
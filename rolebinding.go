@@ -0,0 +1,231 @@
+package beku
+
+import (
+	"encoding/json"
+	"errors"
+
+	"github.com/ghodss/yaml"
+	"k8s.io/api/rbac/v1beta1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+// RoleBinding include kubernetes resource object RoleBinding and error,
+// RoleBinding grants permissions of Role or ClusterRole in it's own namespace
+type RoleBinding struct {
+	rb  *v1beta1.RoleBinding
+	err error
+}
+
+// NewRoleBinding create RoleBinding and chain function call begin with this function.
+func NewRoleBinding() *RoleBinding { return &RoleBinding{rb: &v1beta1.RoleBinding{}} }
+
+// Finish Chain function call end with this function
+// return Kubernetes resource object RoleBinding and error.
+// In the function, it will check necessary parameters,input the default field
+func (obj *RoleBinding) Finish() (*v1beta1.RoleBinding, error) {
+	obj.verify()
+	return obj.rb, obj.err
+}
+
+// JSONNew use json data create RoleBinding
+func (obj *RoleBinding) JSONNew(jsonbyts []byte) *RoleBinding {
+	obj.error(json.Unmarshal(jsonbyts, obj.rb))
+	return obj
+}
+
+// YAMLNew use yaml data create RoleBinding
+func (obj *RoleBinding) YAMLNew(yamlbyts []byte) *RoleBinding {
+	obj.error(yaml.Unmarshal(yamlbyts, obj.rb))
+	return obj
+}
+
+// JSONNewTemplate use json template and values create RoleBinding,
+// the template is rendered by RenderTemplate() and must be valid json
+func (obj *RoleBinding) JSONNewTemplate(tpl []byte, values interface{}) *RoleBinding {
+	jsonbyts, err := renderJSONTemplate(tpl, values)
+	if err != nil {
+		obj.error(err)
+		return obj
+	}
+	return obj.JSONNew(jsonbyts)
+}
+
+// YAMLNewTemplate use yaml template and values create RoleBinding,
+// the template is rendered by RenderTemplate() and must be valid yaml
+func (obj *RoleBinding) YAMLNewTemplate(tpl []byte, values interface{}) *RoleBinding {
+	yamlbyts, err := renderYAMLTemplate(tpl, values)
+	if err != nil {
+		obj.error(err)
+		return obj
+	}
+	return obj.YAMLNew(yamlbyts)
+}
+
+// Replace replace rb by Kubernetes resource object
+func (obj *RoleBinding) Replace(rb *v1beta1.RoleBinding) *RoleBinding {
+	if rb != nil {
+		obj.rb = rb
+	}
+	return obj
+}
+
+// SetName set RoleBinding name
+func (obj *RoleBinding) SetName(name string) *RoleBinding {
+	obj.rb.SetName(name)
+	return obj
+}
+
+// SetNamespace set RoleBinding namespace
+func (obj *RoleBinding) SetNamespace(namespace string) *RoleBinding {
+	obj.rb.SetNamespace(namespace)
+	return obj
+}
+
+// SetNamespaceAndName set RoleBinding namespace and name
+func (obj *RoleBinding) SetNamespaceAndName(namespace, name string) *RoleBinding {
+	obj.SetNamespace(namespace)
+	obj.SetName(name)
+	return obj
+}
+
+// SetLabels set RoleBinding labels
+func (obj *RoleBinding) SetLabels(labels map[string]string) *RoleBinding {
+	obj.rb.SetLabels(labels)
+	return obj
+}
+
+// SetAnnotations set RoleBinding annotations
+func (obj *RoleBinding) SetAnnotations(annotations map[string]string) *RoleBinding {
+	if len(obj.rb.Annotations) <= 0 {
+		obj.rb.Annotations = annotations
+		return obj
+	}
+	for key, value := range annotations {
+		obj.rb.Annotations[key] = value
+	}
+	return obj
+}
+
+// Subject set RoleBinding subject
+// kind only support "User", "Group", "ServiceAccount"
+// namespace is namespace of ServiceAccount,default is RoleBinding namespace, it is ignored when kind is "User" or "Group"
+func (obj *RoleBinding) Subject(name string, kind SubKind, namespace string) *RoleBinding {
+	subject, err := newSubject(name, kind, namespace)
+	if err != nil {
+		obj.error(err)
+		return obj
+	}
+	obj.rb.Subjects = append(obj.rb.Subjects, subject)
+	return obj
+}
+
+// SetRoleRef set RoleBinding RoleRef to Role in the same namespace
+func (obj *RoleBinding) SetRoleRef(name string) *RoleBinding {
+	return obj.setRoleRef("Role", name)
+}
+
+// SetClusterRoleRef set RoleBinding RoleRef to ClusterRole,
+// the permissions of ClusterRole are only granted in RoleBinding namespace
+func (obj *RoleBinding) SetClusterRoleRef(name string) *RoleBinding {
+	return obj.setRoleRef("ClusterRole", name)
+}
+
+func (obj *RoleBinding) setRoleRef(kind, name string) *RoleBinding {
+	if emptyString(name) {
+		obj.error(errors.New("set SetRoleRef err. name is not allow to be empty"))
+		return obj
+	}
+	obj.rb.RoleRef = v1beta1.RoleRef{
+		APIGroup: rbacAPIGroup,
+		Kind:     kind,
+		Name:     name,
+	}
+	return obj
+}
+
+// Release release RoleBinding on Kubernetes
+func (obj *RoleBinding) Release() (*v1beta1.RoleBinding, error) {
+	rb, err := obj.Finish()
+	if err != nil {
+		return nil, err
+	}
+	client, err := GetKubeClient()
+	if err != nil {
+		return nil, err
+	}
+	return client.RbacV1beta1().RoleBindings(rb.GetNamespace()).Create(rb)
+}
+
+// Apply it will be updated when this resource object exists in K8s,
+// it will be created when it does not exist.
+// RoleRef is immutable on Kubernetes, so RoleBinding will be recreated when RoleRef is changed.
+func (obj *RoleBinding) Apply() (*v1beta1.RoleBinding, error) {
+	rb, err := obj.Finish()
+	if err != nil {
+		return nil, err
+	}
+	client, err := GetKubeClient()
+	if err != nil {
+		return nil, err
+	}
+	present, err := client.RbacV1beta1().RoleBindings(rb.GetNamespace()).Get(rb.GetName(), metav1.GetOptions{})
+	if err != nil {
+		return client.RbacV1beta1().RoleBindings(rb.GetNamespace()).Create(rb)
+	}
+	if present.RoleRef != rb.RoleRef {
+		if err = client.RbacV1beta1().RoleBindings(rb.GetNamespace()).Delete(rb.GetName(), &metav1.DeleteOptions{}); err != nil {
+			return nil, err
+		}
+		return client.RbacV1beta1().RoleBindings(rb.GetNamespace()).Create(rb)
+	}
+	return client.RbacV1beta1().RoleBindings(rb.GetNamespace()).Update(rb)
+}
+
+// Delete delete RoleBinding on Kubernetes
+func (obj *RoleBinding) Delete() error {
+	rb, err := obj.Finish()
+	if err != nil {
+		return err
+	}
+	client, err := GetKubeClient()
+	if err != nil {
+		return err
+	}
+	return client.RbacV1beta1().RoleBindings(rb.GetNamespace()).Delete(rb.GetName(), &metav1.DeleteOptions{})
+}
+
+func (obj *RoleBinding) error(err error) {
+	if obj.err != nil {
+		return
+	}
+	obj.err = err
+}
+
+// verify check RoleBinding necessary value, input the default field and input related data.
+func (obj *RoleBinding) verify() {
+	if obj.err != nil {
+		return
+	}
+	if !verifyString(obj.rb.GetName()) {
+		obj.err = errors.New("RoleBinding name is not allowed to be empty")
+		return
+	}
+	if !verifyString(obj.rb.GetNamespace()) {
+		obj.rb.SetNamespace("default")
+	}
+	for index := range obj.rb.Subjects {
+		if obj.rb.Subjects[index].Kind == string(SA) && !verifyString(obj.rb.Subjects[index].Namespace) {
+			obj.rb.Subjects[index].Namespace = obj.rb.GetNamespace()
+		}
+	}
+	if err := verifySubjects("RoleBinding", obj.rb.Subjects); err != nil {
+		obj.err = err
+		return
+	}
+	if !verifyString(obj.rb.RoleRef.Name) {
+		obj.err = errors.New("RoleBinding RoleRef is not allowed to be empty,you can call SetRoleRef() or SetClusterRoleRef() input")
+		return
+	}
+	obj.rb.APIVersion = "rbac.authorization.k8s.io/v1beta1"
+	obj.rb.Kind = "RoleBinding"
+}
@@ -1,8 +1,13 @@
 package beku
 
 import (
+	"crypto/tls"
+	"crypto/x509"
 	"encoding/json"
+	"encoding/pem"
 	"errors"
+	"fmt"
+	"time"
 
 	"github.com/ghodss/yaml"
 	"k8s.io/api/core/v1"
@@ -13,11 +18,84 @@ import (
 type Secret struct {
 	sc  *v1.Secret
 	err error
+	// nameSuffixHash append content hash to name, baseName is the name without hash
+	nameSuffixHash bool
+	baseName       string
 }
 
 // NewSecret create Secret and chain function call begin with this function.
 func NewSecret() *Secret { return &Secret{sc: &v1.Secret{}} }
 
+// NewDockerRegistrySecret create Secret which is used to pull image from private registry,
+// it can be used by Deployment.SetImagePullSecrets() or ServiceAccount.SetImagePullSecrets().
+// server is the registry address, eg: https://index.docker.io/v1/, registry.example.com:5000
+func NewDockerRegistrySecret(server, username, password, email string) *Secret {
+	obj := &Secret{sc: &v1.Secret{Type: v1.SecretTypeDockerConfigJson}}
+	if !verifyString(server) || !verifyString(username) || !verifyString(password) {
+		obj.error(errors.New("NewDockerRegistrySecret err,server,username and password are not allowed to be empty"))
+		return obj
+	}
+	config := map[string]map[string]map[string]string{
+		"auths": {
+			server: {
+				"username": username,
+				"password": password,
+				"email":    email,
+				"auth":     Base64Encode([]byte(username + ":" + password)),
+			},
+		},
+	}
+	byts, err := json.Marshal(config)
+	if err != nil {
+		obj.error(fmt.Errorf("NewDockerRegistrySecret err,%v", err))
+		return obj
+	}
+	obj.sc.Data = map[string][]byte{v1.DockerConfigJsonKey: byts}
+	return obj
+}
+
+// NewTLSSecret create Secret which contains PEM encoded TLS certificate and private key,
+// it can be used by Ingress.SetTLS(). the private key must match the certificate,
+// the expired certificate is allowed so the Secret can be rendered during renewal, you can call TLSNotAfter() to check its expiry
+func NewTLSSecret(certPEM, keyPEM []byte) *Secret {
+	obj := &Secret{sc: &v1.Secret{Type: v1.SecretTypeTLS}}
+	if err := verifyTLSKeyPair(certPEM, keyPEM); err != nil {
+		obj.error(fmt.Errorf("NewTLSSecret err,%v", err))
+		return obj
+	}
+	obj.sc.Data = map[string][]byte{v1.TLSCertKey: certPEM, v1.TLSPrivateKeyKey: keyPEM}
+	return obj
+}
+
+// NewBasicAuthSecret create Secret which contains username and password for basic authentication,
+// at least one of username and password is required
+func NewBasicAuthSecret(username, password string) *Secret {
+	obj := &Secret{sc: &v1.Secret{Type: v1.SecretTypeBasicAuth, Data: map[string][]byte{}}}
+	if !verifyString(username) && !verifyString(password) {
+		obj.error(errors.New("NewBasicAuthSecret err,username and password are not allowed to be empty at the same time"))
+		return obj
+	}
+	if verifyString(username) {
+		obj.sc.Data[v1.BasicAuthUsernameKey] = []byte(username)
+	}
+	if verifyString(password) {
+		obj.sc.Data[v1.BasicAuthPasswordKey] = []byte(password)
+	}
+	return obj
+}
+
+// NewSSHAuthSecret create Secret which contains PEM encoded private SSH key for SSH authentication,
+// eg: the content of ~/.ssh/id_rsa
+func NewSSHAuthSecret(privateKeyPEM []byte) *Secret {
+	obj := &Secret{sc: &v1.Secret{Type: v1.SecretTypeSSHAuth}}
+	if block, _ := pem.Decode(privateKeyPEM); block == nil {
+		obj.error(errors.New("NewSSHAuthSecret err,privateKey is not PEM encoded"))
+		return obj
+	}
+	obj.sc.Data = map[string][]byte{v1.SSHAuthPrivateKey: privateKeyPEM}
+	return obj
+}
+
 // Finish chain function call end with this function.
 // return obj(Kubernetes resource object) and error
 // In the function, it will check necessary parameters、input the default field。
@@ -38,6 +116,28 @@ func (obj *Secret) YAMLNew(yamlbyts []byte) *Secret {
 	return obj
 }
 
+// JSONNewTemplate use json template and values create Secret,
+// the template is rendered by RenderTemplate() and must be valid json
+func (obj *Secret) JSONNewTemplate(tpl []byte, values interface{}) *Secret {
+	jsonbyts, err := renderJSONTemplate(tpl, values)
+	if err != nil {
+		obj.error(err)
+		return obj
+	}
+	return obj.JSONNew(jsonbyts)
+}
+
+// YAMLNewTemplate use yaml template and values create Secret,
+// the template is rendered by RenderTemplate() and must be valid yaml
+func (obj *Secret) YAMLNewTemplate(tpl []byte, values interface{}) *Secret {
+	yamlbyts, err := renderYAMLTemplate(tpl, values)
+	if err != nil {
+		obj.error(err)
+		return obj
+	}
+	return obj.YAMLNew(yamlbyts)
+}
+
 // Replace replace Secret by Kubernetes resource object
 func (obj *Secret) Replace(sec *v1.Secret) *Secret {
 	if sec != nil {
@@ -49,6 +149,7 @@ func (obj *Secret) Replace(sec *v1.Secret) *Secret {
 // SetName set Secret name
 func (obj *Secret) SetName(name string) *Secret {
 	obj.sc.SetName(name)
+	obj.baseName = ""
 	return obj
 }
 
@@ -61,7 +162,7 @@ func (obj *Secret) SetNamespace(namespace string) *Secret {
 // SetNamespaceAndName set Secret namespace and name
 func (obj *Secret) SetNamespaceAndName(namespace, name string) *Secret {
 	obj.sc.SetNamespace(namespace)
-	obj.sc.SetName(name)
+	obj.SetName(name)
 	return obj
 }
 
@@ -83,7 +184,8 @@ func (obj *Secret) SetDataBytes(bytes map[string][]byte) *Secret {
 	return obj
 }
 
-// SetType set Secret type,have Opaque and kubernetes.io/service-account-token
+// SetType set Secret type,have Opaque, kubernetes.io/service-account-token and the other SecretType,
+// Finish() checks the required keys of the type
 // Opaque user-defined data
 // kubernetes.io/service-account-token is used to kubernetes apiserver,because apiserver need to auth
 func (obj *Secret) SetType(secType SecretType) *Secret {
@@ -91,6 +193,126 @@ func (obj *Secret) SetType(secType SecretType) *Secret {
 	return obj
 }
 
+// TLSNotAfter return the expiry of TLS certificate in Secret,
+// Finish() does not check the expiry, eg: time.Now().After(notAfter) means the certificate is expired
+func (obj *Secret) TLSNotAfter() (time.Time, error) {
+	certPEM, ok := obj.value(v1.TLSCertKey)
+	if !ok {
+		return time.Time{}, fmt.Errorf("Secret key %s is not found", v1.TLSCertKey)
+	}
+	block, _ := pem.Decode(certPEM)
+	if block == nil {
+		return time.Time{}, errors.New("TLS certificate is not PEM encoded")
+	}
+	cert, err := x509.ParseCertificate(block.Bytes)
+	if err != nil {
+		return time.Time{}, err
+	}
+	return cert.NotAfter, nil
+}
+
+// value return the value of key from data or stringData
+func (obj *Secret) value(key string) ([]byte, bool) {
+	if value, ok := obj.sc.StringData[key]; ok {
+		return []byte(value), true
+	}
+	value, ok := obj.sc.Data[key]
+	return value, ok
+}
+
+// verifyTLSKeyPair check the private key matches the certificate,
+// the expiry is not checked, it is reported by TLSNotAfter()
+func verifyTLSKeyPair(certPEM, keyPEM []byte) error {
+	if _, err := tls.X509KeyPair(certPEM, keyPEM); err != nil {
+		return fmt.Errorf("TLS certificate and private key are invalid:%v", err)
+	}
+	return nil
+}
+
+// verifyType check the required keys of Secret type
+func (obj *Secret) verifyType() error {
+	switch obj.sc.Type {
+	case v1.SecretTypeDockerConfigJson, v1.SecretTypeDockercfg:
+		key := v1.DockerConfigJsonKey
+		if obj.sc.Type == v1.SecretTypeDockercfg {
+			key = v1.DockerConfigKey
+		}
+		value, ok := obj.value(key)
+		if !ok {
+			return fmt.Errorf("Secret type %s requires key %s", obj.sc.Type, key)
+		}
+		var config map[string]interface{}
+		if err := json.Unmarshal(value, &config); err != nil {
+			return fmt.Errorf("Secret key %s is not valid json:%v", key, err)
+		}
+	case v1.SecretTypeBasicAuth:
+		_, hasUsername := obj.value(v1.BasicAuthUsernameKey)
+		_, hasPassword := obj.value(v1.BasicAuthPasswordKey)
+		if !hasUsername && !hasPassword {
+			return fmt.Errorf("Secret type %s requires at least one of key %s and %s", obj.sc.Type, v1.BasicAuthUsernameKey, v1.BasicAuthPasswordKey)
+		}
+	case v1.SecretTypeSSHAuth:
+		if _, ok := obj.value(v1.SSHAuthPrivateKey); !ok {
+			return fmt.Errorf("Secret type %s requires key %s", obj.sc.Type, v1.SSHAuthPrivateKey)
+		}
+	case v1.SecretTypeTLS:
+		certPEM, hasCert := obj.value(v1.TLSCertKey)
+		keyPEM, hasKey := obj.value(v1.TLSPrivateKeyKey)
+		if !hasCert || !hasKey {
+			return fmt.Errorf("Secret type %s requires key %s and %s", obj.sc.Type, v1.TLSCertKey, v1.TLSPrivateKeyKey)
+		}
+		if err := verifyTLSKeyPair(certPEM, keyPEM); err != nil {
+			return err
+		}
+	case v1.SecretTypeServiceAccountToken:
+		if !verifyString(obj.sc.Annotations[v1.ServiceAccountNameKey]) {
+			return fmt.Errorf("Secret type %s requires annotation %s", obj.sc.Type, v1.ServiceAccountNameKey)
+		}
+	}
+	return nil
+}
+
+// SetNameSuffixHash append the hash of data to Secret name when hash is true, eg: nginx-conf-5f7d9c6b8a,
+// a new Secret is created when data is changed, so the workloads which reference it are rolled out.
+// the references of workloads, Ingresses and ServiceAccounts in the same Bundle are renamed automatically,
+// and you can call GarbageCollect() to delete the old generations after the workloads are applied
+func (obj *Secret) SetNameSuffixHash(hash bool) *Secret {
+	obj.nameSuffixHash = hash
+	return obj
+}
+
+// GarbageCollect delete the old generations of Secret which has name suffix hash,
+// the generations which are still referenced by workloads(include ReplicaSets for rollback), Ingresses and ServiceAccounts are kept
+func (obj *Secret) GarbageCollect() error {
+	sc, err := obj.Finish()
+	if err != nil {
+		return err
+	}
+	if !obj.nameSuffixHash {
+		return errors.New("GarbageCollect err,it is only allowed when SetNameSuffixHash(true) is called")
+	}
+	return gcConfigGenerations("Secret", sc.GetNamespace(), obj.baseName, sc.GetName())
+}
+
+// hashName append the hash of content to name and label the base name
+func (obj *Secret) hashName(content interface{}) error {
+	if !verifyString(obj.baseName) {
+		obj.baseName = hashBaseName(obj.sc.GetName(), obj.sc.GetLabels())
+	}
+	name, err := hashedName(obj.baseName, content)
+	if err != nil {
+		return err
+	}
+	obj.sc.SetName(name)
+	labels := obj.sc.GetLabels()
+	if labels == nil {
+		labels = make(map[string]string)
+	}
+	labels[NameHashBaseKey] = obj.baseName
+	obj.sc.SetLabels(labels)
+	return nil
+}
+
 // Release release Secret on Kubernetes
 func (obj *Secret) Release() (*v1.Secret, error) {
 	sec, err := obj.Finish()
@@ -142,6 +364,17 @@ func (obj *Secret) verify() {
 		obj.err = errors.New("secret data is not allowed to be empty")
 		return
 	}
+	if err := obj.verifyType(); err != nil {
+		obj.err = err
+		return
+	}
+	if obj.nameSuffixHash {
+		content := map[string]interface{}{"kind": "Secret", "type": obj.sc.Type, "data": obj.sc.Data, "stringData": obj.sc.StringData}
+		if err := obj.hashName(content); err != nil {
+			obj.err = fmt.Errorf("Secret SetNameSuffixHash err,%v", err)
+			return
+		}
+	}
 	obj.sc.Kind = "Secret"
 	obj.sc.APIVersion = "v1"
 
@@ -8,12 +8,15 @@ import (
 	"github.com/ghodss/yaml"
 	"k8s.io/api/core/v1"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/util/validation"
 )
 
 // Service include Kubernetes resource object Service and error
 type Service struct {
-	svc *v1.Service
-	err error
+	svc       *v1.Service
+	endpoints *Endpoints
+	err       error
 }
 
 // NewSvc create service(svc) and chain function call begin with this function.
@@ -40,6 +43,28 @@ func (obj *Service) YAMLNew(yamlbyts []byte) *Service {
 	return obj
 }
 
+// JSONNewTemplate use json template and values create Service,
+// the template is rendered by RenderTemplate() and must be valid json
+func (obj *Service) JSONNewTemplate(tpl []byte, values interface{}) *Service {
+	jsonbyts, err := renderJSONTemplate(tpl, values)
+	if err != nil {
+		obj.error(err)
+		return obj
+	}
+	return obj.JSONNew(jsonbyts)
+}
+
+// YAMLNewTemplate use yaml template and values create Service,
+// the template is rendered by RenderTemplate() and must be valid yaml
+func (obj *Service) YAMLNewTemplate(tpl []byte, values interface{}) *Service {
+	yamlbyts, err := renderYAMLTemplate(tpl, values)
+	if err != nil {
+		obj.error(err)
+		return obj
+	}
+	return obj.YAMLNew(yamlbyts)
+}
+
 // Replace replace Service by Kubernetes resource object
 func (obj *Service) Replace(svc *v1.Service) *Service {
 	if svc != nil {
@@ -143,23 +168,87 @@ func (obj *Service) Headless() *Service {
 	return obj
 }
 
-// Release release Service on Kubernetes
-func (obj *Service) Release() (*v1.Service, error) {
+// SetExternalName set service(svc) type to ExternalName,
+// the service is a DNS CNAME of host outside the cluster, such as "db.example.com".
+// selector and ports are not allowed in ExternalName service
+func (obj *Service) SetExternalName(host string) *Service {
+	if errs := validation.IsDNS1123Subdomain(host); len(errs) > 0 {
+		obj.error(fmt.Errorf("SetExternalName err,host %s is invalid:%v", host, errs))
+		return obj
+	}
+	obj.svc.Spec.Type = v1.ServiceTypeExternalName
+	obj.svc.Spec.ExternalName = host
+	return obj
+}
+
+// WithEndpoints attach Endpoints to service(svc) without selector, so the service routes to addresses of Endpoints,
+// the namespace and name of Endpoints will be set to service namespace and name,
+// Endpoints port names must be the same as service port names
+func (obj *Service) WithEndpoints(ep *Endpoints) *Service {
+	if ep == nil {
+		obj.error(errors.New("WithEndpoints err,endpoints is not allowed to be nil"))
+		return obj
+	}
+	obj.endpoints = ep
+	return obj
+}
+
+// objects finish Service with it's Endpoints, Service is the first one
+func (obj *Service) objects() ([]runtime.Object, error) {
 	svc, err := obj.Finish()
 	if err != nil {
 		return nil, err
 	}
+	if obj.endpoints == nil {
+		return []runtime.Object{svc}, nil
+	}
+	ep, err := obj.endpoints.SetNamespaceAndName(svc.GetNamespace(), svc.GetName()).Finish()
+	if err != nil {
+		return nil, err
+	}
+	if err = obj.endpoints.matchService(svc); err != nil {
+		return nil, err
+	}
+	return []runtime.Object{svc, ep}, nil
+}
+
+// finishAll finish Service with Endpoints, so their errors are returned before releasing
+func (obj *Service) finishAll() (*v1.Service, error) {
+	objs, err := obj.objects()
+	if err != nil {
+		return nil, err
+	}
+	return objs[0].(*v1.Service), nil
+}
+
+// Release release Service on Kubernetes,
+// Endpoints will be released too when it is set by WithEndpoints()
+func (obj *Service) Release() (*v1.Service, error) {
+	svc, err := obj.finishAll()
+	if err != nil {
+		return nil, err
+	}
 	client, err := GetKubeClient()
 	if err != nil {
 		return nil, err
 	}
-	return client.CoreV1().Services(svc.GetNamespace()).Create(svc)
+	svc, err = client.CoreV1().Services(svc.GetNamespace()).Create(svc)
+	if err != nil {
+		return nil, err
+	}
+	if obj.endpoints != nil {
+		if _, err = obj.endpoints.Release(); err != nil {
+			return nil, err
+		}
+	}
+	return svc, nil
 }
 
 // Apply  it will be updated when this resource object exists in K8s,
 // it will be created when it does not exist.
+// Endpoints will be applied too when it is set by WithEndpoints()
 func (obj *Service) Apply() (*v1.Service, error) {
-	svc, err := obj.Finish()
+	svc, err := obj.finishAll()
 	if err != nil {
 		return nil, err
 	}
@@ -169,9 +258,19 @@ func (obj *Service) Apply() (*v1.Service, error) {
 	}
 	_, err = client.CoreV1().Services(svc.GetNamespace()).Get(svc.GetName(), metav1.GetOptions{})
 	if err != nil {
-		return client.CoreV1().Services(svc.GetNamespace()).Create(svc)
+		svc, err = client.CoreV1().Services(svc.GetNamespace()).Create(svc)
+	} else {
+		svc, err = client.CoreV1().Services(svc.GetNamespace()).Update(svc)
+	}
+	if err != nil {
+		return nil, err
+	}
+	if obj.endpoints != nil {
+		if _, err = obj.endpoints.Apply(); err != nil {
+			return nil, err
+		}
 	}
-	return client.CoreV1().Services(svc.GetNamespace()).Update(svc)
+	return svc, nil
 }
 
 func (obj *Service) error(err error) {
@@ -190,6 +289,10 @@ func (obj *Service) verify() {
 		obj.err = errors.New("svc.Name is not allowed to be empty")
 		return
 	}
+	if obj.svc.Spec.Type == v1.ServiceTypeExternalName {
+		obj.verifyExternalName()
+		return
+	}
 	portLen := len(obj.svc.Spec.Ports)
 	if verifyMap(obj.svc.Spec.Selector) {
 		if portLen < 1 {
@@ -214,3 +317,25 @@ func (obj *Service) verify() {
 	obj.svc.Kind = "Service"
 	obj.svc.APIVersion = "v1"
 }
+
+// verifyExternalName check ExternalName service has externalName and has no selector,ports and clusterIP
+func (obj *Service) verifyExternalName() {
+	if !verifyString(obj.svc.Spec.ExternalName) {
+		obj.err = errors.New("svc.Spec.ExternalName is not allowed to be empty when svc.Spec.Type is ExternalName,you can call SetExternalName() input")
+		return
+	}
+	if verifyMap(obj.svc.Spec.Selector) {
+		obj.err = errors.New("svc.Spec.Selector is not allowed when svc.Spec.Type is ExternalName")
+		return
+	}
+	if len(obj.svc.Spec.Ports) > 0 {
+		obj.err = errors.New("svc.Spec.Ports is not allowed when svc.Spec.Type is ExternalName")
+		return
+	}
+	if verifyString(obj.svc.Spec.ClusterIP) {
+		obj.err = errors.New("svc.Spec.ClusterIP is not allowed when svc.Spec.Type is ExternalName")
+		return
+	}
+	obj.svc.Kind = "Service"
+	obj.svc.APIVersion = "v1"
+}
@@ -1,9 +1,12 @@
 package beku
 
 import (
+	"encoding/json"
 	"errors"
 
+	"github.com/ghodss/yaml"
 	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 )
 
 // ServiceAccount include kubernetes resource object ServiceAccount(sa) and error
@@ -23,24 +26,207 @@ func (obj *ServiceAccount) Finish() (*corev1.ServiceAccount, error) {
 	return obj.sa, obj.err
 }
 
+// JSONNew use json data create ServiceAccount(sa)
+func (obj *ServiceAccount) JSONNew(jsonbyts []byte) *ServiceAccount {
+	obj.error(json.Unmarshal(jsonbyts, obj.sa))
+	return obj
+}
+
+// YAMLNew use yaml data create ServiceAccount(sa)
+func (obj *ServiceAccount) YAMLNew(yamlbyts []byte) *ServiceAccount {
+	obj.error(yaml.Unmarshal(yamlbyts, obj.sa))
+	return obj
+}
+
+// JSONNewTemplate use json template and values create ServiceAccount,
+// the template is rendered by RenderTemplate() and must be valid json
+func (obj *ServiceAccount) JSONNewTemplate(tpl []byte, values interface{}) *ServiceAccount {
+	jsonbyts, err := renderJSONTemplate(tpl, values)
+	if err != nil {
+		obj.error(err)
+		return obj
+	}
+	return obj.JSONNew(jsonbyts)
+}
+
+// YAMLNewTemplate use yaml template and values create ServiceAccount,
+// the template is rendered by RenderTemplate() and must be valid yaml
+func (obj *ServiceAccount) YAMLNewTemplate(tpl []byte, values interface{}) *ServiceAccount {
+	yamlbyts, err := renderYAMLTemplate(tpl, values)
+	if err != nil {
+		obj.error(err)
+		return obj
+	}
+	return obj.YAMLNew(yamlbyts)
+}
+
+// Replace replace sa by Kubernetes resource object
+func (obj *ServiceAccount) Replace(sa *corev1.ServiceAccount) *ServiceAccount {
+	if sa != nil {
+		obj.sa = sa
+	}
+	return obj
+}
+
+// SetName set ServiceAccount name
+func (obj *ServiceAccount) SetName(name string) *ServiceAccount {
+	obj.sa.SetName(name)
+	return obj
+}
+
+// SetNamespace set ServiceAccount namespace, default is "default"
+func (obj *ServiceAccount) SetNamespace(namespace string) *ServiceAccount {
+	obj.sa.SetNamespace(namespace)
+	return obj
+}
+
+// SetNamespaceAndName set namespace and name, name is not allowed to be empty
+func (obj *ServiceAccount) SetNamespaceAndName(namespace, name string) *ServiceAccount {
+	obj.SetNamespace(namespace)
+	obj.SetName(name)
+	return obj
+}
+
+// SetNamespceAndName set namespace and name, name is not allowed to be empty, name default is ""
+// Deprecated: use SetNamespaceAndName() instead
+func (obj *ServiceAccount) SetNamespceAndName(namespace, name string) *ServiceAccount {
+	return obj.SetNamespaceAndName(namespace, name)
+}
+
+// SetLabels set ServiceAccount labels
+func (obj *ServiceAccount) SetLabels(labels map[string]string) *ServiceAccount {
+	obj.sa.SetLabels(labels)
+	return obj
+}
+
+// SetAnnotations set ServiceAccount annotations
+func (obj *ServiceAccount) SetAnnotations(annotations map[string]string) *ServiceAccount {
+	if len(obj.sa.Annotations) <= 0 {
+		obj.sa.Annotations = annotations
+		return obj
+	}
+	for key, value := range annotations {
+		obj.sa.Annotations[key] = value
+	}
+	return obj
+}
+
+// SetImagePullSecrets set the names of docker-registry Secrets,
+// they are used by every Pod which uses this ServiceAccount to pull images
+func (obj *ServiceAccount) SetImagePullSecrets(secretNames ...string) *ServiceAccount {
+	for _, name := range secretNames {
+		if !verifyString(name) {
+			obj.error(errors.New("SetImagePullSecrets err,secret name is not allowed to be empty"))
+			return obj
+		}
+		exist := false
+		for _, secret := range obj.sa.ImagePullSecrets {
+			if secret.Name == name {
+				exist = true
+				break
+			}
+		}
+		if !exist {
+			obj.sa.ImagePullSecrets = append(obj.sa.ImagePullSecrets, corev1.LocalObjectReference{Name: name})
+		}
+	}
+	return obj
+}
+
+// SetSecrets set the names of Secrets which are allowed to be used by Pods running with this ServiceAccount
+func (obj *ServiceAccount) SetSecrets(secretNames ...string) *ServiceAccount {
+	for _, name := range secretNames {
+		if !verifyString(name) {
+			obj.error(errors.New("SetSecrets err,secret name is not allowed to be empty"))
+			return obj
+		}
+		exist := false
+		for _, secret := range obj.sa.Secrets {
+			if secret.Name == name {
+				exist = true
+				break
+			}
+		}
+		if !exist {
+			obj.sa.Secrets = append(obj.sa.Secrets, corev1.ObjectReference{Name: name})
+		}
+	}
+	return obj
+}
+
+// SetAutomountToken set whether the ServiceAccount token should be automatically mounted into Pods,
+// it can be overridden by Pod SetServiceAccount(name, automount)
+func (obj *ServiceAccount) SetAutomountToken(automount bool) *ServiceAccount {
+	obj.sa.AutomountServiceAccountToken = &automount
+	return obj
+}
+
+// Release release ServiceAccount on Kubernetes
+func (obj *ServiceAccount) Release() (*corev1.ServiceAccount, error) {
+	sa, err := obj.Finish()
+	if err != nil {
+		return nil, err
+	}
+	client, err := GetKubeClient()
+	if err != nil {
+		return nil, err
+	}
+	return client.CoreV1().ServiceAccounts(sa.GetNamespace()).Create(sa)
+}
+
+// Apply it will be updated when this resource object exists in K8s,
+// it will be created when it does not exist.
+// the token Secrets which are generated by Kubernetes are kept when secrets is not set
+func (obj *ServiceAccount) Apply() (*corev1.ServiceAccount, error) {
+	sa, err := obj.Finish()
+	if err != nil {
+		return nil, err
+	}
+	client, err := GetKubeClient()
+	if err != nil {
+		return nil, err
+	}
+	present, err := client.CoreV1().ServiceAccounts(sa.GetNamespace()).Get(sa.GetName(), metav1.GetOptions{})
+	if err != nil {
+		return client.CoreV1().ServiceAccounts(sa.GetNamespace()).Create(sa)
+	}
+	if len(sa.Secrets) <= 0 {
+		sa.Secrets = present.Secrets
+	}
+	return client.CoreV1().ServiceAccounts(sa.GetNamespace()).Update(sa)
+}
+
+// Delete delete ServiceAccount on Kubernetes
+func (obj *ServiceAccount) Delete() error {
+	sa, err := obj.Finish()
+	if err != nil {
+		return err
+	}
+	client, err := GetKubeClient()
+	if err != nil {
+		return err
+	}
+	return client.CoreV1().ServiceAccounts(sa.GetNamespace()).Delete(sa.GetName(), &metav1.DeleteOptions{})
+}
+
 func (obj *ServiceAccount) verify() {
+	if obj.err != nil {
+		return
+	}
 	if obj.sa.GetName() == "" {
 		obj.error(errors.New("Set Name err,name is not allowed to be empty"))
 		return
 	}
+	if !verifyString(obj.sa.GetNamespace()) {
+		obj.sa.SetNamespace("default")
+	}
 	obj.sa.APIVersion = "v1"
 	obj.sa.Kind = "ServiceAccount"
 }
+
 func (obj *ServiceAccount) error(err error) {
 	if obj.err != nil {
 		return
 	}
 	obj.err = err
 }
-
-// SetNamespceAndName set namespace and name, name is not allowed to be empty, name default is ""
-func (obj *ServiceAccount) SetNamespceAndName(namespace, name string) *ServiceAccount {
-	obj.sa.SetNamespace(namespace)
-	obj.sa.SetName(name)
-	return obj
-}
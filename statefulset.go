@@ -8,13 +8,18 @@ import (
 	"github.com/ghodss/yaml"
 	"k8s.io/api/apps/v1"
 	corev1 "k8s.io/api/core/v1"
+	policyv1beta1 "k8s.io/api/policy/v1beta1"
 
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/util/intstr"
 )
 
 // StatefulSet include kubernetes resource object StatefulSet(sts) and error
 type StatefulSet struct {
 	sts *v1.StatefulSet
+	// pdb is set by SetDisruptionBudget(), it is released together with StatefulSet
+	pdb *PDB
 	err error
 }
 
@@ -41,6 +46,28 @@ func (obj *StatefulSet) YAMLNew(yamlbyts []byte) *StatefulSet {
 	return obj
 }
 
+// JSONNewTemplate use json template and values create StatefulSet,
+// the template is rendered by RenderTemplate() and must be valid json
+func (obj *StatefulSet) JSONNewTemplate(tpl []byte, values interface{}) *StatefulSet {
+	jsonbyts, err := renderJSONTemplate(tpl, values)
+	if err != nil {
+		obj.error(err)
+		return obj
+	}
+	return obj.JSONNew(jsonbyts)
+}
+
+// YAMLNewTemplate use yaml template and values create StatefulSet,
+// the template is rendered by RenderTemplate() and must be valid yaml
+func (obj *StatefulSet) YAMLNewTemplate(tpl []byte, values interface{}) *StatefulSet {
+	yamlbyts, err := renderYAMLTemplate(tpl, values)
+	if err != nil {
+		obj.error(err)
+		return obj
+	}
+	return obj.YAMLNew(yamlbyts)
+}
+
 // Replace replace StatefulSet by Kubernetes resource object
 func (obj *StatefulSet) Replace(sts *v1.StatefulSet) *StatefulSet {
 	if sts != nil {
@@ -143,6 +170,14 @@ func (obj *StatefulSet) SetPodPriorityClass(priorityClassName string) *StatefulS
 	return obj
 }
 
+// SetServiceAccount set StatefulSet Pod ServiceAccount
+// serviceAccountName is Kubernetes resource object ServiceAccount name, eg: UnionRBAC.GetName()
+// automount[0] set whether the ServiceAccount token should be automatically mounted,default use ServiceAccount setting.
+func (obj *StatefulSet) SetServiceAccount(serviceAccountName string, automount ...bool) *StatefulSet {
+	obj.error(setServiceAccount(&obj.sts.Spec.Template, serviceAccountName, automount...))
+	return obj
+}
+
 // SetRequiredORNodeAffinity set node affinity  for RequiredDuringSchedulingIgnoredDuringExecution style
 // A list of keys, many key do OR operation.
 func (obj *StatefulSet) SetRequiredORNodeAffinity(key string, value []string, operator NodeSelectorOperator) *StatefulSet {
@@ -216,6 +251,61 @@ func (obj *StatefulSet) SetPVCMounts(volumeName, mountPath string) *StatefulSet
 	return obj
 }
 
+// SetConfigMapVolume declare ConfigMap volume on StatefulSet Pod, volumeName is used by SetVolumeMount(),
+// cm.Items is the keys which are projected, all keys are projected when it is empty
+func (obj *StatefulSet) SetConfigMapVolume(volumeName string, cm *ConfigMapVolumeSource) *StatefulSet {
+	obj.error(setConfigMapVolume(&obj.sts.Spec.Template, volumeName, cm))
+	return obj
+}
+
+// SetSecretVolume declare Secret volume on StatefulSet Pod, volumeName is used by SetVolumeMount(),
+// secret.Items is the keys which are projected, all keys are projected when it is empty
+func (obj *StatefulSet) SetSecretVolume(volumeName string, secret *SecretVolumeSource) *StatefulSet {
+	obj.error(setSecretVolume(&obj.sts.Spec.Template, volumeName, secret))
+	return obj
+}
+
+// SetEmptyDirVolume declare EmptyDir volume on StatefulSet Pod, it is deleted when Pod is deleted,
+// memory: use tmpfs, sizeLimit[0] is the max size of volume, eg: 1Gi
+func (obj *StatefulSet) SetEmptyDirVolume(volumeName string, memory bool, sizeLimit ...string) *StatefulSet {
+	obj.error(setEmptyDirVolume(&obj.sts.Spec.Template, volumeName, memory, sizeLimit...))
+	return obj
+}
+
+// SetHostPathVolume declare HostPath volume on StatefulSet Pod, path is the absolute path on node
+func (obj *StatefulSet) SetHostPathVolume(volumeName, path string, hostPathType ...HostPathType) *StatefulSet {
+	obj.error(setHostPathVolume(&obj.sts.Spec.Template, volumeName, path, hostPathType...))
+	return obj
+}
+
+// SetDownwardAPIVolume declare DownwardAPI volume on StatefulSet Pod, Pod fields and container resources are exposed as files
+func (obj *StatefulSet) SetDownwardAPIVolume(volumeName string, files []DownwardAPIVolumeFile, defaultMode ...int32) *StatefulSet {
+	obj.error(setDownwardAPIVolume(&obj.sts.Spec.Template, volumeName, files, defaultMode...))
+	return obj
+}
+
+// SetProjectedVolume declare projected volume on StatefulSet Pod, it projects ConfigMap, Secret,
+// DownwardAPI and ServiceAccount token into the same directory
+func (obj *StatefulSet) SetProjectedVolume(volumeName string, sources []VolumeProjection, defaultMode ...int32) *StatefulSet {
+	obj.error(setProjectedVolume(&obj.sts.Spec.Template, volumeName, sources, defaultMode...))
+	return obj
+}
+
+// SetVolumeMount mount volume on StatefulSet container, mount.Container is the container name, default is the first container,
+// the volume must be declared by SetPVClaim(), SetConfigMapVolume() and the other volume functions
+func (obj *StatefulSet) SetVolumeMount(mount VolumeMount) *StatefulSet {
+	obj.error(setVolumeMount(&obj.sts.Spec.Template, mount))
+	return obj
+}
+
+// SetConfigMapFiles mount every key of ConfigMap as a read-only file in mountPath of StatefulSet container,
+// container[0] is the container name, default is the first container.
+// eg: NewCM().SetName("nginx").FromDir("conf") and SetConfigMapFiles(cm, "/etc/nginx/conf.d")
+func (obj *StatefulSet) SetConfigMapFiles(cm *ConfigMap, mountPath string, container ...string) *StatefulSet {
+	obj.error(setConfigMapFiles(&obj.sts.Spec.Template, cm, mountPath, container...))
+	return obj
+}
+
 // SetPVCTemp set StatefulSet PersistentVolumeClaimTemplate
 // can't call SetPVCMounts() function when you call the function,
 // because SetPVCMounts() function has been called automatically,
@@ -394,35 +484,121 @@ func (obj *StatefulSet) ImagePullPolicy(pullPolicy PullPolicy) *StatefulSet {
 	return obj
 }
 
-// Release release StatefulSet on Kubernetes
-func (obj *StatefulSet) Release() (*v1.StatefulSet, error) {
+// SetDisruptionBudget protect Pods of StatefulSet by PodDisruptionBudget(PDB) which has the same name as StatefulSet,
+// val is minAvailable by default, it is maxUnavailable when maxUnavailable[0] is true, eg: FromInt(1),Parse("50%").
+// PDB is released together with StatefulSet by Release() and Apply(), you can get it by FinishWithPDB().
+func (obj *StatefulSet) SetDisruptionBudget(val intstr.IntOrString, maxUnavailable ...bool) *StatefulSet {
+	pdb := NewPDB()
+	if len(maxUnavailable) > 0 && maxUnavailable[0] {
+		pdb.SetMaxUnavailable(val)
+	} else {
+		pdb.SetMinAvailable(val)
+	}
+	if pdb.err != nil {
+		obj.error(fmt.Errorf("SetDisruptionBudget err:%s", pdb.err.Error()))
+		return obj
+	}
+	obj.pdb = pdb
+	return obj
+}
+
+// FinishWithPDB Chain function call end with this function
+// return Kubernetes resource object StatefulSet and PodDisruptionBudget which is set by SetDisruptionBudget() and error,
+// you can get PDB warnings by Warnings()
+func (obj *StatefulSet) FinishWithPDB() (*v1.StatefulSet, *policyv1beta1.PodDisruptionBudget, error) {
 	sts, err := obj.Finish()
+	if err != nil {
+		return nil, nil, err
+	}
+	if obj.pdb == nil {
+		return nil, nil, errors.New("StatefulSet disruption budget is not set,you can call SetDisruptionBudget() input")
+	}
+	pdb, err := obj.pdb.SetStatefulSet(sts).Finish()
+	if err != nil {
+		return nil, nil, err
+	}
+	return sts, pdb, nil
+}
+
+// Warnings return the warnings of PDB which is set by SetDisruptionBudget(),
+// it is available after Finish,eg: PDB blocks all evictions
+func (obj *StatefulSet) Warnings() []string {
+	if obj.pdb == nil {
+		return nil
+	}
+	return obj.pdb.Warnings()
+}
+
+// objects finish StatefulSet and PDB which is set by SetDisruptionBudget()
+func (obj *StatefulSet) objects() ([]runtime.Object, error) {
+	if obj.pdb == nil {
+		sts, err := obj.Finish()
+		if err != nil {
+			return nil, err
+		}
+		return []runtime.Object{sts}, nil
+	}
+	sts, pdb, err := obj.FinishWithPDB()
 	if err != nil {
 		return nil, err
 	}
+	return []runtime.Object{sts, pdb}, nil
+}
+
+// Release release StatefulSet on Kubernetes,
+// PDB will be released too when it is set by SetDisruptionBudget()
+func (obj *StatefulSet) Release() (*v1.StatefulSet, error) {
+	objs, err := obj.objects()
+	if err != nil {
+		return nil, err
+	}
+	sts := objs[0].(*v1.StatefulSet)
 	client, err := GetKubeClient()
 	if err != nil {
 		return nil, err
 	}
-	return client.AppsV1().StatefulSets(sts.GetNamespace()).Create(sts)
+	if err = priorityClassExists(client, sts.Spec.Template.Spec.PriorityClassName); err != nil {
+		return nil, err
+	}
+	sts, err = client.AppsV1().StatefulSets(sts.GetNamespace()).Create(sts)
+	if err != nil || obj.pdb == nil {
+		return sts, err
+	}
+	_, err = obj.pdb.Release()
+	return sts, err
 }
 
 // Apply  it will be updated when this resource object exists in K8s,
 // it will be created when it does not exist.
+// replicas of StatefulSet on Kubernetes is kept when it is scaled by HPA.
+// PDB will be applied too when it is set by SetDisruptionBudget()
 func (obj *StatefulSet) Apply() (*v1.StatefulSet, error) {
-	sts, err := obj.Finish()
+	objs, err := obj.objects()
 	if err != nil {
 		return nil, err
 	}
+	sts := objs[0].(*v1.StatefulSet)
 	client, err := GetKubeClient()
 	if err != nil {
 		return nil, err
 	}
-	_, err = client.AppsV1().StatefulSets(sts.GetNamespace()).Get(sts.GetName(), metav1.GetOptions{})
+	if err = priorityClassExists(client, sts.Spec.Template.Spec.PriorityClassName); err != nil {
+		return nil, err
+	}
+	present, err := client.AppsV1().StatefulSets(sts.GetNamespace()).Get(sts.GetName(), metav1.GetOptions{})
 	if err != nil {
-		return client.AppsV1().StatefulSets(sts.GetNamespace()).Create(sts)
+		sts, err = client.AppsV1().StatefulSets(sts.GetNamespace()).Create(sts)
+	} else {
+		if hpaManaged(client, sts.GetNamespace(), "StatefulSet", sts.GetName()) {
+			sts.Spec.Replicas = present.Spec.Replicas
+		}
+		sts, err = client.AppsV1().StatefulSets(sts.GetNamespace()).Update(sts)
+	}
+	if err != nil || obj.pdb == nil {
+		return sts, err
 	}
-	return client.AppsV1().StatefulSets(sts.GetNamespace()).Update(sts)
+	_, err = obj.pdb.Apply()
+	return sts, err
 }
 
 // verify check service necessary value, input the default field and input related data.
@@ -442,6 +618,14 @@ func (obj *StatefulSet) verify() {
 		obj.err = fmt.Errorf("StatefulSet.Spec.Template.Spec.Containers err:%s", err.Error())
 		return
 	}
+	var claimTemplates []string
+	for _, claim := range obj.sts.Spec.VolumeClaimTemplates {
+		claimTemplates = append(claimTemplates, claim.GetName())
+	}
+	if err := verifyVolumeMounts(obj.sts.Spec.Template.Spec, claimTemplates...); err != nil {
+		obj.err = fmt.Errorf("StatefulSet.Spec.Template.Spec.Volumes err:%s", err.Error())
+		return
+	}
 	//check qos set,if err!=nil, check need auto set qos
 	presentQos, err := qosCheck(obj.sts.Annotations[qosKey], obj.sts.Spec.Template.Spec)
 	if err != nil {
@@ -456,11 +640,20 @@ func (obj *StatefulSet) verify() {
 			return
 		}
 	}
+	// the PDB is finished with StatefulSet, so its errors and warnings are available after Finish
+	if obj.pdb != nil {
+		if _, err := obj.pdb.SetStatefulSet(obj.sts).Finish(); err != nil {
+			obj.err = fmt.Errorf("StatefulSet disruption budget err:%s", err.Error())
+			return
+		}
+	}
 	obj.sts.Kind = "StatefulSet"
 	obj.sts.APIVersion = "apps/v1"
 	if obj.sts.Annotations[ImagePullPolicyKey] == "" {
 		for index := range obj.sts.Spec.Template.Spec.Containers {
-			obj.sts.Spec.Template.Spec.Containers[index].ImagePullPolicy = corev1.PullIfNotPresent
+			if obj.sts.Spec.Template.Spec.Containers[index].ImagePullPolicy == "" {
+				obj.sts.Spec.Template.Spec.Containers[index].ImagePullPolicy = corev1.PullIfNotPresent
+			}
 		}
 		return
 	}
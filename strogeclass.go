@@ -3,9 +3,20 @@ package beku
 import (
 	"encoding/json"
 	"errors"
+	"fmt"
+	"strconv"
 
 	"github.com/ghodss/yaml"
 	"k8s.io/api/storage/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+const (
+	// DefaultStorageClassKey the annotation key of default StorageClass,
+	// PersistentVolumeClaim without storageClassName uses the default StorageClass
+	DefaultStorageClassKey = "storageclass.kubernetes.io/is-default-class"
+	// betaDefaultStorageClassKey the deprecated annotation key of default StorageClass
+	betaDefaultStorageClassKey = "storageclass.beta.kubernetes.io/is-default-class"
 )
 
 // StorageClass include Kubernetes resource object StorageClass and error.
@@ -37,8 +48,41 @@ func (obj *StorageClass) YAMLNew(yamlbyts []byte) *StorageClass {
 	return obj
 }
 
-// SetName set storageCLASS name
-func (obj *StorageClass) SetName() *StorageClass { return obj }
+// JSONNewTemplate use json template and values create StorageClass,
+// the template is rendered by RenderTemplate() and must be valid json
+func (obj *StorageClass) JSONNewTemplate(tpl []byte, values interface{}) *StorageClass {
+	jsonbyts, err := renderJSONTemplate(tpl, values)
+	if err != nil {
+		obj.error(err)
+		return obj
+	}
+	return obj.JSONNew(jsonbyts)
+}
+
+// YAMLNewTemplate use yaml template and values create StorageClass,
+// the template is rendered by RenderTemplate() and must be valid yaml
+func (obj *StorageClass) YAMLNewTemplate(tpl []byte, values interface{}) *StorageClass {
+	yamlbyts, err := renderYAMLTemplate(tpl, values)
+	if err != nil {
+		obj.error(err)
+		return obj
+	}
+	return obj.YAMLNew(yamlbyts)
+}
+
+// Replace replace sc by Kubernetes resource object
+func (obj *StorageClass) Replace(sc *v1.StorageClass) *StorageClass {
+	if sc != nil {
+		obj.sc = sc
+	}
+	return obj
+}
+
+// SetName set storageClass name
+func (obj *StorageClass) SetName(name string) *StorageClass {
+	obj.sc.SetName(name)
+	return obj
+}
 
 // SetProvisioner set storageClass privisioner
 func (obj *StorageClass) SetProvisioner(provisioner string) *StorageClass {
@@ -73,10 +117,139 @@ func (obj *StorageClass) SetVolumeBindingMode(bindingMode VolumeBindingMode) *St
 
 // SetAnnotations set storageClass annotations
 func (obj *StorageClass) SetAnnotations(annotations map[string]string) *StorageClass {
-	obj.sc.SetAnnotations(annotations)
+	if len(obj.sc.Annotations) <= 0 {
+		obj.sc.Annotations = annotations
+		return obj
+	}
+	for key, value := range annotations {
+		obj.sc.Annotations[key] = value
+	}
 	return obj
 }
 
+// SetDefault mark or unmark storageClass as the default StorageClass of cluster,
+// only one StorageClass should be the default one
+func (obj *StorageClass) SetDefault(isDefault bool) *StorageClass {
+	delete(obj.sc.Annotations, betaDefaultStorageClassKey)
+	return obj.SetAnnotations(map[string]string{DefaultStorageClassKey: strconv.FormatBool(isDefault)})
+}
+
+// SetAllowVolumeExpansion set whether PersistentVolumeClaim of storageClass can be expanded
+func (obj *StorageClass) SetAllowVolumeExpansion(allow bool) *StorageClass {
+	obj.sc.AllowVolumeExpansion = &allow
+	return obj
+}
+
+// SetNFSClient set storageClass provisioner to nfs-client provisioner,
+// provisioner is the PROVISIONER_NAME of nfs-client-provisioner deployment, eg: fuseim.pri/ifs
+// archiveOnDelete[0] set whether the data directory is archived when PersistentVolumeClaim is deleted, default is true
+func (obj *StorageClass) SetNFSClient(provisioner string, archiveOnDelete ...bool) *StorageClass {
+	if !verifyString(provisioner) {
+		obj.error(errors.New("SetNFSClient err,provisioner is not allowed to be empty"))
+		return obj
+	}
+	archive := true
+	if len(archiveOnDelete) > 0 {
+		archive = archiveOnDelete[0]
+	}
+	obj.sc.Provisioner = provisioner
+	obj.sc.Parameters = map[string]string{"archiveOnDelete": strconv.FormatBool(archive)}
+	return obj
+}
+
+// SetCephRBD set storageClass provisioner to Ceph RBD and set it's parameters,
+// required parameters are checked in Finish()
+func (obj *StorageClass) SetCephRBD(params CephRBDStorageParameters) *StorageClass {
+	obj.sc.Provisioner = ProvisionerCephRBD
+	obj.sc.Parameters = params.ToMap()
+	return obj
+}
+
+// SetCephFS set storageClass provisioner to CephFS and set it's parameters,
+// required parameters are checked in Finish()
+func (obj *StorageClass) SetCephFS(params CephFSStorageParameters) *StorageClass {
+	obj.sc.Provisioner = ProvisionerCephFS
+	obj.sc.Parameters = params.ToMap()
+	return obj
+}
+
+// SetLocalStorage set storageClass for local volumes which are created by administrator,
+// volumeBindingMode is set to WaitForFirstConsumer so Pod scheduling is considered before binding
+func (obj *StorageClass) SetLocalStorage() *StorageClass {
+	obj.sc.Provisioner = ProvisionerNoProvisioner
+	obj.sc.Parameters = nil
+	return obj.SetVolumeBindingMode(VolumeBindingWaitForFirstConsumer)
+}
+
+// Release release StorageClass on Kubernetes
+func (obj *StorageClass) Release() (*v1.StorageClass, error) {
+	sc, err := obj.Finish()
+	if err != nil {
+		return nil, err
+	}
+	client, err := GetKubeClient()
+	if err != nil {
+		return nil, err
+	}
+	return client.StorageV1().StorageClasses().Create(sc)
+}
+
+// Apply it will be updated when this resource object exists in K8s,
+// it will be created when it does not exist.
+// provisioner,parameters,reclaimPolicy and volumeBindingMode are immutable on Kubernetes,
+// so StorageClass will be recreated when they are changed, the bound PersistentVolumes are not affected.
+func (obj *StorageClass) Apply() (*v1.StorageClass, error) {
+	sc, err := obj.Finish()
+	if err != nil {
+		return nil, err
+	}
+	client, err := GetKubeClient()
+	if err != nil {
+		return nil, err
+	}
+	present, err := client.StorageV1().StorageClasses().Get(sc.GetName(), metav1.GetOptions{})
+	if err != nil {
+		return client.StorageV1().StorageClasses().Create(sc)
+	}
+	if storageClassChanged(present, sc) {
+		if err = client.StorageV1().StorageClasses().Delete(sc.GetName(), &metav1.DeleteOptions{}); err != nil {
+			return nil, err
+		}
+		return client.StorageV1().StorageClasses().Create(sc)
+	}
+	sc.ResourceVersion = present.ResourceVersion
+	return client.StorageV1().StorageClasses().Update(sc)
+}
+
+// Delete delete StorageClass on Kubernetes
+func (obj *StorageClass) Delete() error {
+	sc, err := obj.Finish()
+	if err != nil {
+		return err
+	}
+	client, err := GetKubeClient()
+	if err != nil {
+		return err
+	}
+	return client.StorageV1().StorageClasses().Delete(sc.GetName(), &metav1.DeleteOptions{})
+}
+
+// storageClassChanged check the immutable fields of StorageClass are changed
+func storageClassChanged(present, sc *v1.StorageClass) bool {
+	if present.Provisioner != sc.Provisioner || len(present.Parameters) != len(sc.Parameters) {
+		return true
+	}
+	for key, value := range sc.Parameters {
+		if present.Parameters[key] != value {
+			return true
+		}
+	}
+	if present.ReclaimPolicy != nil && sc.ReclaimPolicy != nil && *present.ReclaimPolicy != *sc.ReclaimPolicy {
+		return true
+	}
+	return present.VolumeBindingMode != nil && sc.VolumeBindingMode != nil && *present.VolumeBindingMode != *sc.VolumeBindingMode
+}
+
 // SetLabels set StorageClass labels
 func (obj *StorageClass) SetLabels(labels map[string]string) *StorageClass {
 	obj.sc.SetLabels(labels)
@@ -87,10 +260,29 @@ func (obj *StorageClass) verify() {
 	if obj.err != nil {
 		return
 	}
+	if !verifyString(obj.sc.GetName()) {
+		obj.err = errors.New("StorageClass name is not allowed to be empty,you can call SetName() input")
+		return
+	}
 	if obj.sc.Provisioner == "" {
 		obj.err = errors.New("StorageClass.spec.Provisioner is not allowed to be empty")
 		return
 	}
+	for _, key := range storageRequiredParameters[obj.sc.Provisioner] {
+		if !verifyString(obj.sc.Parameters[key]) {
+			obj.err = fmt.Errorf("StorageClass parameter %s is not allowed to be empty when provisioner is %s", key, obj.sc.Provisioner)
+			return
+		}
+	}
+	if obj.sc.Provisioner == ProvisionerNoProvisioner &&
+		(obj.sc.VolumeBindingMode == nil || *obj.sc.VolumeBindingMode != v1.VolumeBindingWaitForFirstConsumer) {
+		obj.err = fmt.Errorf("StorageClass volumeBindingMode must be WaitForFirstConsumer when provisioner is %s", ProvisionerNoProvisioner)
+		return
+	}
+	if value, ok := obj.sc.Annotations[DefaultStorageClassKey]; ok && value != "true" && value != "false" {
+		obj.err = fmt.Errorf("StorageClass annotation %s must be true or false", DefaultStorageClassKey)
+		return
+	}
 	obj.sc.APIVersion = "storage.k8s.io/v1"
 	obj.sc.Kind = "StorageClass"
 	return
@@ -0,0 +1,270 @@
+package beku
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"reflect"
+	"regexp"
+	"strconv"
+	"strings"
+	"text/template"
+
+	"github.com/ghodss/yaml"
+)
+
+// templateMarker wrap template line number which is inserted into template lines,
+// it is used to map rendered line back to template line.
+const templateMarker = "\x1e"
+
+var (
+	templateMarkerRegexp = regexp.MustCompile(templateMarker + `(\d+)` + templateMarker)
+	templateErrRegexp    = regexp.MustCompile(`template: beku:(\d+):(\d+):`)
+	templateVarRegexp    = regexp.MustCompile(`\$\$|\$\{([A-Za-z_][A-Za-z0-9_]*)\}`)
+	yamlLineRegexp       = regexp.MustCompile(`line (\d+):`)
+)
+
+// templateFuncs the safe function set of template, there are no functions access file,network or environment.
+var templateFuncs = template.FuncMap{
+	"default": func(def interface{}, given interface{}) interface{} {
+		if emptyValue(given) {
+			return def
+		}
+		return given
+	},
+	"required": func(msg string, val interface{}) (interface{}, error) {
+		if emptyValue(val) {
+			return nil, errors.New(msg)
+		}
+		return val, nil
+	},
+	"quote":      func(v interface{}) string { return strconv.Quote(fmt.Sprint(v)) },
+	"squote":     func(v interface{}) string { return "'" + strings.Replace(fmt.Sprint(v), "'", "''", -1) + "'" },
+	"upper":      strings.ToUpper,
+	"lower":      strings.ToLower,
+	"trim":       strings.TrimSpace,
+	"trimPrefix": func(prefix, s string) string { return strings.TrimPrefix(s, prefix) },
+	"trimSuffix": func(suffix, s string) string { return strings.TrimSuffix(s, suffix) },
+	"replace":    func(old, new, s string) string { return strings.Replace(s, old, new, -1) },
+	"contains":   func(substr, s string) bool { return strings.Contains(s, substr) },
+	"hasPrefix":  func(prefix, s string) bool { return strings.HasPrefix(s, prefix) },
+	"hasSuffix":  func(suffix, s string) bool { return strings.HasSuffix(s, suffix) },
+	"join": func(sep string, v interface{}) string {
+		val := reflect.ValueOf(v)
+		if val.Kind() != reflect.Slice && val.Kind() != reflect.Array {
+			return fmt.Sprint(v)
+		}
+		strs := make([]string, val.Len())
+		for i := range strs {
+			strs[i] = fmt.Sprint(val.Index(i).Interface())
+		}
+		return strings.Join(strs, sep)
+	},
+	"split":   func(sep, s string) []string { return strings.Split(s, sep) },
+	"indent":  indent,
+	"nindent": func(spaces int, s string) string { return "\n" + indent(spaces, s) },
+	"toYaml": func(v interface{}) (string, error) {
+		byts, err := yaml.Marshal(v)
+		return strings.TrimSuffix(string(byts), "\n"), err
+	},
+	"toJson": func(v interface{}) (string, error) {
+		byts, err := json.Marshal(v)
+		return string(byts), err
+	},
+	"b64enc": func(s string) string { return Base64Encode([]byte(s)) },
+	"b64dec": func(s string) (string, error) {
+		byts, err := Base64Decode(s)
+		return string(byts), err
+	},
+}
+
+// emptyValue return true when v is nil, zero number, false, or empty string, slice and map
+func emptyValue(v interface{}) bool {
+	if v == nil {
+		return true
+	}
+	val := reflect.ValueOf(v)
+	switch val.Kind() {
+	case reflect.Array, reflect.Map, reflect.Slice, reflect.String:
+		return val.Len() == 0
+	case reflect.Bool:
+		return !val.Bool()
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		return val.Int() == 0
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64, reflect.Uintptr:
+		return val.Uint() == 0
+	case reflect.Float32, reflect.Float64:
+		return val.Float() == 0
+	case reflect.Interface, reflect.Ptr:
+		return val.IsNil()
+	}
+	return false
+}
+
+func indent(spaces int, s string) string {
+	pad := strings.Repeat(" ", spaces)
+	return pad + strings.Replace(s, "\n", "\n"+pad, -1)
+}
+
+// RenderTemplate substitute ${VAR} in template by values and environment variables, then render Go text/template with values,
+// values is the data of template, ${VAR} is looked up in values firstly when values is map, and then in environment variables,
+// use $$ to write a literal $. ${VAR} is substituted before rendering, so the values which are rendered by template are kept as they are.
+// missing keys and unset ${VAR} are errors, errors contain the template line which produced them.
+// functions of template: default,required,quote,squote,upper,lower,trim,trimPrefix,trimSuffix,replace,
+// contains,hasPrefix,hasSuffix,join,split,indent,nindent,toYaml,toJson,b64enc,b64dec
+func RenderTemplate(tpl []byte, values interface{}) ([]byte, error) {
+	byts, _, err := renderTemplate(tpl, values)
+	return byts, err
+}
+
+// renderTemplate render template and return rendered data and the template line of every rendered line
+func renderTemplate(tpl []byte, values interface{}) ([]byte, []int, error) {
+	lines := strings.Split(string(tpl), "\n")
+	for index, line := range lines {
+		var err error
+		if lines[index], err = substituteVars(line, values); err != nil {
+			return nil, nil, fmt.Errorf("template line %d err:%v", index+1, err)
+		}
+	}
+	marked, offsets := markTemplate(lines)
+	t, err := template.New("beku").Option("missingkey=error").Funcs(templateFuncs).Parse(marked)
+	if err != nil {
+		return nil, nil, fmt.Errorf("template err:%v", fixTemplateErr(err, offsets))
+	}
+	var buf bytes.Buffer
+	if err = t.Execute(&buf, values); err != nil {
+		return nil, nil, fmt.Errorf("template err:%v", fixTemplateErr(err, offsets))
+	}
+	lines = strings.Split(buf.String(), "\n")
+	sources := make([]int, 0, len(lines))
+	last := 0
+	for _, line := range lines {
+		source := last
+		if matchs := templateMarkerRegexp.FindAllStringSubmatch(line, -1); len(matchs) > 0 {
+			source, _ = strconv.Atoi(matchs[0][1])
+			last, _ = strconv.Atoi(matchs[len(matchs)-1][1])
+		}
+		sources = append(sources, source)
+	}
+	return templateMarkerRegexp.ReplaceAll(buf.Bytes(), nil), sources, nil
+}
+
+// markTemplate insert line number marker at the beginning of template lines,
+// the lines which are inside action or whose whitespace are trimmed by '{{-' or '-}}' are not marked.
+// return marked template and marker length of every line
+func markTemplate(lines []string) (string, []int) {
+	offsets := make([]int, len(lines))
+	depth, trimNext := 0, false
+	var buf bytes.Buffer
+	for index, line := range lines {
+		if index > 0 {
+			buf.WriteByte('\n')
+		}
+		if depth == 0 && !trimNext && !strings.HasPrefix(strings.TrimLeft(line, " \t\r"), "{{-") {
+			marker := fmt.Sprintf("%s%d%s", templateMarker, index+1, templateMarker)
+			buf.WriteString(marker)
+			offsets[index] = len(marker)
+		}
+		buf.WriteString(line)
+		if depth += strings.Count(line, "{{") - strings.Count(line, "}}"); depth < 0 {
+			depth = 0
+		}
+		trimNext = strings.HasSuffix(strings.TrimRight(line, " \t\r"), "-}}")
+	}
+	return buf.String(), offsets
+}
+
+// fixTemplateErr remove marker length from the column of template error
+func fixTemplateErr(err error, offsets []int) error {
+	msg := templateErrRegexp.ReplaceAllStringFunc(err.Error(), func(s string) string {
+		matchs := templateErrRegexp.FindStringSubmatch(s)
+		line, _ := strconv.Atoi(matchs[1])
+		col, _ := strconv.Atoi(matchs[2])
+		if line > 0 && line <= len(offsets) && col > offsets[line-1] {
+			col -= offsets[line-1]
+		}
+		return fmt.Sprintf("template: beku:%d:%d:", line, col)
+	})
+	return errors.New(msg)
+}
+
+// substituteVars substitute ${VAR} in template line, the value which contains '{{' is written as template string constant,
+// so it is output as it is instead of being parsed as template
+func substituteVars(line string, values interface{}) (string, error) {
+	var err error
+	line = templateVarRegexp.ReplaceAllStringFunc(line, func(s string) string {
+		if s == "$$" {
+			return "$"
+		}
+		name := s[2 : len(s)-1]
+		val, ok := lookupValue(values, name)
+		if !ok {
+			val, ok = os.LookupEnv(name)
+		}
+		if ok {
+			if strings.Contains(val, "{{") {
+				return "{{" + strconv.Quote(val) + "}}"
+			}
+			return val
+		}
+		if err == nil {
+			err = fmt.Errorf("${%s} is not set", name)
+		}
+		return s
+	})
+	return line, err
+}
+
+// lookupValue look up key in values when values is map with string key
+func lookupValue(values interface{}, key string) (string, bool) {
+	val := reflect.ValueOf(values)
+	if val.Kind() != reflect.Map || val.Type().Key().Kind() != reflect.String {
+		return "", false
+	}
+	v := val.MapIndex(reflect.ValueOf(key).Convert(val.Type().Key()))
+	if !v.IsValid() {
+		return "", false
+	}
+	return fmt.Sprint(v.Interface()), true
+}
+
+// renderYAMLTemplate render yaml template and check the result is valid yaml,
+// the yaml error line is mapped back to template line
+func renderYAMLTemplate(tpl []byte, values interface{}) ([]byte, error) {
+	byts, sources, err := renderTemplate(tpl, values)
+	if err != nil {
+		return nil, err
+	}
+	if _, err = yaml.YAMLToJSON(byts); err != nil {
+		if matchs := yamlLineRegexp.FindStringSubmatch(err.Error()); len(matchs) > 1 {
+			line, _ := strconv.Atoi(matchs[1])
+			if line > 0 && line <= len(sources) {
+				return nil, fmt.Errorf("template line %d(rendered line %d) is invalid yaml:%v", sources[line-1], line, err)
+			}
+		}
+		return nil, fmt.Errorf("template rendered invalid yaml:%v", err)
+	}
+	return byts, nil
+}
+
+// renderJSONTemplate render json template and check the result is valid json,
+// the json error offset is mapped back to template line
+func renderJSONTemplate(tpl []byte, values interface{}) ([]byte, error) {
+	byts, sources, err := renderTemplate(tpl, values)
+	if err != nil {
+		return nil, err
+	}
+	var v interface{}
+	if err = json.Unmarshal(byts, &v); err != nil {
+		if syntaxErr, ok := err.(*json.SyntaxError); ok {
+			line := bytes.Count(byts[:syntaxErr.Offset], []byte("\n")) + 1
+			if line <= len(sources) {
+				return nil, fmt.Errorf("template line %d(rendered line %d) is invalid json:%v", sources[line-1], line, err)
+			}
+		}
+		return nil, fmt.Errorf("template rendered invalid json:%v", err)
+	}
+	return byts, nil
+}
@@ -0,0 +1,94 @@
+package test
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/yulibaozi/beku"
+	appsv1 "k8s.io/api/apps/v1"
+	"k8s.io/api/core/v1"
+	networkingv1beta1 "k8s.io/api/networking/v1beta1"
+)
+
+func Test_ConfigMapNameSuffixHash(t *testing.T) {
+	cm, err := beku.NewCM().SetNamespaceAndName("yulibaozi", "nginx-conf").SetNameSuffixHash(true).
+		SetData(map[string]string{"default.conf": "server {}"}).Finish()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.HasPrefix(cm.GetName(), "nginx-conf-") || len(cm.GetName()) != len("nginx-conf-")+10 ||
+		cm.GetLabels()[beku.NameHashBaseKey] != "nginx-conf" {
+		t.Fatalf("ConfigMap metadata is %+v", cm.ObjectMeta)
+	}
+	same, err := beku.NewCM().SetNamespaceAndName("yulibaozi", "nginx-conf").SetNameSuffixHash(true).
+		SetData(map[string]string{"default.conf": "server {}"}).Finish()
+	if err != nil {
+		t.Fatal(err)
+	}
+	changed, err := beku.NewCM().SetNamespaceAndName("yulibaozi", "nginx-conf").SetNameSuffixHash(true).
+		SetData(map[string]string{"default.conf": "server { listen 8080; }"}).Finish()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if same.GetName() != cm.GetName() || changed.GetName() == cm.GetName() {
+		t.Fatalf("ConfigMap names are %s, %s and %s", cm.GetName(), same.GetName(), changed.GetName())
+	}
+	byts, err := beku.ToYAML(cm)
+	if err != nil {
+		t.Fatal(err)
+	}
+	reloaded, err := beku.NewCM().YAMLNew(byts).SetNameSuffixHash(true).Finish()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if reloaded.GetName() != cm.GetName() {
+		t.Fatalf("ConfigMap read from hashed manifest should not be hashed twice, it is %s", reloaded.GetName())
+	}
+}
+
+func Test_BundleRenameHashedConfigs(t *testing.T) {
+	cm := beku.NewCM().SetNamespaceAndName("yulibaozi", "nginx-conf").SetNameSuffixHash(true).
+		SetData(map[string]string{"default.conf": "server {}"})
+	secret := beku.NewBasicAuthSecret("admin", "password").SetNamespaceAndName("yulibaozi", "nginx-auth").SetNameSuffixHash(true)
+	other := beku.NewCM().SetNamespaceAndName("other", "nginx-conf").SetData(map[string]string{"a": "b"})
+	dp := beku.NewDeployment().SetNamespaceAndName("yulibaozi", "nginx").SetSelector(map[string]string{"app": "nginx"}).
+		SetContainer("nginx", "nginx:1.17", 80).SetConfigMapFiles(beku.NewCM().SetName("nginx-conf"), "/etc/nginx/conf.d").
+		SetSecretVolume("auth", &beku.SecretVolumeSource{SecretName: "nginx-auth"}).
+		SetVolumeMount(beku.VolumeMount{Name: "auth", MountPath: "/etc/auth"})
+	objs, err := beku.NewBundle().Add(dp, cm, secret, other).Finish()
+	if err != nil {
+		t.Fatal(err)
+	}
+	deployment := objs[0].(*appsv1.Deployment)
+	cmName, secretName := objs[1].(*v1.ConfigMap).GetName(), objs[2].(*v1.Secret).GetName()
+	volumes := deployment.Spec.Template.Spec.Volumes
+	if volumes[0].ConfigMap.Name != cmName || volumes[1].Secret.SecretName != secretName || cmName == "nginx-conf" {
+		t.Fatalf("Deployment volumes is %+v, ConfigMap is %s, Secret is %s", volumes, cmName, secretName)
+	}
+	if objs[3].(*v1.ConfigMap).GetName() != "nginx-conf" {
+		t.Fatalf("ConfigMap without hash is renamed to %s", objs[3].(*v1.ConfigMap).GetName())
+	}
+}
+
+func Test_BundleRenameHashedSecretReferences(t *testing.T) {
+	certPEM, keyPEM := selfSignedCert(t, time.Now().Add(24*time.Hour))
+	tlsSecret := beku.NewTLSSecret(certPEM, keyPEM).SetNamespaceAndName("yulibaozi", "web-tls").SetNameSuffixHash(true)
+	registry := beku.NewDockerRegistrySecret("registry.example.com", "admin", "password", "").
+		SetNamespaceAndName("yulibaozi", "registry").SetNameSuffixHash(true)
+	ing := beku.NewIngress().SetNamespaceAndName("yulibaozi", "web").SetRule("web.example.com", "/", "web", beku.FromInt(80)).
+		SetTLS("web-tls", "web.example.com")
+	sa := beku.NewSa().SetNamespaceAndName("yulibaozi", "web").SetImagePullSecrets("registry").SetSecrets("web-tls")
+	objs, err := beku.NewBundle().Add(tlsSecret, registry, ing, sa).Finish()
+	if err != nil {
+		t.Fatal(err)
+	}
+	tlsName, registryName := objs[0].(*v1.Secret).GetName(), objs[1].(*v1.Secret).GetName()
+	if ingress := objs[2].(*networkingv1beta1.Ingress); ingress.Spec.TLS[0].SecretName != tlsName {
+		t.Fatalf("Ingress TLS Secret is %s, expect %s", ingress.Spec.TLS[0].SecretName, tlsName)
+	}
+	account := objs[3].(*v1.ServiceAccount)
+	if account.ImagePullSecrets[0].Name != registryName || account.Secrets[0].Name != tlsName {
+		t.Fatalf("ServiceAccount is %+v, Secrets are %s and %s", account, tlsName, registryName)
+	}
+}
@@ -0,0 +1,75 @@
+package test
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/yulibaozi/beku"
+)
+
+func Test_ConfigMapFromFiles(t *testing.T) {
+	dir, err := ioutil.TempDir("", "beku-cm")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	files := map[string][]byte{
+		"nginx.conf": []byte("worker_processes 1;\n"),
+		"logo.png":   {0x89, 0x50, 0x4e, 0x47, 0xff, 0xfe},
+		"app.env":    []byte("# comment\nLOG_LEVEL=debug\n\nDSN=mysql://root@db:3306/app?a=b\n"),
+	}
+	for name, byts := range files {
+		if err = ioutil.WriteFile(filepath.Join(dir, name), byts, 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err = os.Mkdir(filepath.Join(dir, "sub"), 0755); err != nil {
+		t.Fatal(err)
+	}
+	cm, err := beku.NewCM().SetNamespaceAndName("yulibaozi", "nginx").FromDir(dir).Finish()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if cm.Data["nginx.conf"] != "worker_processes 1;\n" || len(cm.BinaryData["logo.png"]) != 6 || len(cm.Data)+len(cm.BinaryData) != 3 {
+		t.Fatalf("ConfigMap is %+v", cm)
+	}
+	cm, err = beku.NewCM().SetNamespaceAndName("yulibaozi", "app").FromEnvFile(filepath.Join(dir, "app.env")).
+		FromFile("default.conf", filepath.Join(dir, "nginx.conf")).Finish()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if cm.Data["LOG_LEVEL"] != "debug" || cm.Data["DSN"] != "mysql://root@db:3306/app?a=b" || cm.Data["default.conf"] == "" {
+		t.Fatalf("ConfigMap data is %+v", cm.Data)
+	}
+	if _, err = beku.NewCM().SetName("app").FromFile("", filepath.Join(dir, "nginx.conf")).
+		FromFile("", filepath.Join(dir, "nginx.conf")).Finish(); err == nil {
+		t.Fatal("repeated key should return error")
+	}
+	big := filepath.Join(dir, "big.txt")
+	if err = ioutil.WriteFile(big, []byte(strings.Repeat("a", 1024*1024)), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if _, err = beku.NewCM().SetName("big").FromFile("", big).Finish(); err == nil || !strings.Contains(err.Error(), "1MiB") {
+		t.Fatalf("ConfigMap larger than 1MiB should return size error, but err is %v", err)
+	}
+}
+
+func Test_SetConfigMapFiles(t *testing.T) {
+	cm := beku.NewCM().SetNamespaceAndName("yulibaozi", "nginx.conf").SetData(map[string]string{"default.conf": "server {}"})
+	dp, err := beku.NewDeployment().SetNamespaceAndName("yulibaozi", "nginx").SetSelector(map[string]string{"app": "nginx"}).
+		SetContainer("nginx", "nginx:1.17", 80).SetContainer("reloader", "reloader", 8080).
+		SetConfigMapFiles(cm, "/etc/nginx/conf.d").SetConfigMapFiles(cm, "/config", "reloader").Finish()
+	if err != nil {
+		t.Fatal(err)
+	}
+	spec := dp.Spec.Template.Spec
+	if len(spec.Volumes) != 1 || spec.Volumes[0].Name != "nginx-conf" || spec.Volumes[0].ConfigMap.Name != "nginx.conf" {
+		t.Fatalf("Deployment volumes is %+v", spec.Volumes)
+	}
+	if !spec.Containers[1].VolumeMounts[0].ReadOnly || spec.Containers[1].VolumeMounts[0].MountPath != "/config" {
+		t.Fatalf("reloader volume mounts is %+v", spec.Containers[1].VolumeMounts)
+	}
+}
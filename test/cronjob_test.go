@@ -0,0 +1,55 @@
+package test
+
+import (
+	"testing"
+	"time"
+
+	"github.com/yulibaozi/beku"
+	batchv1beta1 "k8s.io/api/batch/v1beta1"
+)
+
+func Test_CreateCronJob(t *testing.T) {
+	cj, err := beku.NewCronJob().SetNamespaceAndName("yulibaozi", "report").SetSchedule("30 2 * * 1-5").
+		SetConcurrencyPolicy(beku.ConcurrencyPolicyForbid).SetStartingDeadlineSeconds(300).SetHistoryLimit(5, 2).
+		SetContainer("report", "report:v1").SetCommand([]string{"/report"}, "--daily").
+		SetEnvs(map[string]string{"DB_HOST": "mysql"}).Finish()
+	if err != nil {
+		t.Fatal(err)
+	}
+	podSpec := cj.Spec.JobTemplate.Spec.Template.Spec
+	if cj.Spec.ConcurrencyPolicy != batchv1beta1.ForbidConcurrent || *cj.Spec.SuccessfulJobsHistoryLimit != 5 ||
+		podSpec.RestartPolicy != "Never" || podSpec.Containers[0].Command[0] != "/report" {
+		t.Fatalf("CronJob is %+v", cj.Spec)
+	}
+	if _, err = beku.NewCronJob().SetNamespaceAndName("yulibaozi", "report").SetSchedule("61 * * * *").
+		SetContainer("report", "report:v1").Finish(); err == nil {
+		t.Fatal("invalid schedule should return error")
+	}
+	if _, err = beku.NewCronJob().SetNamespaceAndName("yulibaozi", "report").SetContainer("report", "report:v1").Finish(); err == nil {
+		t.Fatal("empty schedule should return error")
+	}
+}
+
+func Test_CronJobNextRuns(t *testing.T) {
+	from := time.Date(2019, 7, 5, 23, 0, 0, 0, time.Local) // Friday
+	runs, err := beku.NewCronJob().SetSchedule("30 2 * * 1-5").NextRuns(3, from)
+	if err != nil {
+		t.Fatal(err)
+	}
+	expects := []time.Time{
+		time.Date(2019, 7, 8, 2, 30, 0, 0, time.Local),
+		time.Date(2019, 7, 9, 2, 30, 0, 0, time.Local),
+		time.Date(2019, 7, 10, 2, 30, 0, 0, time.Local),
+	}
+	if len(runs) != len(expects) {
+		t.Fatalf("NextRuns is %v", runs)
+	}
+	for index := range expects {
+		if !runs[index].Equal(expects[index]) {
+			t.Fatalf("NextRuns[%d] is %v,expect %v", index, runs[index], expects[index])
+		}
+	}
+	if _, err = beku.NewCronJob().SetSchedule("30 2 * * 1-5").NextRuns(-1, from); err == nil {
+		t.Fatal("negative n should return error")
+	}
+}
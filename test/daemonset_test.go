@@ -0,0 +1,18 @@
+package test
+
+import (
+	"testing"
+
+	"github.com/yulibaozi/beku"
+)
+
+func Test_DaemonSetAPIVersion(t *testing.T) {
+	ds, err := beku.NewDS().SetNamespaceAndName("kube-system", "fluentd").SetSelector(map[string]string{"app": "fluentd"}).
+		SetContainer("fluentd", "fluentd:v1.4", 24224).Finish()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if ds.APIVersion != "apps/v1" || ds.Kind != "DaemonSet" {
+		t.Fatalf("DaemonSet TypeMeta is %+v", ds.TypeMeta)
+	}
+}
@@ -44,3 +44,32 @@ func Test_DeploymentPostStart(t *testing.T) {
 	}
 	t.Log(string(data))
 }
+
+func Test_DeploymentKeepImagePullPolicy(t *testing.T) {
+	dep, err := beku.NewDeployment().YAMLNew([]byte(`
+metadata:
+  name: mysql
+spec:
+  selector:
+    matchLabels:
+      app: mysql
+  template:
+    metadata:
+      labels:
+        app: mysql
+    spec:
+      containers:
+      - name: mysql
+        image: mysql:5.6
+        imagePullPolicy: Always
+      - name: sidecar
+        image: busybox
+`)).Finish()
+	if err != nil {
+		t.Fatal(err)
+	}
+	containers := dep.Spec.Template.Spec.Containers
+	if containers[0].ImagePullPolicy != "Always" || containers[1].ImagePullPolicy != "IfNotPresent" {
+		t.Fatalf("explicit ImagePullPolicy should be kept and empty one defaults to IfNotPresent, containers are %+v", containers)
+	}
+}
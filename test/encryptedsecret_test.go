@@ -0,0 +1,60 @@
+package test
+
+import (
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/x509"
+	"encoding/pem"
+	"strings"
+	"testing"
+
+	"github.com/yulibaozi/beku"
+)
+
+func Test_EncryptedSecret(t *testing.T) {
+	alice, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		t.Fatal(err)
+	}
+	bob, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		t.Fatal(err)
+	}
+	pubPEM := pem.EncodeToMemory(&pem.Block{Type: "RSA PUBLIC KEY", Bytes: x509.MarshalPKCS1PublicKey(&alice.PublicKey)})
+	alicePub, err := beku.ParseRSAPublicKey(pubPEM)
+	if err != nil {
+		t.Fatal(err)
+	}
+	yamlbyts, err := beku.NewBasicAuthSecret("admin", "s3cr3t-password").SetNamespaceAndName("yulibaozi", "db").
+		ToEncryptedYAML(alicePub, &bob.PublicKey)
+	if err != nil {
+		t.Fatal(err)
+	}
+	manifest := string(yamlbyts)
+	if strings.Contains(manifest, "s3cr3t-password") || strings.Contains(manifest, beku.Base64Encode([]byte("s3cr3t-password"))) {
+		t.Fatalf("encrypted manifest leaks password:%s", manifest)
+	}
+	if !strings.Contains(manifest, "name: db") || !strings.Contains(manifest, "password: ENC[AES256_GCM,") {
+		t.Fatalf("encrypted manifest is not readable for review:%s", manifest)
+	}
+	for _, key := range []*rsa.PrivateKey{alice, bob} {
+		sec, err := beku.NewSecret().YAMLNewEncrypted(yamlbyts, key).Finish()
+		if err != nil {
+			t.Fatal(err)
+		}
+		if string(sec.Data["password"]) != "s3cr3t-password" || sec.Annotations[beku.EncryptedDataKeysKey] != "" {
+			t.Fatalf("decrypted Secret is %+v", sec)
+		}
+	}
+	eve, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err = beku.NewSecret().YAMLNewEncrypted(yamlbyts, eve).Finish(); err == nil {
+		t.Fatal("private key which is not a recipient should return error")
+	}
+	tampered := []byte(strings.Replace(manifest, "username: ENC[AES256_GCM,data:", "username: ENC[AES256_GCM,data:A", 1))
+	if _, err = beku.NewSecret().YAMLNewEncrypted(tampered, alice).Finish(); err == nil {
+		t.Fatal("tampered value should return error")
+	}
+}
@@ -0,0 +1,63 @@
+package test
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+	"text/template"
+
+	"github.com/yulibaozi/beku"
+)
+
+func Test_HelmChart(t *testing.T) {
+	dp := beku.NewDeployment().SetNamespaceAndName("yulibaozi", "mysql").SetSelector(map[string]string{"app": "mysql"}).
+		SetContainer("mysql", "mysql:5.6", 3306).SetReplicas(3)
+	svc := beku.NewSvc().SetNamespaceAndName("yulibaozi", "mysql-svc").SetSelector(map[string]string{"app": "mysql"}).
+		SetPorts([]beku.ServicePort{{Name: "mysql", Port: 3306}})
+	files, err := beku.NewHelmChart("mysql", "0.1.0").SetBundle(beku.NewBundle().Add(dp, svc)).
+		SetValues(beku.HelmImage, beku.HelmReplicas).Finish()
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, name := range []string{"Chart.yaml", "values.yaml", "templates/deployment-mysql.yaml", "templates/service-mysql-svc.yaml"} {
+		if _, ok := files[name]; !ok {
+			t.Fatalf("chart file %s not found", name)
+		}
+	}
+	tpl := string(files["templates/deployment-mysql.yaml"])
+	for _, want := range []string{
+		`namespace: {{ .Release.Namespace }}`,
+		`replicas: {{ index .Values "deployment" "mysql" "replicas" }}`,
+		`image: {{ index .Values "deployment" "mysql" "containers" "mysql" "image" | quote }}`,
+	} {
+		if !strings.Contains(tpl, want) {
+			t.Fatalf("template not contains %q:\n%s", want, tpl)
+		}
+	}
+	values := string(files["values.yaml"])
+	if !strings.Contains(values, "image: mysql:5.6") || !strings.Contains(values, "replicas: 3") {
+		t.Fatalf("values.yaml is:\n%s", values)
+	}
+	if _, err = beku.NewHelmChart("MySQL", "0.1.0").SetBundle(beku.NewBundle().Add(svc)).Finish(); err == nil {
+		t.Fatal("chart name with upper case letters should return error")
+	}
+}
+
+func Test_HelmChartEscapeDelimiters(t *testing.T) {
+	cm := beku.NewCM().SetNamespaceAndName("yulibaozi", "alert").SetData(map[string]string{"alert.tmpl": "{{ .Foo }} is down"})
+	files, err := beku.NewHelmChart("alert", "0.1.0").SetBundle(beku.NewBundle().Add(cm)).Finish()
+	if err != nil {
+		t.Fatal(err)
+	}
+	tpl, err := template.New("cm").Parse(string(files["templates/configmap-alert.yaml"]))
+	if err != nil {
+		t.Fatal(err)
+	}
+	var buf bytes.Buffer
+	if err = tpl.Execute(&buf, map[string]interface{}{"Release": map[string]interface{}{"Namespace": "yulibaozi"}}); err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(buf.String(), "{{ .Foo }} is down") || !strings.Contains(buf.String(), "namespace: yulibaozi") {
+		t.Fatalf("rendered template is:\n%s", buf.String())
+	}
+}
@@ -0,0 +1,50 @@
+package test
+
+import (
+	"testing"
+
+	"github.com/yulibaozi/beku"
+	"k8s.io/api/autoscaling/v2beta2"
+)
+
+func Test_CreateHPA(t *testing.T) {
+	hpa, err := beku.NewHPA().SetNamespaceAndName("yulibaozi", "worker").SetScaleTarget("StatefulSet", "worker").
+		SetReplicas(2, 10).SetMemoryUtilization(70).SetPodsMetric("packets-per-second", "1k").
+		SetExternalMetric("queue_messages_ready", map[string]string{"queue": "worker"}, "30").Finish()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(hpa.Spec.Metrics) != 3 || hpa.Spec.Metrics[2].Type != v2beta2.ExternalMetricSourceType || *hpa.Spec.MinReplicas != 2 {
+		t.Fatalf("HPA is %+v", hpa.Spec)
+	}
+	if _, err = beku.NewHPA().SetScaleTarget("DaemonSet", "worker").SetReplicas(1, 3).Finish(); err == nil {
+		t.Fatal("DaemonSet scale target should return error")
+	}
+	if _, err = beku.NewHPA().SetScaleTarget("Deployment", "worker").SetReplicas(3, 1).Finish(); err == nil {
+		t.Fatal("max replicas less than min replicas should return error")
+	}
+}
+
+func Test_DeploymentSetAutoscale(t *testing.T) {
+	dp, hpa, err := beku.NewDeployment().SetNamespaceAndName("yulibaozi", "web").SetPodLabels(map[string]string{"app": "web"}).
+		SetContainer("web", "web:v1", 8080).SetResourceRequst(map[beku.ResourceName]string{beku.ResourceCPU: "100m", beku.ResourceMemory: "128Mi"}).
+		SetAutoscale(2, 5, 60).FinishWithHPA()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if *dp.Spec.Replicas != 2 || hpa.GetName() != "web" || hpa.GetNamespace() != "yulibaozi" ||
+		hpa.Spec.ScaleTargetRef.Kind != "Deployment" || *hpa.Spec.Metrics[0].Resource.Target.AverageUtilization != 60 {
+		t.Fatalf("HPA is %+v", hpa)
+	}
+	_, _, err = beku.NewDeployment().SetNamespaceAndName("yulibaozi", "web").SetPodLabels(map[string]string{"app": "web"}).
+		SetContainer("web", "web:v1", 8080).SetAutoscale(2, 5, 60).FinishWithHPA()
+	if err == nil {
+		t.Fatal("autoscale without cpu requests should return error")
+	}
+	_, _, err = beku.NewDeployment().SetNamespaceAndName("yulibaozi", "web").SetPodLabels(map[string]string{"app": "web"}).
+		SetContainer("web", "web:v1", 8080).SetResourceLimit(map[beku.ResourceName]string{beku.ResourceCPU: "200m", beku.ResourceMemory: "256Mi"}).
+		SetAutoscale(2, 5, 60).FinishWithHPA()
+	if err != nil {
+		t.Fatalf("autoscale with only cpu limits should be allowed, requests default to limits: %v", err)
+	}
+}
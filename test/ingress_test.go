@@ -0,0 +1,57 @@
+package test
+
+import (
+	"testing"
+
+	"github.com/yulibaozi/beku"
+)
+
+func Test_CreateIngress(t *testing.T) {
+	ing, err := beku.NewIngress().SetNamespaceAndName("yulibaozi", "web").SetIngressClass("nginx").
+		SetRule("web.example.com", "/", "web", beku.FromInt(80)).
+		SetRule("web.example.com", "/api", "api", beku.FromString("http")).
+		SetTLS("web-tls", "web.example.com").SetRewriteTarget("/").Finish()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(ing.Spec.Rules) != 1 || len(ing.Spec.Rules[0].HTTP.Paths) != 2 || ing.Annotations[beku.IngressClassKey] != "nginx" {
+		t.Fatalf("Ingress is %+v", ing)
+	}
+	if _, err = beku.NewIngress().SetNamespaceAndName("yulibaozi", "web").SetRule("web.example.com", "/", "web", beku.FromInt(80)).
+		SetTLS("web-tls", "other.example.com").Finish(); err == nil {
+		t.Fatal("TLS host which is not in rules should return error")
+	}
+}
+
+func Test_ServiceToIngress(t *testing.T) {
+	svc, err := beku.NewSvc().SetNamespaceAndName("yulibaozi", "web").SetSelector(map[string]string{"app": "web"}).
+		SetPorts([]beku.ServicePort{{Name: "http", Port: 80}, {Name: "metrics", Port: 9090}}).Finish()
+	if err != nil {
+		t.Fatal(err)
+	}
+	ing, err := beku.ServiceToIngress(svc, "web.example.com", "/", beku.FromString("http"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if ing.Spec.Rules[0].HTTP.Paths[0].Backend.ServiceName != "web" {
+		t.Fatalf("Ingress is %+v", ing)
+	}
+	if _, err = beku.ServiceToIngress(svc, "web.example.com", "/", beku.FromInt(8080)); err == nil {
+		t.Fatal("port which is not in Service should return error")
+	}
+	single, err := beku.NewSvc().SetNamespaceAndName("yulibaozi", "api").SetSelector(map[string]string{"app": "api"}).
+		SetPorts([]beku.ServicePort{{Name: "http", Port: 8080}}).Finish()
+	if err != nil {
+		t.Fatal(err)
+	}
+	ing, err = beku.ServiceToIngress(single, "api.example.com", "/")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if port := ing.Spec.Rules[0].HTTP.Paths[0].Backend.ServicePort; port.IntValue() != 8080 {
+		t.Fatalf("Ingress should use the only Service port, it is %s", port.String())
+	}
+	if _, err = beku.ServiceToIngress(svc, "web.example.com", "/"); err == nil {
+		t.Fatal("empty port of Service with multiple ports should return error")
+	}
+}
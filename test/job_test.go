@@ -0,0 +1,30 @@
+package test
+
+import (
+	"testing"
+
+	"github.com/yulibaozi/beku"
+	"k8s.io/api/core/v1"
+)
+
+func Test_CreateJob(t *testing.T) {
+	job, err := beku.NewJob().SetNamespaceAndName("yulibaozi", "migrate").SetContainer("migrate", "migrate:v1").
+		SetCommand([]string{"/migrate"}, "up").SetEnvs(map[string]string{"DB_HOST": "mysql"}).
+		SetCompletions(1).SetBackoffLimit(2).SetActiveDeadlineSeconds(600).SetTTLSecondsAfterFinished(60).Finish()
+	if err != nil {
+		t.Fatal(err)
+	}
+	container := job.Spec.Template.Spec.Containers[0]
+	if job.Spec.Template.Spec.RestartPolicy != v1.RestartPolicyNever || len(container.Ports) != 0 ||
+		container.Command[0] != "/migrate" || container.Args[0] != "up" || *job.Spec.BackoffLimit != 2 {
+		t.Fatalf("Job is %+v", job.Spec)
+	}
+	if _, err = beku.NewJob().SetNamespaceAndName("yulibaozi", "migrate").SetContainer("migrate", "migrate:v1").
+		SetRestartPolicy(beku.RestartPolicyAlways).Finish(); err == nil {
+		t.Fatal("Job with restart policy Always should return error")
+	}
+	if _, err = beku.NewJob().SetNamespaceAndName("yulibaozi", "migrate").SetContainer("migrate", "migrate:v1").
+		SetCompletions(0).Finish(); err == nil {
+		t.Fatal("Job with zero completions should return error")
+	}
+}
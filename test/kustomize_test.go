@@ -0,0 +1,237 @@
+package test
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/yulibaozi/beku"
+	appsv1 "k8s.io/api/apps/v1"
+	"k8s.io/api/core/v1"
+	networkingv1 "k8s.io/api/networking/v1"
+)
+
+func writeFiles(t *testing.T, dir string, files map[string]string) {
+	for name, content := range files {
+		path := filepath.Join(dir, name)
+		if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+			t.Fatal(err)
+		}
+		if err := ioutil.WriteFile(path, []byte(content), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+}
+
+func Test_ReadKustomization(t *testing.T) {
+	dir, err := ioutil.TempDir("", "beku-kustomize")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	writeFiles(t, dir, map[string]string{
+		"base/kustomization.yaml": `
+resources:
+- deployment.yaml
+configMapGenerator:
+- name: mysql-conf
+  literals:
+  - MODE=dev
+`,
+		"base/deployment.yaml": `
+apiVersion: apps/v1
+kind: Deployment
+metadata:
+  name: mysql
+spec:
+  selector:
+    matchLabels:
+      app: mysql
+  template:
+    metadata:
+      labels:
+        app: mysql
+    spec:
+      containers:
+      - name: mysql
+        image: mysql:5.6
+        envFrom:
+        - configMapRef:
+            name: mysql-conf
+`,
+		"prod/kustomization.yaml": `
+resources:
+- ../base
+namePrefix: prod-
+namespace: yulibaozi
+commonLabels:
+  env: prod
+images:
+- name: mysql
+  newTag: "5.7"
+patchesStrategicMerge:
+- replicas.yaml
+patchesJson6902:
+- target:
+    group: apps
+    version: v1
+    kind: Deployment
+    name: mysql
+  patch: |
+    - op: add
+      path: /spec/template/spec/containers/0/args
+      value: ["--verbose"]
+`,
+		"prod/replicas.yaml": `
+apiVersion: apps/v1
+kind: Deployment
+metadata:
+  name: mysql
+spec:
+  replicas: 3
+`,
+	})
+	bundle, err := beku.ReadKustomization(filepath.Join(dir, "prod"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	dp, ok := bundle.Get("Deployment", "yulibaozi", "prod-mysql").(*appsv1.Deployment)
+	if !ok {
+		t.Fatal("Deployment prod-mysql is not found")
+	}
+	container := dp.Spec.Template.Spec.Containers[0]
+	if *dp.Spec.Replicas != 3 || container.Image != "mysql:5.7" || container.Args[0] != "--verbose" {
+		t.Fatalf("Deployment is not patched: %+v", dp.Spec)
+	}
+	if dp.Spec.Selector.MatchLabels["env"] != "prod" || dp.Spec.Template.Labels["env"] != "prod" {
+		t.Fatalf("commonLabels are not applied: %+v", dp.Spec.Selector)
+	}
+	cmName := container.EnvFrom[0].ConfigMapRef.Name
+	if !strings.HasPrefix(cmName, "prod-mysql-conf-") || len(cmName) != len("prod-mysql-conf-")+10 {
+		t.Fatalf("ConfigMap reference should have name suffix hash, it is %s", cmName)
+	}
+	cm, ok := bundle.Get("ConfigMap", "yulibaozi", cmName).(*v1.ConfigMap)
+	if !ok || cm.Data["MODE"] != "dev" {
+		t.Fatalf("ConfigMap is %+v", cm)
+	}
+}
+
+func Test_KustomizeSelectors(t *testing.T) {
+	dir, err := ioutil.TempDir("", "beku-kustomize")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	writeFiles(t, dir, map[string]string{
+		"kustomization.yaml": `
+resources:
+- manifests.yaml
+commonLabels:
+  env: prod
+generatorOptions:
+  disableNameSuffixHash: true
+secretGenerator:
+- name: mysql-pass
+  literals:
+  - password=123456
+`,
+		"manifests.yaml": `
+apiVersion: v1
+kind: Service
+metadata:
+  name: external-mysql
+spec:
+  ports:
+  - port: 3306
+---
+apiVersion: v1
+kind: Service
+metadata:
+  name: mysql
+spec:
+  selector:
+    app: mysql
+  ports:
+  - port: 3306
+---
+apiVersion: networking.k8s.io/v1
+kind: NetworkPolicy
+metadata:
+  name: default-deny
+spec:
+  podSelector: {}
+---
+apiVersion: networking.k8s.io/v1
+kind: NetworkPolicy
+metadata:
+  name: mysql
+spec:
+  podSelector:
+    matchLabels:
+      app: mysql
+`,
+	})
+	bundle, err := beku.ReadKustomization(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if svc := bundle.Get("Service", "", "external-mysql").(*v1.Service); svc.Spec.Selector != nil {
+		t.Fatalf("Service without selector should not get selector, it is %v", svc.Spec.Selector)
+	}
+	if svc := bundle.Get("Service", "", "mysql").(*v1.Service); svc.Spec.Selector["env"] != "prod" {
+		t.Fatalf("Service selector is %v", svc.Spec.Selector)
+	}
+	if np := bundle.Get("NetworkPolicy", "", "default-deny").(*networkingv1.NetworkPolicy); len(np.Spec.PodSelector.MatchLabels) > 0 {
+		t.Fatalf("empty podSelector should select all pods, it is %v", np.Spec.PodSelector)
+	}
+	if np := bundle.Get("NetworkPolicy", "", "mysql").(*networkingv1.NetworkPolicy); np.Spec.PodSelector.MatchLabels["env"] != "prod" {
+		t.Fatalf("podSelector is %v", np.Spec.PodSelector)
+	}
+	if bundle.Get("Secret", "", "mysql-pass") == nil {
+		t.Fatal("Secret generator with disableNameSuffixHash should keep its name")
+	}
+}
+
+func Test_Kustomize(t *testing.T) {
+	newDp := func(replicas int32) *beku.Deployment {
+		return beku.NewDeployment().SetNamespaceAndName("yulibaozi", "mysql").SetSelector(map[string]string{"app": "mysql"}).
+			SetContainer("mysql", "mysql:5.6", 3306).SetReplicas(replicas)
+	}
+	cm := beku.NewCM().SetNamespaceAndName("yulibaozi", "mysql-conf").SetData(map[string]string{"MODE": "dev"})
+	files, err := beku.NewKustomize(beku.NewBundle().Add(newDp(1), cm)).
+		AddOverlay("prod", beku.NewBundle().Add(newDp(3))).Finish()
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, name := range []string{"base/kustomization.yaml", "base/deployment-mysql.yaml", "base/configmap-mysql-conf.yaml",
+		"overlays/prod/kustomization.yaml", "overlays/prod/patch-deployment-mysql.yaml", "overlays/prod/delete-configmap-mysql-conf.yaml"} {
+		if _, ok := files[name]; !ok {
+			t.Fatalf("kustomize file %s is not found", name)
+		}
+	}
+	patch := string(files["overlays/prod/patch-deployment-mysql.yaml"])
+	if !strings.Contains(patch, "replicas: 3") || strings.Contains(patch, "image:") {
+		t.Fatalf("patch is:\n%s", patch)
+	}
+	dir, err := ioutil.TempDir("", "beku-kustomize")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	if err = beku.NewKustomize(beku.NewBundle().Add(newDp(1), cm)).
+		AddOverlay("prod", beku.NewBundle().Add(newDp(3))).Write(dir); err != nil {
+		t.Fatal(err)
+	}
+	bundle, err := beku.ReadKustomization(filepath.Join(dir, "overlays", "prod"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if dp, ok := bundle.Get("Deployment", "yulibaozi", "mysql").(*appsv1.Deployment); !ok || *dp.Spec.Replicas != 3 {
+		t.Fatalf("overlay Deployment is %+v", dp)
+	}
+	if bundle.Get("ConfigMap", "yulibaozi", "mysql-conf") != nil {
+		t.Fatal("ConfigMap should be deleted by overlay")
+	}
+}
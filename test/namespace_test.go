@@ -0,0 +1,44 @@
+package test
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/yulibaozi/beku"
+)
+
+func Test_NamespaceLabels(t *testing.T) {
+	ns, err := beku.NewNs().SetName("monitoring").SetLabels(map[string]string{"team": "sre"}).
+		SetAnnotations(map[string]string{"owner": "sre@example.com"}).
+		SetPodSecurity(beku.PodSecurityEnforce, beku.PodSecurityBaseline, "v1.25").
+		SetPodSecurity(beku.PodSecurityWarn, beku.PodSecurityRestricted).Finish()
+	if err != nil {
+		t.Fatal(err)
+	}
+	labels := ns.GetLabels()
+	if labels["team"] != "sre" || labels[beku.NamespaceNameLabelKey] != "monitoring" ||
+		labels["pod-security.kubernetes.io/enforce"] != "baseline" || labels["pod-security.kubernetes.io/enforce-version"] != "v1.25" ||
+		labels["pod-security.kubernetes.io/warn"] != "restricted" {
+		t.Fatalf("Namespace labels is %v", labels)
+	}
+	if _, err = beku.NewNs().SetName("monitoring").SetPodSecurity(beku.PodSecurityEnforce, "strict").Finish(); err == nil {
+		t.Fatal("unsupported Pod Security level should return error")
+	}
+}
+
+func Test_NamespaceYAMLNew(t *testing.T) {
+	ns, err := beku.NewNs().YAMLNew([]byte("apiVersion: v1\nkind: Namespace\nmetadata:\n  name: dev\n  labels:\n    env: dev\n")).Finish()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if ns.GetName() != "dev" || ns.GetLabels()["env"] != "dev" {
+		t.Fatalf("Namespace is %+v", ns.ObjectMeta)
+	}
+}
+
+func Test_DeleteProtectedNamespace(t *testing.T) {
+	err := beku.NewNs().SetName("kube-system").Delete(0)
+	if err == nil || !strings.Contains(err.Error(), "protected") {
+		t.Fatalf("deleting kube-system should be refused, but err is %v", err)
+	}
+}
@@ -0,0 +1,50 @@
+package test
+
+import (
+	"testing"
+
+	"github.com/yulibaozi/beku"
+	"k8s.io/api/core/v1"
+)
+
+func Test_CreateNetworkPolicy(t *testing.T) {
+	web := beku.NewDeployment().SetNamespaceAndName("yulibaozi", "web").SetPodLabels(map[string]string{"app": "web"}).
+		SetContainer("web", "web:v1", 8080)
+	mysql := beku.NewSvc().SetNamespaceAndName("yulibaozi", "mysql").SetSelector(map[string]string{"app": "mysql"}).
+		SetPorts([]beku.ServicePort{{Name: "mysql", Port: 3306, TargetPort: 13306}})
+	np, err := beku.NewNetworkPolicy().SetNamespaceAndName("yulibaozi", "api").SetPodSelector(map[string]string{"app": "api"}).
+		AllowFrom(web, beku.NetworkPort{Port: beku.FromInt(8080)}).AllowTo(mysql).
+		SetIngressRule([]beku.NetworkPeer{{CIDR: "10.0.0.0/8", Except: []string{"10.1.0.0/16"}}}).Finish()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(np.Spec.PolicyTypes) != 2 || np.Spec.Ingress[0].From[0].PodSelector.MatchLabels["app"] != "web" ||
+		np.Spec.Egress[0].Ports[0].Port.IntVal != 13306 || *np.Spec.Egress[0].Ports[0].Protocol != v1.ProtocolTCP {
+		t.Fatalf("NetworkPolicy is %+v", np.Spec)
+	}
+	if _, err = beku.NewNetworkPolicy().SetNamespaceAndName("yulibaozi", "api").
+		SetIngressRule([]beku.NetworkPeer{{CIDR: "10.0.0.0/8", Except: []string{"192.168.0.0/16"}}}).Finish(); err == nil {
+		t.Fatal("except CIDR which is not in CIDR should return error")
+	}
+	other := beku.NewDeployment().SetNamespaceAndName("other", "web").SetPodLabels(map[string]string{"app": "web"})
+	if _, err = beku.NewNetworkPolicy().SetNamespaceAndName("yulibaozi", "api").AllowFrom(other).Finish(); err == nil {
+		t.Fatal("workload in other namespace should return error")
+	}
+}
+
+func Test_NetworkPolicyPresets(t *testing.T) {
+	deny, err := beku.NewDefaultDenyNetworkPolicy("yulibaozi").Finish()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(deny.Spec.PolicyTypes) != 2 || len(deny.Spec.Ingress) != 0 || len(deny.Spec.Egress) != 0 {
+		t.Fatalf("default deny NetworkPolicy is %+v", deny.Spec)
+	}
+	dns, err := beku.NewAllowDNSNetworkPolicy("yulibaozi").Finish()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(dns.Spec.Egress) != 1 || len(dns.Spec.Egress[0].Ports) != 2 || dns.Spec.Egress[0].To[0].NamespaceSelector == nil {
+		t.Fatalf("allow dns NetworkPolicy is %+v", dns.Spec)
+	}
+}
@@ -0,0 +1,44 @@
+package test
+
+import (
+	"testing"
+
+	"github.com/yulibaozi/beku"
+	"k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+func Test_NodeTaints(t *testing.T) {
+	node := beku.ReadNewNode(&v1.Node{ObjectMeta: metav1.ObjectMeta{Name: "node-1"}}).
+		SetTaints("maintenance", "true", beku.TaintEffectNoSchedule).
+		SetTaints("maintenance", "false", beku.TaintEffectNoSchedule).
+		SetTaints("maintenance", "true", beku.TaintEffectNoExecute)
+	data, err := node.Finish()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(data.Spec.Taints) != 2 || data.Spec.Taints[0].Value != "false" {
+		t.Fatalf("taints with the same key and effect should be replaced, taints are %+v", data.Spec.Taints)
+	}
+	data, err = node.RemoveTaint("maintenance", beku.TaintEffectNoExecute).Finish()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(data.Spec.Taints) != 1 || data.Spec.Taints[0].Effect != v1.TaintEffectNoSchedule {
+		t.Fatalf("taint should be removed by key and effect, taints are %+v", data.Spec.Taints)
+	}
+	data, err = node.RemoveTaint("maintenance", "").Finish()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(data.Spec.Taints) != 0 {
+		t.Fatalf("taints of all effects should be removed, taints are %+v", data.Spec.Taints)
+	}
+	if _, err = beku.ReadNewNode(&v1.Node{ObjectMeta: metav1.ObjectMeta{Name: "node-1"}}).
+		RemoveTaint("maintenance", beku.TaintEffect("NoWhere")).Finish(); err == nil {
+		t.Fatal("RemoveTaint with unsupported effect should return error")
+	}
+	if _, err = beku.ReadNewNode(&v1.Node{}).Finish(); err == nil {
+		t.Fatal("Node without name should return error")
+	}
+}
@@ -0,0 +1,67 @@
+package test
+
+import (
+	"testing"
+
+	"github.com/yulibaozi/beku"
+)
+
+func Test_CreatePDB(t *testing.T) {
+	pdb := beku.NewPDB().SetNamespaceAndName("yulibaozi", "web").SetSelector(map[string]string{"app": "web"}).
+		SetMaxUnavailable(beku.Parse("25%")).SetReplicas(4)
+	obj, err := pdb.Finish()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if obj.Spec.MaxUnavailable.StrVal != "25%" || len(pdb.Warnings()) != 0 {
+		t.Fatalf("PDB is %+v,warnings:%v", obj.Spec, pdb.Warnings())
+	}
+	if _, err = beku.NewPDB().SetNamespaceAndName("yulibaozi", "web").SetSelector(map[string]string{"app": "web"}).
+		SetMinAvailable(beku.FromInt(1)).SetMaxUnavailable(beku.FromInt(1)).Finish(); err == nil {
+		t.Fatal("PDB with minAvailable and maxUnavailable should return error")
+	}
+	if _, err = beku.NewPDB().SetNamespaceAndName("yulibaozi", "web").SetSelector(map[string]string{"app": "web"}).
+		SetMinAvailable(beku.Parse("120%")).Finish(); err == nil {
+		t.Fatal("PDB with percentage greater than 100% should return error")
+	}
+}
+
+func Test_SetDisruptionBudget(t *testing.T) {
+	dpBuilder := beku.NewDeployment().SetNamespaceAndName("yulibaozi", "web").SetPodLabels(map[string]string{"app": "web"}).
+		SetContainer("web", "web:v1", 8080).SetDisruptionBudget(beku.FromInt(1))
+	_, pdb, err := dpBuilder.FinishWithPDB()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if pdb.GetName() != "web" || pdb.Spec.Selector.MatchLabels["app"] != "web" || len(dpBuilder.Warnings()) != 1 {
+		t.Fatalf("PDB is %+v,warnings:%v", pdb, dpBuilder.Warnings())
+	}
+	stsBuilder := beku.NewSts().SetNamespaceAndName("yulibaozi", "mysql").SetPodLabels(map[string]string{"app": "mysql"}).
+		SetContainer("mysql", "mysql:5.7", 3306).SetReplicas(3).SetDisruptionBudget(beku.Parse("50%"), true)
+	_, pdb, err = stsBuilder.FinishWithPDB()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if pdb.Spec.MaxUnavailable.StrVal != "50%" || len(stsBuilder.Warnings()) != 0 {
+		t.Fatalf("PDB is %+v,warnings:%v", pdb, stsBuilder.Warnings())
+	}
+}
+
+func Test_DisruptionBudgetWarningsAfterFinish(t *testing.T) {
+	dpBuilder := beku.NewDeployment().SetNamespaceAndName("yulibaozi", "web").SetPodLabels(map[string]string{"app": "web"}).
+		SetContainer("web", "web:v1", 8080).SetReplicas(1).SetDisruptionBudget(beku.FromInt(1))
+	if _, err := dpBuilder.Finish(); err != nil {
+		t.Fatal(err)
+	}
+	if len(dpBuilder.Warnings()) != 1 {
+		t.Fatalf("Deployment PDB blocking all evictions should warn after Finish,warnings:%v", dpBuilder.Warnings())
+	}
+	stsBuilder := beku.NewSts().SetNamespaceAndName("yulibaozi", "mysql").SetPodLabels(map[string]string{"app": "mysql"}).
+		SetContainer("mysql", "mysql:5.7", 3306).SetReplicas(2).SetDisruptionBudget(beku.FromInt(2))
+	if _, err := stsBuilder.Finish(); err != nil {
+		t.Fatal(err)
+	}
+	if len(stsBuilder.Warnings()) != 1 {
+		t.Fatalf("StatefulSet PDB blocking all evictions should warn after Finish,warnings:%v", stsBuilder.Warnings())
+	}
+}
@@ -0,0 +1,35 @@
+package test
+
+import (
+	"testing"
+
+	"github.com/yulibaozi/beku"
+)
+
+func Test_CreatePriorityClass(t *testing.T) {
+	pc, err := beku.NewPriorityClass().SetNameAndValue("high-priority", 1000000).
+		SetPreemptionPolicy(beku.PreemptNever).SetDescription("online services").Finish()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if pc.APIVersion != "scheduling.k8s.io/v1" || pc.PreemptionPolicy == nil || *pc.PreemptionPolicy != "Never" {
+		t.Fatalf("PriorityClass is %+v", pc)
+	}
+	if _, err = beku.NewPriorityClass().SetNameAndValue("too-high", beku.HighestUserDefinablePriority+1).Finish(); err == nil {
+		t.Fatal("PriorityClass with value greater than HighestUserDefinablePriority should return error")
+	}
+	if _, err = beku.NewPriorityClass().SetNameAndValue("system-app", 100).Finish(); err == nil {
+		t.Fatal("PriorityClass with system- prefix should return error")
+	}
+}
+
+func Test_CheckPriorityClasses(t *testing.T) {
+	pc := beku.NewPriorityClass().SetNameAndValue("high-priority", 1000000)
+	dp := beku.NewDeployment().SetNamespaceAndName("yulibaozi", "web").SetPodLabels(map[string]string{"app": "web"}).
+		SetContainer("web", "web:v1", 8080).SetPodPriorityClass("high-priority")
+	ds := beku.NewDS().SetNamespaceAndName("yulibaozi", "agent").SetPodLabels(map[string]string{"app": "agent"}).
+		SetContainer("agent", "agent:v1", 9100).SetPodPriorityClass("system-node-critical")
+	if err := beku.NewBundle().Add(pc, dp, ds).CheckPriorityClasses(); err != nil {
+		t.Fatal(err)
+	}
+}
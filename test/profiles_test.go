@@ -0,0 +1,77 @@
+package test
+
+import (
+	"testing"
+
+	"github.com/yulibaozi/beku"
+	appsv1 "k8s.io/api/apps/v1"
+)
+
+func Test_Profiles(t *testing.T) {
+	dp := beku.NewDeployment().SetNamespaceAndName("yulibaozi", "mysql").SetSelector(map[string]string{"app": "mysql"}).
+		SetContainer("mysql", "mysql:5.6", 3306)
+	profiles := beku.NewProfiles(dp).
+		AddFunc("staging", func(dp *beku.Deployment) { dp.SetReplicas(2) }).
+		AddFunc("prod", func(dp *beku.Deployment) *beku.Deployment { return dp.SetReplicas(5) }).
+		AddPatch("prod", []byte(`
+spec:
+  template:
+    spec:
+      containers:
+      - name: mysql
+        image: mysql:5.7
+`))
+	objs, report, err := profiles.Finish("prod")
+	if err != nil {
+		t.Fatal(err)
+	}
+	prod := objs[0].(*appsv1.Deployment)
+	if *prod.Spec.Replicas != 5 || prod.Spec.Template.Spec.Containers[0].Image != "mysql:5.7" {
+		t.Fatalf("prod Deployment is %+v", prod.Spec)
+	}
+	fields := report["Deployment/yulibaozi/mysql"]
+	if fields["spec.replicas"] != "prod" || fields["spec.template.spec.containers[0].image"] != "prod" ||
+		fields["spec.template.spec.containers[0].name"] != beku.ProfileBase {
+		t.Fatalf("report is:\n%s", report)
+	}
+
+	objs, _, err = profiles.Finish("staging")
+	if err != nil {
+		t.Fatal(err)
+	}
+	staging := objs[0].(*appsv1.Deployment)
+	if *staging.Spec.Replicas != 2 || staging.Spec.Template.Spec.Containers[0].Image != "mysql:5.6" {
+		t.Fatalf("staging Deployment is %+v", staging.Spec)
+	}
+	if _, _, err = profiles.Finish("dev"); err == nil {
+		t.Fatal("unregistered profile should return error")
+	}
+}
+
+func Test_ProfilesBundle(t *testing.T) {
+	bundle := beku.NewBundle().Add(
+		beku.NewDeployment().SetNamespaceAndName("yulibaozi", "mysql").SetSelector(map[string]string{"app": "mysql"}).
+			SetContainer("mysql", "mysql:5.6", 3306),
+		beku.NewDeployment().SetNamespaceAndName("yulibaozi", "redis").SetSelector(map[string]string{"app": "redis"}).
+			SetContainer("redis", "redis:5", 6379),
+	)
+	profiles := beku.NewProfiles(bundle).
+		AddFunc("prod", func(dp *beku.Deployment) { dp.SetReplicas(3) }, "redis").
+		AddPatch("prod", []byte(`
+apiVersion: apps/v1
+kind: Deployment
+metadata:
+  name: mysql
+spec:
+  replicas: 4
+`))
+	for i := 0; i < 2; i++ {
+		objs, report, err := profiles.Finish("prod")
+		if err != nil {
+			t.Fatal(err)
+		}
+		if *objs[0].(*appsv1.Deployment).Spec.Replicas != 4 || *objs[1].(*appsv1.Deployment).Spec.Replicas != 3 {
+			t.Fatalf("report is:\n%s", report)
+		}
+	}
+}
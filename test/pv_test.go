@@ -18,3 +18,72 @@ func Test_CreatePV(t *testing.T) {
 	}
 	t.Log(string(databyts))
 }
+
+func Test_CreateLocalPV(t *testing.T) {
+	pv, err := beku.NewPV().SetName("local-pv").SetCapacity(map[beku.ResourceName]string{beku.ResourceStorage: "10Gi"}).
+		SetAccessMode(beku.ReadWriteOnce).SetLocal("/mnt/disks/ssd1").SetNodes("node-1").Finish()
+	if err != nil {
+		t.Fatal(err)
+	}
+	terms := pv.Spec.NodeAffinity.Required.NodeSelectorTerms
+	if len(terms) != 1 || terms[0].MatchExpressions[0].Values[0] != "node-1" {
+		t.Fatalf("PersistentVolume node affinity is %+v", pv.Spec.NodeAffinity)
+	}
+	if _, err = beku.NewPV().SetName("local-pv").SetCapacity(map[beku.ResourceName]string{beku.ResourceStorage: "10Gi"}).
+		SetAccessMode(beku.ReadWriteOnce).SetLocal("/mnt/disks/ssd1").Finish(); err == nil {
+		t.Fatal("local PersistentVolume without node affinity should return error")
+	}
+	if _, err = beku.NewPV().SetName("local-pv").SetCapacity(map[beku.ResourceName]string{beku.ResourceStorage: "10Gi"}).
+		SetAccessMode(beku.ReadWriteMany).SetLocal("/mnt/disks/ssd1").SetNodes("node-1").Finish(); err == nil {
+		t.Fatal("local PersistentVolume with ReadWriteMany should return error")
+	}
+}
+
+func Test_CreatePVSources(t *testing.T) {
+	lun := int32(0)
+	_, err := beku.NewPV().SetName("iscsi-pv").SetCapacity(map[beku.ResourceName]string{beku.ResourceStorage: "10Gi"}).
+		SetAccessMode(beku.ReadWriteOnce).SetISCSI(&beku.ISCSIPersistentVolumeSource{
+		TargetPortal: "10.0.0.1:3260", IQN: "iqn.2019-01.com.example:storage", Lun: 1}).Finish()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err = beku.NewPV().SetName("iscsi-pv").SetISCSI(&beku.ISCSIPersistentVolumeSource{
+		TargetPortal: "10.0.0.1:3260", IQN: "iqn.2019-01.com.example:storage", SessionCHAPAuth: true}).Finish(); err == nil {
+		t.Fatal("iSCSI with CHAP and without secretRef should return error")
+	}
+	if _, err = beku.NewPV().SetName("fc-pv").SetFC(&beku.FCVolumeSource{
+		TargetWWNs: []string{"500a0981891b8dc5"}, Lun: &lun, WWIDs: []string{"3600508b400105e210000900000490000"}}).Finish(); err == nil {
+		t.Fatal("FC with both targetWWNs and wwids should return error")
+	}
+	_, err = beku.NewPV().SetName("csi-pv").SetCapacity(map[beku.ResourceName]string{beku.ResourceStorage: "10Gi"}).
+		SetAccessMode(beku.ReadWriteMany).SetCSI(&beku.CSIPersistentVolumeSource{
+		Driver: "nfs.csi.k8s.io", VolumeHandle: "nfs-server/share", VolumeAttributes: map[string]string{"server": "10.0.0.2"},
+		NodePublishSecretRef: &beku.SecretReference{Name: "nfs-secret", Namespace: "default"}}).Finish()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err = beku.NewPV().SetName("csi-pv").SetCSI(&beku.CSIPersistentVolumeSource{Driver: "nfs.csi.k8s.io"}).Finish(); err == nil {
+		t.Fatal("CSI without volumeHandle should return error")
+	}
+	if _, err = beku.NewPV().SetName("two-pv").SetCapacity(map[beku.ResourceName]string{beku.ResourceStorage: "10Gi"}).
+		SetAccessMode(beku.ReadWriteOnce).SetHostPath("/data").SetGlusterFS("glusterfs-cluster", "vol", false).Finish(); err == nil {
+		t.Fatal("PersistentVolume with two sources should return error")
+	}
+}
+
+func Test_CreateUnionPVBlock(t *testing.T) {
+	pv, _, err := beku.NewUnionPV().SetNamespaceAndName("yulibaozi", "block").SetAccessMode(beku.ReadWriteOnce).
+		SetCapacity(map[beku.ResourceName]string{beku.ResourceStorage: "10Gi"}).SetVolumeMode(beku.PersistentVolumeBlock).
+		SetLocal("/dev/sdb").SetNodes("node-1").Finish()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if pv.Spec.VolumeMode == nil || *pv.Spec.VolumeMode != "Block" {
+		t.Fatalf("PersistentVolume volume mode is %v", pv.Spec.VolumeMode)
+	}
+	if _, _, err = beku.NewUnionPV().SetNamespaceAndName("yulibaozi", "block").SetAccessMode(beku.ReadWriteOnce).
+		SetCapacity(map[beku.ResourceName]string{beku.ResourceStorage: "10Gi"}).SetVolumeMode(beku.PersistentVolumeBlock).
+		SetHostPath("/data").Finish(); err == nil {
+		t.Fatal("hostPath with volume mode Block should return error")
+	}
+}
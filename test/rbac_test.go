@@ -0,0 +1,107 @@
+package test
+
+import (
+	"testing"
+
+	"github.com/yulibaozi/beku"
+	"k8s.io/api/rbac/v1beta1"
+)
+
+func Test_UnionRBAC(t *testing.T) {
+	sa, role, binding, err := beku.NewUnionRBAC().SetNamespaceAndName("yulibaozi", "reader").
+		SetRole([]string{"get", "list", "watch"}, []string{""}, []string{"pods"}).Finish()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, ok := role.(*v1beta1.ClusterRole); !ok {
+		t.Fatalf("role kind is %T,want *v1beta1.ClusterRole", role)
+	}
+	crb := binding.(*v1beta1.ClusterRoleBinding)
+	if crb.RoleRef.Name != "reader" || crb.Subjects[0].Name != sa.GetName() || crb.Subjects[0].Namespace != "yulibaozi" {
+		t.Fatalf("binding not reference the ServiceAccount and role: %+v", crb)
+	}
+	dp, err := beku.NewDeployment().SetNamespaceAndName("yulibaozi", "reader").SetSelector(map[string]string{"app": "reader"}).
+		SetContainer("reader", "reader:v1", 8080).SetServiceAccount(sa.GetName()).Finish()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if dp.Spec.Template.Spec.ServiceAccountName != "reader" {
+		t.Fatalf("ServiceAccountName is %q", dp.Spec.Template.Spec.ServiceAccountName)
+	}
+}
+
+func Test_UnionRBACNamespaced(t *testing.T) {
+	_, role, binding, err := beku.NewUnionRBAC().SetNamespaceAndName("yulibaozi", "reader").SetNamespaced().
+		SetRole([]string{"get"}, []string{""}, []string{"configmaps"}).Finish()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if r, ok := role.(*v1beta1.Role); !ok || r.GetNamespace() != "yulibaozi" {
+		t.Fatalf("role is %#v", role)
+	}
+	if b, ok := binding.(*v1beta1.RoleBinding); !ok || b.RoleRef.Kind != "Role" {
+		t.Fatalf("binding is %#v", binding)
+	}
+	if _, _, _, err = beku.NewUnionRBAC().SetNamespaceAndName("yulibaozi", "reader").Finish(); err == nil {
+		t.Fatal("UnionRBAC without rules should return error")
+	}
+}
+
+func Test_CreateRoleAndRoleBinding(t *testing.T) {
+	role, err := beku.NewRole().SetNamespaceAndName("yulibaozi", "config-reader").
+		SetRule(beku.PolicyRule{Verbs: []string{"get"}, APIGroups: []string{""}, Resources: []string{"configmaps"}, ResourceNames: []string{"app-config"}}).
+		SetPreset(beku.PresetSecretsReader).Finish()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(role.Rules) != 2 || role.Rules[0].ResourceNames[0] != "app-config" {
+		t.Fatalf("Role is %+v", role)
+	}
+	if _, err = beku.NewRole().SetNamespaceAndName("yulibaozi", "metrics").
+		SetRule(beku.PolicyRule{Verbs: []string{"get"}, NonResourceURLs: []string{"/metrics"}}).Finish(); err == nil {
+		t.Fatal("Role with nonResourceURLs should return error")
+	}
+	rb, err := beku.NewRoleBinding().SetNamespaceAndName("yulibaozi", "view").Subject("reader", beku.SA, "").
+		SetClusterRoleRef("view").Finish()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if rb.RoleRef.Kind != "ClusterRole" || rb.Subjects[0].Namespace != "yulibaozi" {
+		t.Fatalf("RoleBinding is %+v", rb)
+	}
+}
+
+func Test_ClusterRoleAggregation(t *testing.T) {
+	role, err := beku.NewClusterRole().SetName("crd-viewer").AggregateTo("view").
+		SetRole([]string{"get", "list", "watch"}, []string{"example.com"}, []string{"widgets"}).
+		SetNonResourceURLs([]string{"get"}, []string{"/healthz"}).Finish()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if role.Labels[beku.AggregateToKeyPrefix+"view"] != "true" || role.Rules[1].NonResourceURLs[0] != "/healthz" {
+		t.Fatalf("ClusterRole is %+v", role)
+	}
+	aggregated, err := beku.NewClusterRole().SetName("monitoring").
+		SetAggregationRule(map[string]string{beku.AggregateToKeyPrefix + "monitoring": "true"}).Finish()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(aggregated.AggregationRule.ClusterRoleSelectors) != 1 {
+		t.Fatalf("ClusterRole is %+v", aggregated)
+	}
+}
+
+func Test_ClusterRoleRulesAppend(t *testing.T) {
+	presetRules := len(beku.PresetSecretsReader.Rules())
+	role, err := beku.NewClusterRole().SetName("widget-admin").
+		SetRole([]string{"get"}, []string{"example.com"}, []string{"widgets"}).
+		SetPreset(beku.PresetSecretsReader).
+		SetRule(beku.PolicyRule{Verbs: []string{"get"}, APIGroups: []string{""}, Resources: []string{"configmaps"}, ResourceNames: []string{"widget-conf"}}).
+		SetRole([]string{"list"}, []string{"example.com"}, []string{"gadgets"}).Finish()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(role.Rules) != presetRules+3 || role.Rules[0].Resources[0] != "widgets" || role.Rules[len(role.Rules)-1].Resources[0] != "gadgets" {
+		t.Fatalf("SetRole,SetRule and SetPreset should append rules in order, rules are %+v", role.Rules)
+	}
+}
@@ -0,0 +1,88 @@
+package test
+
+import (
+	"testing"
+
+	"github.com/yulibaozi/beku"
+	"k8s.io/api/core/v1"
+)
+
+func Test_CreateResourceQuota(t *testing.T) {
+	quota, err := beku.NewResourceQuota().SetNamespaceAndName("yulibaozi", "compute").
+		SetHard(map[beku.ResourceName]string{
+			beku.ResourceRequestsCPU:                                              "4",
+			beku.ResourceLimitsMemory:                                             "8Gi",
+			beku.ResourcePods:                                                     "20",
+			beku.ResourceCount("deployments.apps"):                                "10",
+			beku.StorageClassResource("ssd", beku.ResourceRequestsStorage):        "100Gi",
+			beku.StorageClassResource("ssd", beku.ResourcePersistentVolumeClaims): "5",
+		}).Finish()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(quota.Spec.Hard) != 6 || quota.Kind != "ResourceQuota" {
+		t.Fatalf("ResourceQuota is %+v", quota)
+	}
+	if _, err = beku.NewResourceQuota().SetNamespaceAndName("yulibaozi", "besteffort").
+		SetHard(map[beku.ResourceName]string{beku.ResourcePods: "5", beku.ResourceLimitsCPU: "1"}).
+		SetScopes(beku.ResourceQuotaScopeBestEffort).Finish(); err == nil {
+		t.Fatal("ResourceQuota with BestEffort scope should only allow pods")
+	}
+	if _, err = beku.NewResourceQuota().SetNamespaceAndName("yulibaozi", "terminating").
+		SetHard(map[beku.ResourceName]string{beku.ResourcePods: "5"}).
+		SetScopes(beku.ResourceQuotaScopeTerminating, beku.ResourceQuotaScopeNotTerminating).Finish(); err == nil {
+		t.Fatal("ResourceQuota with Terminating and NotTerminating scopes should return error")
+	}
+	if _, err = beku.NewDeployment().SetNamespaceAndName("yulibaozi", "web").SetSelector(map[string]string{"app": "web"}).
+		SetContainer("web", "web:v1", 8080).SetResourceLimit(map[beku.ResourceName]string{beku.ResourcePods: "3"}).Finish(); err == nil {
+		t.Fatal("quota-only resource name should not be allowed in container limits")
+	}
+}
+
+func Test_CreateLimitRange(t *testing.T) {
+	lr, err := beku.NewLimitRange().SetNamespaceAndName("yulibaozi", "limits").
+		SetContainerDefault(map[beku.ResourceName]string{beku.ResourceCPU: "100m", beku.ResourceMemory: "128Mi"},
+			map[beku.ResourceName]string{beku.ResourceCPU: "500m", beku.ResourceMemory: "512Mi"}).
+		SetLimit(beku.LimitRangeItem{Type: beku.LimitTypePersistentVolumeClaim,
+			Min: map[beku.ResourceName]string{beku.ResourceStorage: "1Gi"},
+			Max: map[beku.ResourceName]string{beku.ResourceStorage: "50Gi"}}).Finish()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(lr.Spec.Limits) != 2 || lr.Spec.Limits[0].Type != v1.LimitTypeContainer {
+		t.Fatalf("LimitRange is %+v", lr.Spec)
+	}
+	if _, err = beku.NewLimitRange().SetNamespaceAndName("yulibaozi", "limits").
+		SetContainerDefault(map[beku.ResourceName]string{beku.ResourceCPU: "1"},
+			map[beku.ResourceName]string{beku.ResourceCPU: "500m"}).Finish(); err == nil {
+		t.Fatal("LimitRange with defaultRequest greater than default should return error")
+	}
+	if _, err = beku.NewLimitRange().SetNamespaceAndName("yulibaozi", "limits").
+		SetLimit(beku.LimitRangeItem{Type: beku.LimitTypePod,
+			Default: map[beku.ResourceName]string{beku.ResourceCPU: "1"}}).Finish(); err == nil {
+		t.Fatal("LimitRange with default of Pod type should return error")
+	}
+}
+
+func Test_NamespaceWithQuota(t *testing.T) {
+	ns := beku.NewNs().SetName("yulibaozi").
+		WithQuota(beku.NewResourceQuota().SetName("compute").
+			SetHard(map[beku.ResourceName]string{beku.ResourceRequestsCPU: "4"})).
+		WithLimitRange(beku.NewLimitRange().SetName("limits").
+			SetContainerDefault(nil, map[beku.ResourceName]string{beku.ResourceMemory: "512Mi"}))
+	objs, err := beku.NewBundle().Add(ns).Finish()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(objs) != 3 {
+		t.Fatalf("Bundle objects is %d,expected 3", len(objs))
+	}
+	quota, ok := objs[1].(*v1.ResourceQuota)
+	if !ok || quota.GetNamespace() != "yulibaozi" {
+		t.Fatalf("ResourceQuota is %+v", objs[1])
+	}
+	lr, ok := objs[2].(*v1.LimitRange)
+	if !ok || lr.GetNamespace() != "yulibaozi" {
+		t.Fatalf("LimitRange is %+v", objs[2])
+	}
+}
@@ -1,7 +1,16 @@
 package test
 
 import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/json"
+	"encoding/pem"
+	"math/big"
 	"testing"
+	"time"
 
 	"github.com/yulibaozi/beku"
 )
@@ -20,3 +29,80 @@ func Test_CreateSecret(t *testing.T) {
 	t.Error(string(result))
 
 }
+
+// selfSignedCert generate PEM encoded self-signed certificate and private key
+func selfSignedCert(t *testing.T, notAfter time.Time) (certPEM, keyPEM []byte) {
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	template := &x509.Certificate{
+		SerialNumber: big.NewInt(1),
+		Subject:      pkix.Name{CommonName: "example.com"},
+		NotBefore:    notAfter.Add(-24 * time.Hour),
+		NotAfter:     notAfter,
+	}
+	der, err := x509.CreateCertificate(rand.Reader, template, template, &key.PublicKey, key)
+	if err != nil {
+		t.Fatal(err)
+	}
+	keyDer, err := x509.MarshalECPrivateKey(key)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: der}), pem.EncodeToMemory(&pem.Block{Type: "EC PRIVATE KEY", Bytes: keyDer})
+}
+
+func Test_CreateTLSSecret(t *testing.T) {
+	notAfter := time.Now().Add(90 * 24 * time.Hour).Truncate(time.Second)
+	certPEM, keyPEM := selfSignedCert(t, notAfter)
+	secret := beku.NewTLSSecret(certPEM, keyPEM).SetNamespaceAndName("yulibaozi", "example-tls")
+	sec, err := secret.Finish()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if sec.Type != "kubernetes.io/tls" || len(sec.Data["tls.crt"]) <= 0 || len(sec.Data["tls.key"]) <= 0 {
+		t.Fatalf("TLS Secret is %+v", sec)
+	}
+	expiry, err := secret.TLSNotAfter()
+	if err != nil || !expiry.Equal(notAfter.UTC()) {
+		t.Fatalf("TLS expiry is %v, err is %v", expiry, err)
+	}
+	_, otherKey := selfSignedCert(t, notAfter)
+	if _, err = beku.NewTLSSecret(certPEM, otherKey).SetName("mismatch").Finish(); err == nil {
+		t.Fatal("TLS Secret with mismatched key should return error")
+	}
+	expiredCert, expiredKey := selfSignedCert(t, time.Now().Add(-time.Hour))
+	expired := beku.NewTLSSecret(expiredCert, expiredKey).SetName("expired")
+	if _, err = expired.Finish(); err != nil {
+		t.Fatalf("TLS Secret with expired certificate should be rendered for renewal: %v", err)
+	}
+	if expiry, err = expired.TLSNotAfter(); err != nil || !time.Now().After(expiry) {
+		t.Fatalf("TLS expiry should report expired certificate, it is %v, err is %v", expiry, err)
+	}
+}
+
+func Test_CreateTypedSecrets(t *testing.T) {
+	sec, err := beku.NewDockerRegistrySecret("registry.example.com", "admin", "password", "admin@example.com").
+		SetNamespaceAndName("yulibaozi", "registry").Finish()
+	if err != nil {
+		t.Fatal(err)
+	}
+	var config map[string]map[string]map[string]string
+	if err = json.Unmarshal(sec.Data[".dockerconfigjson"], &config); err != nil {
+		t.Fatal(err)
+	}
+	if config["auths"]["registry.example.com"]["auth"] != beku.Base64Encode([]byte("admin:password")) {
+		t.Fatalf("dockerconfigjson is %s", sec.Data[".dockerconfigjson"])
+	}
+	if _, err = beku.NewBasicAuthSecret("admin", "").SetName("basic").Finish(); err != nil {
+		t.Fatal(err)
+	}
+	if _, err = beku.NewSSHAuthSecret([]byte("ssh-rsa AAAA")).SetName("ssh").Finish(); err == nil {
+		t.Fatal("SSH Secret without PEM private key should return error")
+	}
+	if _, err = beku.NewSecret().SetName("tls").SetType(beku.SecretTypeTLS).
+		SetDataString(map[string]string{"tls.crt": "cert"}).Finish(); err == nil {
+		t.Fatal("TLS Secret without tls.key should return error")
+	}
+}
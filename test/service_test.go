@@ -4,6 +4,7 @@ import (
 	"testing"
 
 	"github.com/yulibaozi/beku"
+	"k8s.io/api/core/v1"
 )
 
 func Test_CreateSvc(t *testing.T) {
@@ -21,3 +22,42 @@ func Test_CreateSvc(t *testing.T) {
 	}
 	t.Error(string(data))
 }
+
+func Test_CreateExternalNameSvc(t *testing.T) {
+	svc, err := beku.NewSvc().SetNamespaceAndName("yulibaozi", "mysql").SetExternalName("mysql.example.com").Finish()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if svc.Spec.ExternalName != "mysql.example.com" {
+		t.Fatalf("Service is %+v", svc.Spec)
+	}
+	if _, err = beku.NewSvc().SetNamespaceAndName("yulibaozi", "mysql").SetExternalName("mysql.example.com").
+		SetPort(beku.ServicePort{Port: 3306}).Finish(); err == nil {
+		t.Fatal("ExternalName Service with ports should return error")
+	}
+	if _, err = beku.NewSvc().SetNamespaceAndName("yulibaozi", "mysql").SetExternalName("http://mysql").Finish(); err == nil {
+		t.Fatal("ExternalName Service with invalid host should return error")
+	}
+}
+
+func Test_CreateSvcWithEndpoints(t *testing.T) {
+	svc := beku.NewSvc().SetNamespaceAndName("yulibaozi", "mysql").SetPort(beku.ServicePort{Name: "mysql", Port: 3306}).
+		WithEndpoints(beku.NewEndpoints().SetAddresses("10.0.0.10", "10.0.0.11").
+			SetPorts(beku.EndpointPort{Name: "mysql", Port: 3306}))
+	objs, err := beku.NewBundle().Add(svc).Finish()
+	if err != nil {
+		t.Fatal(err)
+	}
+	ep, ok := objs[1].(*v1.Endpoints)
+	if !ok || ep.GetName() != "mysql" || ep.GetNamespace() != "yulibaozi" || len(ep.Subsets[0].Addresses) != 2 {
+		t.Fatalf("Endpoints is %+v", objs[1])
+	}
+	svc = beku.NewSvc().SetNamespaceAndName("yulibaozi", "mysql").SetPort(beku.ServicePort{Name: "mysql", Port: 3306}).
+		WithEndpoints(beku.NewEndpoints().SetAddresses("10.0.0.10").SetPorts(beku.EndpointPort{Name: "db", Port: 3306}))
+	if _, err = beku.NewBundle().Add(svc).Finish(); err == nil {
+		t.Fatal("Endpoints with port name which is not in Service should return error")
+	}
+	if _, err = beku.NewEndpoints().SetNamespaceAndName("yulibaozi", "mysql").SetAddresses("mysql.example.com").Finish(); err == nil {
+		t.Fatal("Endpoints with hostname should return error")
+	}
+}
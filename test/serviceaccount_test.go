@@ -0,0 +1,44 @@
+package test
+
+import (
+	"testing"
+
+	"github.com/yulibaozi/beku"
+)
+
+func Test_CreateServiceAccount(t *testing.T) {
+	sa, err := beku.NewSa().SetNamespaceAndName("yulibaozi", "web").SetLabels(map[string]string{"app": "web"}).
+		SetImagePullSecrets("registry", "registry").SetSecrets("web-token").SetAutomountToken(false).Finish()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(sa.ImagePullSecrets) != 1 || len(sa.Secrets) != 1 || sa.AutomountServiceAccountToken == nil || *sa.AutomountServiceAccountToken {
+		t.Fatalf("ServiceAccount is %+v", sa)
+	}
+	sa, err = beku.NewSa().YAMLNew([]byte("metadata:\n  name: web\nimagePullSecrets:\n- name: registry\n")).Finish()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if sa.GetNamespace() != "default" || sa.ImagePullSecrets[0].Name != "registry" {
+		t.Fatalf("ServiceAccount is %+v", sa)
+	}
+}
+
+func Test_SetWorkloadServiceAccount(t *testing.T) {
+	sts, err := beku.NewSts().SetNamespaceAndName("yulibaozi", "mysql").SetPodLabels(map[string]string{"app": "mysql"}).
+		SetContainer("mysql", "mysql:5.7", 3306).SetServiceAccount("mysql", false).Finish()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if sts.Spec.Template.Spec.ServiceAccountName != "mysql" || *sts.Spec.Template.Spec.AutomountServiceAccountToken {
+		t.Fatalf("StatefulSet pod spec is %+v", sts.Spec.Template.Spec)
+	}
+	pod, err := beku.NewPod().SetNamespaceAndName("yulibaozi", "debug").SetContainer("debug", "busybox", 80).
+		SetServiceAccount("debug").Finish()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if pod.Spec.ServiceAccountName != "debug" || pod.Spec.AutomountServiceAccountToken != nil {
+		t.Fatalf("Pod spec is %+v", pod.Spec)
+	}
+}
@@ -0,0 +1,50 @@
+package test
+
+import (
+	"testing"
+
+	"github.com/yulibaozi/beku"
+)
+
+func Test_CreateStorageClass(t *testing.T) {
+	sc, err := beku.NewStorageClass().SetName("nfs").SetNFSClient("fuseim.pri/ifs").SetDefault(true).
+		SetAllowVolumeExpansion(true).SetReclaimPolicy(beku.PersistentVolumeReclaimRetain).Finish()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if sc.Annotations[beku.DefaultStorageClassKey] != "true" || sc.Parameters["archiveOnDelete"] != "true" || !*sc.AllowVolumeExpansion {
+		t.Fatalf("StorageClass is %+v", sc)
+	}
+	sc, err = beku.NewStorageClass().SetName("local").SetLocalStorage().Finish()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if sc.Provisioner != beku.ProvisionerNoProvisioner || string(*sc.VolumeBindingMode) != "WaitForFirstConsumer" {
+		t.Fatalf("StorageClass is %+v", sc)
+	}
+	if _, err = beku.NewStorageClass().SetName("local").SetLocalStorage().
+		SetVolumeBindingMode(beku.VolumeBindingImmediate).Finish(); err == nil {
+		t.Fatal("local StorageClass with Immediate binding mode should return error")
+	}
+}
+
+func Test_CreateCephStorageClass(t *testing.T) {
+	sc, err := beku.NewStorageClass().SetName("rbd").SetCephRBD(beku.CephRBDStorageParameters{
+		Monitors:        []string{"10.16.153.105:6789", "10.16.153.106:6789"},
+		AdminSecretName: "ceph-admin",
+		UserSecretName:  "ceph-user",
+		Pool:            "kube",
+	}).Finish()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if sc.Parameters["monitors"] != "10.16.153.105:6789,10.16.153.106:6789" || sc.Parameters["pool"] != "kube" {
+		t.Fatalf("StorageClass parameters is %v", sc.Parameters)
+	}
+	if _, err = beku.NewStorageClass().SetName("cephfs").SetCephFS(beku.CephFSStorageParameters{
+		Monitors: []string{"10.16.153.105:6789"},
+		AdminID:  "admin",
+	}).Finish(); err == nil {
+		t.Fatal("CephFS StorageClass without adminSecretName should return error")
+	}
+}
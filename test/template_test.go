@@ -0,0 +1,84 @@
+package test
+
+import (
+	"os"
+	"strings"
+	"testing"
+
+	"github.com/yulibaozi/beku"
+)
+
+const dpTemplate = `apiVersion: apps/v1
+kind: Deployment
+metadata:
+  name: {{ .name }}
+  namespace: ${NAMESPACE}
+spec:
+  replicas: {{ .replicas }}
+  selector:
+    matchLabels:
+      app: {{ .name }}
+  template:
+    metadata:
+      labels:
+        app: {{ .name }}
+    spec:
+      containers:
+      {{- range .containers }}
+      - name: {{ .name }}
+        image: {{ .image | quote }}
+      {{- end }}
+`
+
+func Test_YAMLNewTemplate(t *testing.T) {
+	os.Setenv("NAMESPACE", "yulibaozi")
+	defer os.Unsetenv("NAMESPACE")
+	values := map[string]interface{}{
+		"name":       "mysql",
+		"replicas":   3,
+		"containers": []map[string]string{{"name": "mysql", "image": "mysql:5.6"}},
+	}
+	dp, err := beku.NewDeployment().YAMLNewTemplate([]byte(dpTemplate), values).Finish()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if dp.GetNamespace() != "yulibaozi" || *dp.Spec.Replicas != 3 || dp.Spec.Template.Spec.Containers[0].Image != "mysql:5.6" {
+		t.Fatalf("Deployment is %+v", dp)
+	}
+
+	delete(values, "replicas")
+	_, err = beku.NewDeployment().YAMLNewTemplate([]byte(dpTemplate), values).Finish()
+	if err == nil || !strings.Contains(err.Error(), "beku:7:") {
+		t.Fatalf("missing key should return error with template line, got %v", err)
+	}
+
+	values["replicas"] = "1\n  bad: [yaml"
+	_, err = beku.NewDeployment().YAMLNewTemplate([]byte(dpTemplate), values).Finish()
+	if err == nil || !strings.Contains(err.Error(), "template line 7") {
+		t.Fatalf("invalid yaml should return error with template line, got %v", err)
+	}
+
+	os.Unsetenv("NAMESPACE")
+	values["replicas"] = 1
+	_, err = beku.NewDeployment().YAMLNewTemplate([]byte(dpTemplate), values).Finish()
+	if err == nil || !strings.Contains(err.Error(), "template line 5") {
+		t.Fatalf("unset ${NAMESPACE} should return error with template line, got %v", err)
+	}
+	values["NAMESPACE"] = "default"
+	if _, err = beku.NewDeployment().YAMLNewTemplate([]byte(dpTemplate), values).Finish(); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func Test_RenderTemplateDollarValue(t *testing.T) {
+	os.Setenv("NAMESPACE", "yulibaozi")
+	defer os.Unsetenv("NAMESPACE")
+	tpl := "namespace: ${NAMESPACE}\npassword: {{ .password | quote }}\nprice: $$5"
+	byts, err := beku.RenderTemplate([]byte(tpl), map[string]string{"password": "p$$w${X}{{"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if expect := "namespace: yulibaozi\npassword: \"p$$w${X}{{\"\nprice: $5"; string(byts) != expect {
+		t.Fatalf("rendered template is:\n%s", byts)
+	}
+}
@@ -0,0 +1,80 @@
+package test
+
+import (
+	"testing"
+
+	"github.com/yulibaozi/beku"
+)
+
+func Test_DeploymentVolumes(t *testing.T) {
+	mode := int32(0400)
+	dp, err := beku.NewDeployment().SetNamespaceAndName("yulibaozi", "nginx").SetSelector(map[string]string{"app": "nginx"}).
+		SetContainer("nginx", "nginx:1.17", 80).SetContainer("sidecar", "busybox", 8080).
+		SetConfigMapVolume("conf", &beku.ConfigMapVolumeSource{Name: "nginx-conf", Items: []beku.KeyToPath{{Key: "nginx.conf", Path: "nginx.conf"}}}).
+		SetSecretVolume("tls", &beku.SecretVolumeSource{SecretName: "nginx-tls", DefaultMode: &mode}).
+		SetEmptyDirVolume("cache", true, "256Mi").
+		SetProjectedVolume("all", []beku.VolumeProjection{
+			{ConfigMap: "nginx-conf"},
+			{DownwardAPI: []beku.DownwardAPIVolumeFile{{Path: "labels", FieldPath: "metadata.labels"}}},
+			{ServiceAccountToken: &beku.ServiceAccountTokenProjection{Path: "token", ExpirationSeconds: 3600}},
+		}).
+		SetVolumeMount(beku.VolumeMount{Name: "conf", MountPath: "/etc/nginx/nginx.conf", SubPath: "nginx.conf", ReadOnly: true}).
+		SetVolumeMount(beku.VolumeMount{Name: "tls", MountPath: "/etc/tls", ReadOnly: true}).
+		SetVolumeMount(beku.VolumeMount{Name: "cache", MountPath: "/cache", Container: "sidecar"}).Finish()
+	if err != nil {
+		t.Fatal(err)
+	}
+	spec := dp.Spec.Template.Spec
+	if len(spec.Volumes) != 4 || len(spec.Containers[0].VolumeMounts) != 2 || len(spec.Containers[1].VolumeMounts) != 1 {
+		t.Fatalf("Deployment Pod spec is %+v", spec)
+	}
+	if spec.Volumes[2].EmptyDir.Medium != "Memory" || spec.Volumes[2].EmptyDir.SizeLimit.String() != "256Mi" {
+		t.Fatalf("EmptyDir volume is %+v", spec.Volumes[2].EmptyDir)
+	}
+}
+
+func Test_VolumeMountErrors(t *testing.T) {
+	if _, err := beku.NewDeployment().SetNamespaceAndName("yulibaozi", "nginx").SetSelector(map[string]string{"app": "nginx"}).
+		SetContainer("nginx", "nginx:1.17", 80).SetVolumeMount(beku.VolumeMount{Name: "conf", MountPath: "/etc/nginx"}).Finish(); err == nil {
+		t.Fatal("volume mount without volume should return error")
+	}
+	if _, err := beku.NewPod().SetNamespaceAndName("yulibaozi", "nginx").SetContainer("nginx", "nginx:1.17", 80).
+		SetHostPathVolume("logs", "/var/log").SetHostPathVolume("logs", "/var/log/nginx").Finish(); err == nil {
+		t.Fatal("repeated volume name should return error")
+	}
+	if _, err := beku.NewJob().SetNamespaceAndName("yulibaozi", "pi").SetContainer("pi", "perl", 80).
+		SetSecretVolume("secret", &beku.SecretVolumeSource{SecretName: "pi", Items: []beku.KeyToPath{{Key: "a", Path: "../a"}}}).Finish(); err == nil {
+		t.Fatal("items path with '..' should return error")
+	}
+	if _, err := beku.NewDS().SetNamespaceAndName("yulibaozi", "agent").SetContainer("agent", "agent", 80).
+		SetVolumeMount(beku.VolumeMount{Name: "logs", MountPath: "/logs", Container: "missing"}).Finish(); err == nil {
+		t.Fatal("volume mount on missing container should return error")
+	}
+	if _, err := beku.NewSts().SetNamespaceAndName("yulibaozi", "mysql").SetSelector(map[string]string{"app": "mysql"}).
+		SetContainer("mysql", "mysql:5.7", 3306).SetProjectedVolume("all", []beku.VolumeProjection{{ConfigMap: "a", Secret: "b"}}).Finish(); err == nil {
+		t.Fatal("projection with two sources should return error")
+	}
+}
+
+func Test_DownwardAPIResourceContainer(t *testing.T) {
+	pod, err := beku.NewPod().SetNamespaceAndName("yulibaozi", "nginx").
+		SetDownwardAPIVolume("podinfo", []beku.DownwardAPIVolumeFile{{Path: "cpu_limit", Resource: "limits.cpu"}}).
+		SetProjectedVolume("all", []beku.VolumeProjection{
+			{DownwardAPI: []beku.DownwardAPIVolumeFile{{Path: "mem_limit", Resource: "limits.memory", ContainerName: "sidecar"}}},
+		}).
+		SetContainer("nginx", "nginx:1.17", 80).SetContainer("sidecar", "busybox", 8080).Finish()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if name := pod.Spec.Volumes[0].DownwardAPI.Items[0].ResourceFieldRef.ContainerName; name != "nginx" {
+		t.Fatalf("downwardAPI resource container should default to the first container, it is %q", name)
+	}
+	if name := pod.Spec.Volumes[1].Projected.Sources[0].DownwardAPI.Items[0].ResourceFieldRef.ContainerName; name != "sidecar" {
+		t.Fatalf("downwardAPI resource container is %q", name)
+	}
+	if _, err = beku.NewPod().SetNamespaceAndName("yulibaozi", "nginx").SetContainer("nginx", "nginx:1.17", 80).
+		SetDownwardAPIVolume("podinfo", []beku.DownwardAPIVolumeFile{{Path: "cpu_limit", Resource: "limits.cpu", ContainerName: "missing"}}).
+		Finish(); err == nil {
+		t.Fatal("downwardAPI resource of missing container should return error")
+	}
+}
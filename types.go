@@ -2,9 +2,14 @@ package beku
 
 import (
 	"errors"
+	"strings"
+	"time"
 
+	batchv1beta1 "k8s.io/api/batch/v1beta1"
 	"k8s.io/api/core/v1"
+	networkingv1 "k8s.io/api/networking/v1"
 	storv1 "k8s.io/api/storage/v1"
+	"k8s.io/apimachinery/pkg/util/intstr"
 )
 
 const (
@@ -45,6 +50,14 @@ type ServicePort struct {
 	NodePort   int32    `json:"nodePort,omitempty" protobuf:"varint,5,opt,name=nodePort"`
 }
 
+// EndpointPort the port of Endpoints, Name must be the same as the name of Service port,
+// Port is the port of external addresses, Protocol default value 'TCP'
+type EndpointPort struct {
+	Name     string
+	Port     int32
+	Protocol Protocol
+}
+
 var (
 	resourceLimit   = make(map[ResourceName]string, 0)
 	resourceRequest = make(map[ResourceName]string, 0)
@@ -305,6 +318,151 @@ func stringToResourceName(resource string) ResourceName {
 	return ""
 }
 
+// stringToQuotaResourceName return resource name which is supported by ResourceQuota,
+// it supports compute resources, quota-only resource names, ResourceCount() and StorageClassResource()
+func stringToQuotaResourceName(resource string) ResourceName {
+	r := ResourceName(resource)
+	if resources[r] != "" || quotaResources[r] {
+		return r
+	}
+	if strings.HasPrefix(resource, resourceCountPrefix) || strings.Contains(resource, storageClassResourceInfix) {
+		return r
+	}
+	return ""
+}
+
+// Resource names which are only used by ResourceQuota
+const (
+	// ResourceRequestsCPU sum of cpu requests of all Pods in namespace
+	ResourceRequestsCPU ResourceName = "requests.cpu"
+	// ResourceRequestsMemory sum of memory requests of all Pods in namespace
+	ResourceRequestsMemory ResourceName = "requests.memory"
+	// ResourceRequestsStorage sum of storage requests of all PersistentVolumeClaims in namespace
+	ResourceRequestsStorage ResourceName = "requests.storage"
+	// ResourceRequestsEphemeralStorage sum of local ephemeral storage requests of all Pods in namespace
+	ResourceRequestsEphemeralStorage ResourceName = "requests.ephemeral-storage"
+	// ResourceLimitsCPU sum of cpu limits of all Pods in namespace
+	ResourceLimitsCPU ResourceName = "limits.cpu"
+	// ResourceLimitsMemory sum of memory limits of all Pods in namespace
+	ResourceLimitsMemory ResourceName = "limits.memory"
+	// ResourceLimitsEphemeralStorage sum of local ephemeral storage limits of all Pods in namespace
+	ResourceLimitsEphemeralStorage ResourceName = "limits.ephemeral-storage"
+	// ResourcePods the number of Pods which is not terminal
+	ResourcePods ResourceName = "pods"
+	// ResourceServices the number of Services
+	ResourceServices ResourceName = "services"
+	// ResourceServicesNodePorts the number of Services of NodePort type
+	ResourceServicesNodePorts ResourceName = "services.nodeports"
+	// ResourceServicesLoadBalancers the number of Services of LoadBalancer type
+	ResourceServicesLoadBalancers ResourceName = "services.loadbalancers"
+	// ResourceReplicationControllers the number of ReplicationControllers
+	ResourceReplicationControllers ResourceName = "replicationcontrollers"
+	// ResourceQuotas the number of ResourceQuotas
+	ResourceQuotas ResourceName = "resourcequotas"
+	// ResourceSecrets the number of Secrets
+	ResourceSecrets ResourceName = "secrets"
+	// ResourceConfigMaps the number of ConfigMaps
+	ResourceConfigMaps ResourceName = "configmaps"
+	// ResourcePersistentVolumeClaims the number of PersistentVolumeClaims
+	ResourcePersistentVolumeClaims ResourceName = "persistentvolumeclaims"
+)
+
+const (
+	resourceCountPrefix       = "count/"
+	storageClassResourceInfix = ".storageclass.storage.k8s.io/"
+)
+
+var quotaResources = map[ResourceName]bool{
+	ResourceRequestsCPU:              true,
+	ResourceRequestsMemory:           true,
+	ResourceRequestsStorage:          true,
+	ResourceRequestsEphemeralStorage: true,
+	ResourceLimitsCPU:                true,
+	ResourceLimitsMemory:             true,
+	ResourceLimitsEphemeralStorage:   true,
+	ResourcePods:                     true,
+	ResourceServices:                 true,
+	ResourceServicesNodePorts:        true,
+	ResourceServicesLoadBalancers:    true,
+	ResourceReplicationControllers:   true,
+	ResourceQuotas:                   true,
+	ResourceSecrets:                  true,
+	ResourceConfigMaps:               true,
+	ResourcePersistentVolumeClaims:   true,
+}
+
+// ResourceCount the ResourceQuota resource name of the number of objects,
+// resource is plural resource name with group,eg: deployments.apps,jobs.batch,configmaps
+func ResourceCount(resource string) ResourceName {
+	return ResourceName(resourceCountPrefix + resource)
+}
+
+// StorageClassResource the ResourceQuota resource name of PersistentVolumeClaims of StorageClass,
+// name only ResourceRequestsStorage and ResourcePersistentVolumeClaims
+func StorageClassResource(storageClass string, name ResourceName) ResourceName {
+	return ResourceName(storageClass + storageClassResourceInfix + string(name))
+}
+
+// ResourceQuotaScope defines a filter that must match each object tracked by a quota
+type ResourceQuotaScope string
+
+const (
+	// ResourceQuotaScopeTerminating match all Pods that have an active deadline
+	ResourceQuotaScopeTerminating ResourceQuotaScope = "Terminating"
+	// ResourceQuotaScopeNotTerminating match all Pods that do not have an active deadline
+	ResourceQuotaScopeNotTerminating ResourceQuotaScope = "NotTerminating"
+	// ResourceQuotaScopeBestEffort match all Pods that have best effort quality of service
+	ResourceQuotaScopeBestEffort ResourceQuotaScope = "BestEffort"
+	// ResourceQuotaScopeNotBestEffort match all Pods that do not have best effort quality of service
+	ResourceQuotaScopeNotBestEffort ResourceQuotaScope = "NotBestEffort"
+)
+
+var quotaScopes = map[ResourceQuotaScope]v1.ResourceQuotaScope{
+	"Terminating":    v1.ResourceQuotaScopeTerminating,
+	"NotTerminating": v1.ResourceQuotaScopeNotTerminating,
+	"BestEffort":     v1.ResourceQuotaScopeBestEffort,
+	"NotBestEffort":  v1.ResourceQuotaScopeNotBestEffort,
+}
+
+// ToK8s translate into Kubernetes ResourceQuotaScope, return "" when it is not supported
+func (scope ResourceQuotaScope) ToK8s() v1.ResourceQuotaScope {
+	return quotaScopes[scope]
+}
+
+// LimitType is a type of object that is limited
+type LimitType string
+
+const (
+	// LimitTypePod limit that applies to all Pods in a namespace
+	LimitTypePod LimitType = "Pod"
+	// LimitTypeContainer limit that applies to all containers in a namespace
+	LimitTypeContainer LimitType = "Container"
+	// LimitTypePersistentVolumeClaim limit that applies to all PersistentVolumeClaims in a namespace
+	LimitTypePersistentVolumeClaim LimitType = "PersistentVolumeClaim"
+)
+
+var limitTypes = map[LimitType]v1.LimitType{
+	"Pod":                   v1.LimitTypePod,
+	"Container":             v1.LimitTypeContainer,
+	"PersistentVolumeClaim": v1.LimitTypePersistentVolumeClaim,
+}
+
+// ToK8s translate into Kubernetes LimitType, return "" when it is not supported
+func (lt LimitType) ToK8s() v1.LimitType {
+	return limitTypes[lt]
+}
+
+// LimitRangeItem defines a min/max usage limit for any resource that matches on kind,
+// Default and DefaultRequest are only allowed when Type is Container
+type LimitRangeItem struct {
+	Type                 LimitType
+	Max                  map[ResourceName]string
+	Min                  map[ResourceName]string
+	Default              map[ResourceName]string
+	DefaultRequest       map[ResourceName]string
+	MaxLimitRequestRatio map[ResourceName]string
+}
+
 // PersistentVolumeAccessMode volume access mode read,write
 type PersistentVolumeAccessMode string
 
@@ -405,7 +563,8 @@ func (sa ServiceAffinity) ToK8s() v1.ServiceAffinity {
 	return v1.ServiceAffinityNone
 }
 
-// SecretType 'Opaque' or 'kubernetes.io/service-account-token'
+// SecretType 'Opaque', 'kubernetes.io/service-account-token', 'kubernetes.io/dockerconfigjson',
+// 'kubernetes.io/dockercfg', 'kubernetes.io/basic-auth', 'kubernetes.io/ssh-auth' or 'kubernetes.io/tls'
 type SecretType string
 
 const (
@@ -419,11 +578,48 @@ const (
 	// - Secret.Annotations["kubernetes.io/service-account.uid"] - the UID of the ServiceAccount the token identifies
 	// - Secret.Data["token"] - a token that identifies the service account to the API
 	SecretTypeServiceAccountToken SecretType = "kubernetes.io/service-account-token"
+
+	// SecretTypeDockerConfigJSON contains a dockercfg file which is used to pull image, it is created by NewDockerRegistrySecret()
+	//
+	// Required fields:
+	// - Secret.Data[".dockerconfigjson"] - a serialized ~/.docker/config.json file
+	SecretTypeDockerConfigJSON SecretType = "kubernetes.io/dockerconfigjson"
+
+	// SecretTypeDockercfg contains a legacy dockercfg file which is used to pull image
+	//
+	// Required fields:
+	// - Secret.Data[".dockercfg"] - a serialized ~/.dockercfg file
+	SecretTypeDockercfg SecretType = "kubernetes.io/dockercfg"
+
+	// SecretTypeBasicAuth contains data needed for basic authentication, it is created by NewBasicAuthSecret()
+	//
+	// Required at least one of fields:
+	// - Secret.Data["username"] - username used for authentication
+	// - Secret.Data["password"] - password or token needed for authentication
+	SecretTypeBasicAuth SecretType = "kubernetes.io/basic-auth"
+
+	// SecretTypeSSHAuth contains data needed for SSH authentication, it is created by NewSSHAuthSecret()
+	//
+	// Required field:
+	// - Secret.Data["ssh-privatekey"] - private SSH key needed for authentication
+	SecretTypeSSHAuth SecretType = "kubernetes.io/ssh-auth"
+
+	// SecretTypeTLS contains information about a TLS client or server secret, it is created by NewTLSSecret()
+	//
+	// Required fields:
+	// - Secret.Data["tls.key"] - TLS private key
+	// - Secret.Data["tls.crt"] - TLS certificate
+	SecretTypeTLS SecretType = "kubernetes.io/tls"
 )
 
 var secreTypes = map[SecretType]v1.SecretType{
 	"Opaque":                              v1.SecretTypeOpaque,
 	"kubernetes.io/service-account-token": v1.SecretTypeServiceAccountToken,
+	"kubernetes.io/dockerconfigjson":      v1.SecretTypeDockerConfigJson,
+	"kubernetes.io/dockercfg":             v1.SecretTypeDockercfg,
+	"kubernetes.io/basic-auth":            v1.SecretTypeBasicAuth,
+	"kubernetes.io/ssh-auth":              v1.SecretTypeSSHAuth,
+	"kubernetes.io/tls":                   v1.SecretTypeTLS,
 }
 
 // ToK8s translate into Kubernets SecretType
@@ -478,6 +674,29 @@ func (pp PullPolicy) ToK8s() v1.PullPolicy {
 	return v1.PullIfNotPresent
 }
 
+// RestartPolicy describes how the container should be restarted.
+type RestartPolicy string
+
+const (
+	// RestartPolicyAlways always restart the container, it is not allowed in Job
+	RestartPolicyAlways RestartPolicy = "Always"
+	// RestartPolicyOnFailure restart the container when it exit with failure
+	RestartPolicyOnFailure RestartPolicy = "OnFailure"
+	// RestartPolicyNever never restart the container
+	RestartPolicyNever RestartPolicy = "Never"
+)
+
+var restartPolicys = map[RestartPolicy]v1.RestartPolicy{
+	"Always":    v1.RestartPolicyAlways,
+	"OnFailure": v1.RestartPolicyOnFailure,
+	"Never":     v1.RestartPolicyNever,
+}
+
+// ToK8s translate into Kubernetes RestartPolicy, return "" when it is not supported
+func (rp RestartPolicy) ToK8s() v1.RestartPolicy {
+	return restartPolicys[rp]
+}
+
 // PodQOSClass defines the supported qos classes of Pods.
 type PodQOSClass string
 
@@ -645,3 +864,412 @@ var taintEffects = map[TaintEffect]v1.TaintEffect{
 func (te TaintEffect) ToK8s() v1.TaintEffect {
 	return taintEffects[te]
 }
+
+// ConcurrencyPolicy describes how the Job of CronJob will be handled.
+type ConcurrencyPolicy string
+
+const (
+	// ConcurrencyPolicyAllow allows CronJobs to run concurrently.
+	ConcurrencyPolicyAllow ConcurrencyPolicy = "Allow"
+	// ConcurrencyPolicyForbid forbids concurrent runs, skipping next run if previous hasn't finished yet.
+	ConcurrencyPolicyForbid ConcurrencyPolicy = "Forbid"
+	// ConcurrencyPolicyReplace cancels currently running Job and replaces it with a new one.
+	ConcurrencyPolicyReplace ConcurrencyPolicy = "Replace"
+)
+
+var concurrencyPolicys = map[ConcurrencyPolicy]batchv1beta1.ConcurrencyPolicy{
+	"Allow":   batchv1beta1.AllowConcurrent,
+	"Forbid":  batchv1beta1.ForbidConcurrent,
+	"Replace": batchv1beta1.ReplaceConcurrent,
+}
+
+// ToK8s translate into Kubernetes ConcurrencyPolicy, return "" when it is not supported
+func (cp ConcurrencyPolicy) ToK8s() batchv1beta1.ConcurrencyPolicy {
+	return concurrencyPolicys[cp]
+}
+
+// PolicyType describes the NetworkPolicy type
+type PolicyType string
+
+const (
+	// PolicyTypeIngress is a NetworkPolicy that affects ingress traffic on selected pods
+	PolicyTypeIngress PolicyType = "Ingress"
+	// PolicyTypeEgress is a NetworkPolicy that affects egress traffic on selected pods
+	PolicyTypeEgress PolicyType = "Egress"
+)
+
+var policyTypes = map[PolicyType]networkingv1.PolicyType{
+	"Ingress": networkingv1.PolicyTypeIngress,
+	"Egress":  networkingv1.PolicyTypeEgress,
+}
+
+// ToK8s translate into Kubernetes PolicyType, return "" when it is not supported
+func (pt PolicyType) ToK8s() networkingv1.PolicyType {
+	return policyTypes[pt]
+}
+
+// NetworkPeer describes the peer which is allowed by NetworkPolicy,
+// PodLabels and NamespaceLabels select Pods,empty map selects all and nil map is ignored,
+// CIDR selects IP block,Except are the CIDRs excluded from CIDR,
+// CIDR is not allowed to be set with PodLabels or NamespaceLabels at the same time
+type NetworkPeer struct {
+	PodLabels       map[string]string
+	NamespaceLabels map[string]string
+	CIDR            string
+	Except          []string
+}
+
+// NetworkPort describes the port which is allowed by NetworkPolicy,
+// Port is port number or named port of Pod,all ports are allowed when it is 0,
+// Protocol default value 'TCP'
+type NetworkPort struct {
+	Protocol Protocol
+	Port     intstr.IntOrString
+}
+
+// PreemptionPolicy describes a policy for if/when to preempt a pod
+type PreemptionPolicy string
+
+const (
+	// PreemptLowerPriority means that pod can preempt other pods with lower priority
+	PreemptLowerPriority PreemptionPolicy = "PreemptLowerPriority"
+	// PreemptNever means that pod never preempts other pods with lower priority
+	PreemptNever PreemptionPolicy = "Never"
+)
+
+var preemptionPolicies = map[PreemptionPolicy]v1.PreemptionPolicy{
+	"PreemptLowerPriority": v1.PreemptLowerPriority,
+	"Never":                v1.PreemptNever,
+}
+
+// ToK8s translate into Kubernetes PreemptionPolicy, return "" when it is not supported
+func (policy PreemptionPolicy) ToK8s() v1.PreemptionPolicy {
+	return preemptionPolicies[policy]
+}
+
+// Provisioners of StorageClass presets
+const (
+	// ProvisionerCephRBD the in-tree Ceph RBD provisioner
+	ProvisionerCephRBD = "kubernetes.io/rbd"
+	// ProvisionerCephFS the external CephFS provisioner
+	ProvisionerCephFS = "ceph.com/cephfs"
+	// ProvisionerNoProvisioner local volumes are not provisioned dynamically, PersistentVolumes are created by administrator
+	ProvisionerNoProvisioner = "kubernetes.io/no-provisioner"
+)
+
+// CephRBDStorageParameters the parameters of Ceph RBD StorageClass,
+// Monitors,AdminSecretName and UserSecretName are required
+type CephRBDStorageParameters struct {
+	// Monitors Ceph monitors, eg: 10.16.153.105:6789
+	Monitors []string
+	// AdminID Ceph client ID that is capable of creating images in the pool, default is "admin"
+	AdminID string
+	// AdminSecretName Secret name of AdminID, the Secret type must be "kubernetes.io/rbd"
+	AdminSecretName string
+	// AdminSecretNamespace the namespace of AdminSecretName, default is "default"
+	AdminSecretNamespace string
+	// Pool Ceph RBD pool, default is "rbd"
+	Pool string
+	// UserID Ceph client ID that is used to map the RBD image, default is the same as AdminID
+	UserID string
+	// UserSecretName Secret name of UserID, it must exist in the same namespace as PersistentVolumeClaim
+	UserSecretName string
+	// FSType fsType of the image, default is "ext4"
+	FSType string
+	// ImageFeatures Ceph RBD image features, eg: layering
+	ImageFeatures string
+}
+
+// ToMap translate into StorageClass parameters, empty field is ignored
+func (params CephRBDStorageParameters) ToMap() map[string]string {
+	return nonEmptyMap(map[string]string{
+		"monitors":             strings.Join(params.Monitors, ","),
+		"adminId":              params.AdminID,
+		"adminSecretName":      params.AdminSecretName,
+		"adminSecretNamespace": params.AdminSecretNamespace,
+		"pool":                 params.Pool,
+		"userId":               params.UserID,
+		"userSecretName":       params.UserSecretName,
+		"fsType":               params.FSType,
+		"imageFeatures":        params.ImageFeatures,
+	})
+}
+
+// CephFSStorageParameters the parameters of CephFS StorageClass,
+// Monitors,AdminID,AdminSecretName and AdminSecretNamespace are required
+type CephFSStorageParameters struct {
+	// Monitors Ceph monitors, eg: 10.16.153.105:6789
+	Monitors []string
+	// AdminID Ceph client ID that is capable of creating volumes
+	AdminID string
+	// AdminSecretName Secret name of AdminID
+	AdminSecretName string
+	// AdminSecretNamespace the namespace of AdminSecretName
+	AdminSecretNamespace string
+	// ClaimRoot the root path of volumes in CephFS, default is "/volumes/kubernetes"
+	ClaimRoot string
+}
+
+// ToMap translate into StorageClass parameters, empty field is ignored
+func (params CephFSStorageParameters) ToMap() map[string]string {
+	return nonEmptyMap(map[string]string{
+		"monitors":             strings.Join(params.Monitors, ","),
+		"adminId":              params.AdminID,
+		"adminSecretName":      params.AdminSecretName,
+		"adminSecretNamespace": params.AdminSecretNamespace,
+		"claimRoot":            params.ClaimRoot,
+	})
+}
+
+// storageRequiredParameters the required parameters of StorageClass provisioners
+var storageRequiredParameters = map[string][]string{
+	ProvisionerCephRBD: {"monitors", "adminSecretName", "userSecretName"},
+	ProvisionerCephFS:  {"monitors", "adminId", "adminSecretName", "adminSecretNamespace"},
+}
+
+func nonEmptyMap(m map[string]string) map[string]string {
+	for key, value := range m {
+		if value == "" {
+			delete(m, key)
+		}
+	}
+	return m
+}
+
+// HostPathType the type of HostPath volume
+type HostPathType string
+
+const (
+	// HostPathUnset skip the checks before the hostPath volume is mounted
+	HostPathUnset HostPathType = ""
+	// HostPathDirectoryOrCreate an empty directory will be created if nothing exists at the given path
+	HostPathDirectoryOrCreate HostPathType = "DirectoryOrCreate"
+	// HostPathDirectory a directory must exist at the given path
+	HostPathDirectory HostPathType = "Directory"
+	// HostPathFileOrCreate an empty file will be created if nothing exists at the given path
+	HostPathFileOrCreate HostPathType = "FileOrCreate"
+	// HostPathFile a file must exist at the given path
+	HostPathFile HostPathType = "File"
+	// HostPathSocket a UNIX socket must exist at the given path
+	HostPathSocket HostPathType = "Socket"
+	// HostPathCharDev a character device must exist at the given path
+	HostPathCharDev HostPathType = "CharDevice"
+	// HostPathBlockDev a block device must exist at the given path
+	HostPathBlockDev HostPathType = "BlockDevice"
+)
+
+var hostPathTypes = map[HostPathType]v1.HostPathType{
+	"":                  v1.HostPathUnset,
+	"DirectoryOrCreate": v1.HostPathDirectoryOrCreate,
+	"Directory":         v1.HostPathDirectory,
+	"FileOrCreate":      v1.HostPathFileOrCreate,
+	"File":              v1.HostPathFile,
+	"Socket":            v1.HostPathSocket,
+	"CharDevice":        v1.HostPathCharDev,
+	"BlockDevice":       v1.HostPathBlockDev,
+}
+
+// ToK8s translate into Kubernetes HostPathType, the second return is false when it is not supported
+func (hpt HostPathType) ToK8s() (v1.HostPathType, bool) {
+	k8sType, ok := hostPathTypes[hpt]
+	return k8sType, ok
+}
+
+// ISCSIPersistentVolumeSource represents an iSCSI disk
+type ISCSIPersistentVolumeSource struct {
+	// TargetPortal iSCSI target portal, ip or ip:port, the port default is 3260. Required
+	TargetPortal string
+	// IQN target iSCSI qualified name, eg: iqn.2019-01.com.example:storage.disk1. Required
+	IQN string
+	// Lun iSCSI target lun number, 0 to 255
+	Lun int32
+	// ISCSIInterface iSCSI interface name that uses an iSCSI transport, default is "default"(tcp)
+	ISCSIInterface string
+	// FSType filesystem type of the volume, eg: "ext4", "xfs"
+	FSType string
+	// ReadOnly force the volume to be mounted read-only
+	ReadOnly bool
+	// Portals iSCSI target portal list for multipath
+	Portals []string
+	// DiscoveryCHAPAuth whether support iSCSI discovery CHAP authentication
+	DiscoveryCHAPAuth bool
+	// SessionCHAPAuth whether support iSCSI session CHAP authentication
+	SessionCHAPAuth bool
+	// SecretRef CHAP Secret for iSCSI target and initiator authentication,
+	// it is required when DiscoveryCHAPAuth or SessionCHAPAuth is true
+	SecretRef *SecretReference
+	// InitiatorName custom iSCSI initiator name, the connection is created with iface <target portal>:<volume name>
+	InitiatorName string
+}
+
+// FCVolumeSource represents a Fibre Channel volume,
+// TargetWWNs and Lun or WWIDs must be set, but not both at the same time
+type FCVolumeSource struct {
+	// TargetWWNs Fibre Channel target worldwide names
+	TargetWWNs []string
+	// Lun Fibre Channel target lun number
+	Lun *int32
+	// FSType filesystem type of the volume, eg: "ext4", "xfs"
+	FSType string
+	// ReadOnly force the volume to be mounted read-only
+	ReadOnly bool
+	// WWIDs Fibre Channel volume worldwide identifiers
+	WWIDs []string
+}
+
+// CSIPersistentVolumeSource represents storage that is managed by an external CSI volume driver
+type CSIPersistentVolumeSource struct {
+	// Driver the name of the CSI driver, eg: csi.example.com. Required
+	Driver string
+	// VolumeHandle the unique volume name returned by the CSI volume plugin's CreateVolume. Required
+	VolumeHandle string
+	// ReadOnly the value to pass to ControllerPublishVolumeRequest, default is false
+	ReadOnly bool
+	// FSType filesystem type of the volume, eg: "ext4", "xfs"
+	FSType string
+	// VolumeAttributes the attributes of the volume to publish
+	VolumeAttributes map[string]string
+	// ControllerPublishSecretRef Secret which is passed to ControllerPublishVolume and ControllerUnpublishVolume
+	ControllerPublishSecretRef *SecretReference
+	// NodeStageSecretRef Secret which is passed to NodeStageVolume and NodeUnstageVolume
+	NodeStageSecretRef *SecretReference
+	// NodePublishSecretRef Secret which is passed to NodePublishVolume and NodeUnpublishVolume
+	NodePublishSecretRef *SecretReference
+}
+
+// ToK8s translate into Kubernetes SecretReference, return nil when it is nil
+func (ref *SecretReference) ToK8s() *v1.SecretReference {
+	if ref == nil {
+		return nil
+	}
+	return &v1.SecretReference{Name: ref.Name, Namespace: ref.Namespace}
+}
+
+// KeyToPath maps a key of ConfigMap or Secret to a file in volume
+type KeyToPath struct {
+	// Key the key of ConfigMap data or Secret data. Required
+	Key string
+	// Path the relative path of file in volume, it is not allowed to contain '..'. Required
+	Path string
+	// Mode the file permission bits, between 0 and 0777, default use DefaultMode of volume
+	Mode *int32
+}
+
+// ConfigMapVolumeSource mounts ConfigMap into Pod as volume
+type ConfigMapVolumeSource struct {
+	// Name the name of ConfigMap. Required
+	Name string
+	// Items the keys which are projected into volume, all keys are projected when it is empty
+	Items []KeyToPath
+	// DefaultMode the file permission bits, between 0 and 0777, default is 0644
+	DefaultMode *int32
+	// Optional Pod can start when ConfigMap or the keys in Items do not exist
+	Optional bool
+}
+
+// SecretVolumeSource mounts Secret into Pod as volume
+type SecretVolumeSource struct {
+	// SecretName the name of Secret. Required
+	SecretName string
+	// Items the keys which are projected into volume, all keys are projected when it is empty
+	Items []KeyToPath
+	// DefaultMode the file permission bits, between 0 and 0777, default is 0644
+	DefaultMode *int32
+	// Optional Pod can start when Secret or the keys in Items do not exist
+	Optional bool
+}
+
+// DownwardAPIVolumeFile exposes Pod field or container resource as a file,
+// one of FieldPath and Resource must be set
+type DownwardAPIVolumeFile struct {
+	// Path the relative path of file in volume, it is not allowed to contain '..'. Required
+	Path string
+	// FieldPath the Pod field, eg: metadata.name, metadata.namespace, metadata.labels, metadata.annotations
+	FieldPath string
+	// ContainerName the container name of Resource, default is the first container
+	ContainerName string
+	// Resource the container resource, eg: limits.cpu, limits.memory, requests.cpu, requests.memory
+	Resource string
+	// Mode the file permission bits, between 0 and 0777, default use DefaultMode of volume
+	Mode *int32
+}
+
+// ServiceAccountTokenProjection projects a ServiceAccount token which is bound to Pod
+type ServiceAccountTokenProjection struct {
+	// Audience the intended audience of token, default is the identifier of apiserver
+	Audience string
+	// ExpirationSeconds the validity of token, it must be at least 600 seconds, default is 3600 seconds
+	ExpirationSeconds int64
+	// Path the relative path of token file in volume. Required
+	Path string
+}
+
+// VolumeProjection one source of projected volume, only one of ConfigMap, Secret,
+// DownwardAPI and ServiceAccountToken is allowed to be set
+type VolumeProjection struct {
+	// ConfigMap the name of ConfigMap
+	ConfigMap string
+	// Secret the name of Secret
+	Secret string
+	// Items the keys of ConfigMap or Secret which are projected, all keys are projected when it is empty
+	Items []KeyToPath
+	// Optional Pod can start when ConfigMap or Secret does not exist
+	Optional bool
+	// DownwardAPI the Pod fields and container resources which are projected
+	DownwardAPI []DownwardAPIVolumeFile
+	// ServiceAccountToken the ServiceAccount token which is projected
+	ServiceAccountToken *ServiceAccountTokenProjection
+}
+
+// VolumeMount describes a mounting of volume within a container
+type VolumeMount struct {
+	// Name the volume name, it must be declared by SetConfigMapVolume() and the other volume functions. Required
+	Name string
+	// MountPath the path within the container, it must be absolute path. Required
+	MountPath string
+	// SubPath the path within the volume, default is "" (volume's root)
+	SubPath string
+	// ReadOnly mount volume read-only
+	ReadOnly bool
+	// Container the container name which the volume is mounted on, default is the first container
+	Container string
+}
+
+// PodSecurityMode the mode of Pod Security admission, it is the label key suffix of Namespace
+type PodSecurityMode string
+
+const (
+	// PodSecurityEnforce Pods which violate the level are rejected
+	PodSecurityEnforce PodSecurityMode = "enforce"
+	// PodSecurityAudit violations are recorded in audit log, Pods are allowed
+	PodSecurityAudit PodSecurityMode = "audit"
+	// PodSecurityWarn violations are returned to user as warnings, Pods are allowed
+	PodSecurityWarn PodSecurityMode = "warn"
+)
+
+// PodSecurityLevel the level of Pod Security Standards
+type PodSecurityLevel string
+
+const (
+	// PodSecurityPrivileged unrestricted policy
+	PodSecurityPrivileged PodSecurityLevel = "privileged"
+	// PodSecurityBaseline minimally restrictive policy which prevents known privilege escalations
+	PodSecurityBaseline PodSecurityLevel = "baseline"
+	// PodSecurityRestricted heavily restricted policy which follows Pod hardening best practices
+	PodSecurityRestricted PodSecurityLevel = "restricted"
+)
+
+// DrainOptions the options of Node.Drain()
+type DrainOptions struct {
+	// Timeout the max time of draining, it includes waiting for PodDisruptionBudget, 0 means no timeout
+	Timeout time.Duration
+	// GracePeriodSeconds the termination grace period of evicted Pods, default use Pod setting
+	GracePeriodSeconds *int64
+	// DeleteEmptyDirData continue even if there are Pods using emptyDir, the data is deleted when Pod is evicted
+	DeleteEmptyDirData bool
+	// Force continue even if there are Pods which are not managed by ReplicaSet, StatefulSet, Job and so on,
+	// they will not be recreated on other nodes
+	Force bool
+	// PodSelector only evict the Pods which match the label selector, eg: app=nginx
+	PodSelector string
+}
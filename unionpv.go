@@ -2,6 +2,7 @@ package beku
 
 import (
 	"errors"
+	"fmt"
 	"reflect"
 
 	"k8s.io/api/core/v1"
@@ -111,6 +112,68 @@ func (un *UnionPV) SetRBD(rbd *RBDPersistentVolumeSource) *UnionPV {
 	return un
 }
 
+// SetCephFS set PersistentVolume volume source is CephFS
+func (un *UnionPV) SetCephFS(cephFs *CephFSPersistentVolumeSource) *UnionPV {
+	un.pv.SetCephFS(cephFs)
+	return un
+}
+
+// SetHostPath set PersistentVolume volume source is hostPath, access mode must be ReadWriteOnce
+func (un *UnionPV) SetHostPath(path string, hostPathType ...HostPathType) *UnionPV {
+	un.pv.SetHostPath(path, hostPathType...)
+	return un
+}
+
+// SetLocal set PersistentVolume volume source is local, access mode must be ReadWriteOnce,
+// you must call SetNodes() or SetRequiredORNodeAffinity() to bind it to nodes
+func (un *UnionPV) SetLocal(path string, fsType ...string) *UnionPV {
+	un.pv.SetLocal(path, fsType...)
+	return un
+}
+
+// SetNodes bind PersistentVolume to nodes by hostname
+func (un *UnionPV) SetNodes(hostnames ...string) *UnionPV {
+	un.pv.SetNodes(hostnames...)
+	return un
+}
+
+// SetRequiredORNodeAffinity set PersistentVolume node affinity, many key do OR operation
+func (un *UnionPV) SetRequiredORNodeAffinity(key string, value []string, operator NodeSelectorOperator) *UnionPV {
+	un.pv.SetRequiredORNodeAffinity(key, value, operator)
+	return un
+}
+
+// SetRequiredAndNodeAffinity set PersistentVolume node affinity, many key do AND operation
+func (un *UnionPV) SetRequiredAndNodeAffinity(key string, value []string, operator NodeSelectorOperator) *UnionPV {
+	un.pv.SetRequiredAndNodeAffinity(key, value, operator)
+	return un
+}
+
+// SetISCSI set PersistentVolume volume source is iSCSI
+func (un *UnionPV) SetISCSI(iscsi *ISCSIPersistentVolumeSource) *UnionPV {
+	un.pv.SetISCSI(iscsi)
+	return un
+}
+
+// SetGlusterFS set PersistentVolume volume source is GlusterFS,
+// the Endpoints of GlusterFS servers is in the namespace of PersistentVolumeClaim by default
+func (un *UnionPV) SetGlusterFS(endpoints, path string, readOnly bool, endpointsNamespace ...string) *UnionPV {
+	un.pv.SetGlusterFS(endpoints, path, readOnly, endpointsNamespace...)
+	return un
+}
+
+// SetFC set PersistentVolume volume source is Fibre Channel
+func (un *UnionPV) SetFC(fc *FCVolumeSource) *UnionPV {
+	un.pv.SetFC(fc)
+	return un
+}
+
+// SetCSI set PersistentVolume volume source is CSI driver
+func (un *UnionPV) SetCSI(csi *CSIPersistentVolumeSource) *UnionPV {
+	un.pv.SetCSI(csi)
+	return un
+}
+
 // Release release UnionPV on Kubernetes
 func (un *UnionPV) Release() (pv *v1.PersistentVolume, pvc *v1.PersistentVolumeClaim, err error) {
 	pv, pvc, err = un.Finish()
@@ -148,6 +211,15 @@ func (un *UnionPV) verify() {
 	if !verifyString(un.pvc.GetNamespace()) {
 		un.SetNamespace("default")
 	}
+	//check volume mode, pv and pvc must be the same volume mode, otherwise they can't be bound
+	if mode := un.pvc.pvc.Spec.VolumeMode; mode != nil && *mode == v1.PersistentVolumeBlock {
+		sources := pvSources(un.pv.pv.Spec.PersistentVolumeSource)
+		if len(sources) == 1 && !pvBlockSources[sources[0]] {
+			un.err = fmt.Errorf("UnionPV, volume source %s does not support volume mode Block", sources[0])
+			return
+		}
+	}
+	un.pv.pv.Spec.VolumeMode = un.pvc.pvc.Spec.VolumeMode
 
 	if pvlabels == nil {
 		pvlabels = map[string]string{"name": pvname}
@@ -0,0 +1,259 @@
+package beku
+
+import (
+	"errors"
+	"fmt"
+
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/api/rbac/v1beta1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+)
+
+// UnionRBAC output ServiceAccount, ClusterRole(or Role) and ClusterRoleBinding(or RoleBinding)
+// all of them use the same name, and the binding reference the ServiceAccount and the role.
+type UnionRBAC struct {
+	sa         *ServiceAccount
+	rules      []v1beta1.PolicyRule
+	namespaced bool
+	err        error
+}
+
+// NewUnionRBAC create ServiceAccount,ClusterRole,ClusterRoleBinding and error
+// and chain function call begin with this function.
+func NewUnionRBAC() *UnionRBAC {
+	return &UnionRBAC{sa: NewSa()}
+}
+
+// Finish Chain function call end with this function
+// return ServiceAccount, role, binding and error.
+// role is *v1beta1.ClusterRole, binding is *v1beta1.ClusterRoleBinding by default,
+// role is *v1beta1.Role, binding is *v1beta1.RoleBinding when call SetNamespaced()
+// In the function, it will check necessary parametersainput the default field
+func (un *UnionRBAC) Finish() (sa *corev1.ServiceAccount, role, binding runtime.Object, err error) {
+	un.verify()
+	if un.err != nil {
+		err = un.err
+		return
+	}
+	sa, err = un.sa.Finish()
+	if err != nil {
+		return
+	}
+	name, namespace := un.GetName(), un.GetNamespace()
+	if un.namespaced {
+		if role, err = NewRole().SetNamespaceAndName(namespace, name).setRules(un.rules).Finish(); err != nil {
+			return nil, nil, nil, err
+		}
+		if binding, err = NewRoleBinding().SetNamespaceAndName(namespace, name).Subject(name, SA, namespace).SetRoleRef(name).Finish(); err != nil {
+			return nil, nil, nil, err
+		}
+		return
+	}
+	if role, err = NewClusterRole().SetName(name).setRules(un.rules).Finish(); err != nil {
+		return nil, nil, nil, err
+	}
+	if binding, err = NewClusterRoleBinding().SetName(name).Subject(name, SA, namespace).SetRoleRef(name).Finish(); err != nil {
+		return nil, nil, nil, err
+	}
+	return
+}
+
+// SetNamespaceAndName set ServiceAccount namespace and name, set role and binding name
+// namespace default is 'default'
+func (un *UnionRBAC) SetNamespaceAndName(namespace, name string) *UnionRBAC {
+	un.sa.SetNamespaceAndName(namespace, name)
+	return un
+}
+
+// GetName get ServiceAccount name, it can be used to Deployment.SetServiceAccount()
+func (un *UnionRBAC) GetName() string {
+	return un.sa.sa.GetName()
+}
+
+// GetNamespace get ServiceAccount namespace
+func (un *UnionRBAC) GetNamespace() string {
+	return un.sa.sa.GetNamespace()
+}
+
+// SetNamespaced use namespaced Role and RoleBinding instead of ClusterRole and ClusterRoleBinding,
+// the ServiceAccount only has permissions in it's own namespace.
+func (un *UnionRBAC) SetNamespaced() *UnionRBAC {
+	un.namespaced = true
+	return un
+}
+
+// SetRole set role rule
+// verbs is func method. such as "get", "watch", "list","create", "delete" ..., you can set "*" if you want to use all the func method
+// apiGroups is resource apiGroup. such as "", "apps", "rbac.authorization.k8s.io"... , you can set "*" if you want to use all the resource apiGroup
+// resources is resources object. such as "daemonsets", "deployments","replicasets", you can set "*" if you want to use all the resource object.
+func (un *UnionRBAC) SetRole(verbs, apiGroups, resources []string) *UnionRBAC {
+	return un.SetRule(PolicyRule{Verbs: verbs, APIGroups: apiGroups, Resources: resources})
+}
+
+// SetRule set role rule which supports resourceNames,
+// nonResourceURLs is not allowed when call SetNamespaced()
+func (un *UnionRBAC) SetRule(rule PolicyRule) *UnionRBAC {
+	un.rules = append(un.rules, rule.ToK8s())
+	return un
+}
+
+// SetPreset set built-in rules:PresetReadOnly,PresetNamespaceEditor,PresetSecretsReader
+func (un *UnionRBAC) SetPreset(preset RolePreset) *UnionRBAC {
+	rules := preset.Rules()
+	if len(rules) <= 0 {
+		un.error(fmt.Errorf("SetPreset err,preset %s is not supported", preset))
+		return un
+	}
+	un.rules = append(un.rules, rules...)
+	return un
+}
+
+// Release release UnionRBAC on Kubernetes
+// ServiceAccount, role and binding are released in order,
+// if one of them failed, the object that has been released will be deleted.
+func (un *UnionRBAC) Release() (sa *corev1.ServiceAccount, role, binding runtime.Object, err error) {
+	sa, role, binding, err = un.Finish()
+	if err != nil {
+		return
+	}
+	client, err := GetKubeClient()
+	if err != nil {
+		return
+	}
+	sa, err = client.CoreV1().ServiceAccounts(sa.GetNamespace()).Create(sa)
+	if err != nil {
+		return
+	}
+	switch r := role.(type) {
+	case *v1beta1.Role:
+		role, err = client.RbacV1beta1().Roles(r.GetNamespace()).Create(r)
+	case *v1beta1.ClusterRole:
+		role, err = client.RbacV1beta1().ClusterRoles().Create(r)
+	}
+	if err != nil {
+		un.rollback(false)
+		return
+	}
+	switch b := binding.(type) {
+	case *v1beta1.RoleBinding:
+		binding, err = client.RbacV1beta1().RoleBindings(b.GetNamespace()).Create(b)
+	case *v1beta1.ClusterRoleBinding:
+		binding, err = client.RbacV1beta1().ClusterRoleBindings().Create(b)
+	}
+	if err != nil {
+		un.rollback(true)
+	}
+	return
+}
+
+// Apply apply ServiceAccount, role and binding on Kubernetes in order,
+// they will be updated when they exist and will be created when they do not exist.
+func (un *UnionRBAC) Apply() (sa *corev1.ServiceAccount, role, binding runtime.Object, err error) {
+	sa, role, binding, err = un.Finish()
+	if err != nil {
+		return
+	}
+	client, err := GetKubeClient()
+	if err != nil {
+		return
+	}
+	if _, err = client.CoreV1().ServiceAccounts(sa.GetNamespace()).Get(sa.GetName(), metav1.GetOptions{}); err != nil {
+		sa, err = client.CoreV1().ServiceAccounts(sa.GetNamespace()).Create(sa)
+	} else {
+		sa, err = client.CoreV1().ServiceAccounts(sa.GetNamespace()).Update(sa)
+	}
+	if err != nil {
+		return
+	}
+	switch r := role.(type) {
+	case *v1beta1.Role:
+		role, err = NewRole().Replace(r).Apply()
+	case *v1beta1.ClusterRole:
+		role, err = NewClusterRole().Replace(r).Apply()
+	}
+	if err != nil {
+		return
+	}
+	switch b := binding.(type) {
+	case *v1beta1.RoleBinding:
+		binding, err = NewRoleBinding().Replace(b).Apply()
+	case *v1beta1.ClusterRoleBinding:
+		binding, err = NewClusterRoleBinding().Replace(b).Apply()
+	}
+	return
+}
+
+// Delete delete ServiceAccount, role and binding on Kubernetes
+// the object that does not exist will be skipped.
+func (un *UnionRBAC) Delete() error {
+	un.verify()
+	if un.err != nil {
+		return un.err
+	}
+	return un.delete(true, true)
+}
+
+// rollback delete released ServiceAccount and role when Release failed
+func (un *UnionRBAC) rollback(withRole bool) {
+	un.delete(withRole, false)
+}
+
+func (un *UnionRBAC) delete(withRole, withBinding bool) error {
+	client, err := GetKubeClient()
+	if err != nil {
+		return err
+	}
+	var (
+		errs      []error
+		name, ns  = un.GetName(), un.GetNamespace()
+		delOption = &metav1.DeleteOptions{}
+	)
+	if withBinding {
+		if un.namespaced {
+			errs = append(errs, client.RbacV1beta1().RoleBindings(ns).Delete(name, delOption))
+		} else {
+			errs = append(errs, client.RbacV1beta1().ClusterRoleBindings().Delete(name, delOption))
+		}
+	}
+	if withRole {
+		if un.namespaced {
+			errs = append(errs, client.RbacV1beta1().Roles(ns).Delete(name, delOption))
+		} else {
+			errs = append(errs, client.RbacV1beta1().ClusterRoles().Delete(name, delOption))
+		}
+	}
+	errs = append(errs, client.CoreV1().ServiceAccounts(ns).Delete(name, delOption))
+	for _, err := range errs {
+		if err != nil && !apierrors.IsNotFound(err) {
+			return fmt.Errorf("UnionRBAC delete err:%v", err)
+		}
+	}
+	return nil
+}
+
+// verify check UnionRBAC necessary value, input the default field and input related data.
+func (un *UnionRBAC) verify() {
+	if un.err != nil {
+		return
+	}
+	if !verifyString(un.GetName()) {
+		un.err = errors.New("UnionRBAC name is not allowed to be empty")
+		return
+	}
+	if len(un.rules) <= 0 {
+		un.err = errors.New("UnionRBAC rules is not allowed to be empty,you can call SetRole() input")
+		return
+	}
+	if !verifyString(un.GetNamespace()) {
+		un.sa.SetNamespaceAndName("default", un.GetName())
+	}
+}
+
+func (un *UnionRBAC) error(err error) {
+	if un.err != nil {
+		return
+	}
+	un.err = err
+}